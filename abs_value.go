@@ -0,0 +1,99 @@
+package algebrain
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+)
+
+// Default bounds for an AbsValueSolveGenerator.
+const (
+	DefaultAbsValueMaxValue       = 10
+	DefaultAbsValueMaxCoefficient = 3
+)
+
+// An AbsValueSolveGenerator generates Samples with queries
+// like "solve |x-3|=5", expecting "x=8 or x=-2".
+//
+// Equations have the form |x-c|=r, where c and r are
+// positive, or the scaled form |a*x+b|=a*r.
+// Both solutions are always integers, and the larger
+// solution is listed first.
+// The other order is accepted as an AltResponse.
+type AbsValueSolveGenerator struct {
+	// VarName is the variable to use.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// MaxValue is the maximum center and radius, i.e. the
+	// maximum of c and r.
+	// If this is 0, DefaultAbsValueMaxValue is used.
+	MaxValue int
+
+	// MaxCoefficient is the maximum coefficient a in the
+	// scaled form.
+	// If this is 1, only the |x-c|=r form is generated.
+	// If this is 0, DefaultAbsValueMaxCoefficient is used.
+	MaxCoefficient int
+}
+
+// Generate generates an absolute value equation sample.
+func (a *AbsValueSolveGenerator) Generate() *Sample {
+	varName := a.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	maxValue := a.MaxValue
+	if maxValue == 0 {
+		maxValue = DefaultAbsValueMaxValue
+	}
+	maxCoeff := a.MaxCoefficient
+	if maxCoeff == 0 {
+		maxCoeff = DefaultAbsValueMaxCoefficient
+	}
+
+	radius := rand.Intn(maxValue) + 1
+	var inner polynomial
+	if maxCoeff > 1 && rand.Intn(2) == 0 {
+		// |a*x+b|=a*r, which is |x-c|=r with c=-b/a.
+		coeff := rand.Intn(maxCoeff-1) + 2
+		center := rand.Intn(2*maxValue+1) - maxValue
+		inner = polynomial{-coeff * center, coeff}
+		radius *= coeff
+	} else {
+		inner = polynomial{-(rand.Intn(maxValue) + 1), 1}
+	}
+
+	x1, x2, err := solveAbsLinear(inner[1], inner[0], radius)
+	if err != nil {
+		panic(err)
+	}
+	return &Sample{
+		Query:    fmt.Sprintf("solve |%s|=%d", inner.String(varName), radius),
+		Response: fmt.Sprintf("%s=%d or %s=%d", varName, x1, varName, x2),
+		AltResponses: []string{
+			fmt.Sprintf("%s=%d or %s=%d", varName, x2, varName, x1),
+		},
+	}
+}
+
+// solveAbsLinear solves |a*x+b|=c, returning the larger
+// solution first.
+// It fails unless both solutions are distinct integers.
+func solveAbsLinear(a, b, c int) (x1, x2 int, err error) {
+	if c < 0 {
+		return 0, 0, errors.New("solve absolute value: negative radius")
+	} else if c == 0 {
+		return 0, 0, errors.New("solve absolute value: zero radius")
+	} else if a == 0 {
+		return 0, 0, errors.New("solve absolute value: zero coefficient")
+	}
+	if (c-b)%a != 0 || (-c-b)%a != 0 {
+		return 0, 0, errors.New("solve absolute value: non-integer solutions")
+	}
+	x1, x2 = (c-b)/a, (-c-b)/a
+	if x1 < x2 {
+		x1, x2 = x2, x1
+	}
+	return x1, x2, nil
+}
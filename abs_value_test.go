@@ -0,0 +1,68 @@
+package algebrain
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestAbsValueSolveGenerator(t *testing.T) {
+	queryExpr := regexp.MustCompile(`^solve \|(.*)\|=([0-9]+)$`)
+	responseExpr := regexp.MustCompile(`^x=(-?[0-9]+) or x=(-?[0-9]+)$`)
+	gen := &AbsValueSolveGenerator{}
+	var scaled bool
+	for i := 0; i < 1000; i++ {
+		sample := gen.Generate()
+		queryMatch := queryExpr.FindStringSubmatch(sample.Query)
+		responseMatch := responseExpr.FindStringSubmatch(sample.Response)
+		if queryMatch == nil || responseMatch == nil {
+			t.Fatalf("unexpected sample: %s -> %s", sample.Query, sample.Response)
+		}
+		inner, err := mathexpr.ParseString(queryMatch[1])
+		if err != nil {
+			t.Fatalf("%s: %s", sample.Query, err)
+		}
+		if _, ok := inner.(*mathexpr.BinaryOp); ok && queryMatch[1][0] != 'x' {
+			scaled = true
+		}
+		radius, _ := strconv.Atoi(queryMatch[2])
+		x1, _ := strconv.Atoi(responseMatch[1])
+		x2, _ := strconv.Atoi(responseMatch[2])
+		if x1 <= x2 {
+			t.Fatalf("%s: solutions out of order: %s", sample.Query, sample.Response)
+		}
+		swapped := "x=" + responseMatch[2] + " or x=" + responseMatch[1]
+		if len(sample.AltResponses) != 1 || sample.AltResponses[0] != swapped {
+			t.Fatalf("%s: unexpected alternatives: %v", sample.Query, sample.AltResponses)
+		}
+		for _, x := range []int{x1, x2} {
+			val, err := mathexpr.Evaluate(inner, map[string]float64{"x": float64(x)})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if math.Abs(val) != float64(radius) {
+				t.Fatalf("%s: x=%d gives |%f|", sample.Query, x, val)
+			}
+		}
+	}
+	if !scaled {
+		t.Error("never generated a scaled equation")
+	}
+}
+
+func TestSolveAbsLinear(t *testing.T) {
+	if x1, x2, err := solveAbsLinear(1, -3, 5); err != nil || x1 != 8 || x2 != -2 {
+		t.Errorf("unexpected solutions: %d, %d, %v", x1, x2, err)
+	}
+	if x1, x2, err := solveAbsLinear(-2, 6, 4); err != nil || x1 != 5 || x2 != 1 {
+		t.Errorf("unexpected solutions: %d, %d, %v", x1, x2, err)
+	}
+	for _, args := range [][3]int{{1, -3, -5}, {1, -3, 0}, {0, 1, 2}, {2, 1, 2}} {
+		if _, _, err := solveAbsLinear(args[0], args[1], args[2]); err == nil {
+			t.Errorf("expected error for %v", args)
+		}
+	}
+}
@@ -0,0 +1,200 @@
+package algebrain
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anynet/anyrnn"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+)
+
+// Bounds of the values binned by a Histogram.
+const (
+	histogramMin = -5
+	histogramMax = 5
+)
+
+// A Histogram counts values in equally sized bins which
+// cover the range [-5, 5].
+// Values outside of this range are counted in the first
+// or last bin, and NaNs are not counted.
+type Histogram struct {
+	Counts []int
+}
+
+// NewHistogram creates an empty Histogram with numBins
+// bins.
+func NewHistogram(numBins int) *Histogram {
+	if numBins <= 0 {
+		panic("number of bins must be positive")
+	}
+	return &Histogram{Counts: make([]int, numBins)}
+}
+
+// Add counts the values.
+func (h *Histogram) Add(values ...float64) {
+	for _, x := range values {
+		if !math.IsNaN(x) {
+			h.Counts[h.Bin(x)]++
+		}
+	}
+}
+
+// Bin gets the index of the bin which counts x.
+func (h *Histogram) Bin(x float64) int {
+	frac := (x - histogramMin) / (histogramMax - histogramMin)
+	if frac <= 0 {
+		return 0
+	} else if frac >= 1 {
+		return len(h.Counts) - 1
+	}
+	return essentials.MinInt(int(frac*float64(len(h.Counts))), len(h.Counts)-1)
+}
+
+// BinRange gets the range of values covered by bin i.
+func (h *Histogram) BinRange(i int) (min, max float64) {
+	width := float64(histogramMax-histogramMin) / float64(len(h.Counts))
+	return histogramMin + width*float64(i), histogramMin + width*float64(i+1)
+}
+
+// Total gets the number of counted values.
+func (h *Histogram) Total() int {
+	var res int
+	for _, c := range h.Counts {
+		res += c
+	}
+	return res
+}
+
+// Fraction gets the fraction of the counted values which
+// are in bin i, or 0 if no values have been counted.
+func (h *Histogram) Fraction(i int) float64 {
+	total := h.Total()
+	if total == 0 {
+		return 0
+	}
+	return float64(h.Counts[i]) / float64(total)
+}
+
+// ActivationHistogram runs the samples through the
+// network, as in training, and builds a histogram of the
+// outputs of each layer.
+//
+// Layers are named by their component and their index in
+// it, e.g. "Encoder.Forward.0", "Decoder.1", or "Output.0".
+// The encoder's combined outputs are "Encoder.Mixer".
+//
+// A layer with nearly all of its activations in one bin
+// may have dead or saturated neurons.
+func (n *Network) ActivationHistogram(samples []*Sample, numBins int) map[string]*Histogram {
+	r := &activationRecorder{Histograms: map[string]*Histogram{}, NumBins: numBins}
+	recorded := &Network{
+		Encoder: &anyrnn.Bidir{
+			Forward:  r.Block("Encoder.Forward", n.Encoder.Forward),
+			Backward: r.Block("Encoder.Backward", n.Encoder.Backward),
+			Mixer:    &recordedMixer{Mixer: n.Encoder.Mixer, Name: r.Add("Encoder.Mixer"), R: r},
+		},
+		Output: r.Net("Output", n.Output),
+	}
+	align := *n.Align
+	align.Decoder = r.Block("Decoder", n.Align.Decoder)
+	recorded.Align = &align
+
+	for i := 0; i < len(samples); i += evaluationBatchSize {
+		bs := evaluationBatchSize
+		if i+bs > len(samples) {
+			bs = len(samples) - i
+		}
+		batch, err := n.makeBatch(samples[i:i+bs], false)
+		if err != nil {
+			panic(err)
+		}
+		recorded.applyTeacherForced(batch.EncIn, batch.DecIn).Output()
+	}
+	return r.Histograms
+}
+
+// An activationRecorder builds a Histogram for each
+// wrapped layer.
+type activationRecorder struct {
+	Histograms map[string]*Histogram
+	NumBins    int
+
+	lock sync.Mutex
+}
+
+// Add creates the Histogram for a layer.
+func (a *activationRecorder) Add(name string) string {
+	a.Histograms[name] = NewHistogram(a.NumBins)
+	return name
+}
+
+// Block wraps a block, or each block of a stack, so that
+// its outputs are recorded.
+func (a *activationRecorder) Block(name string, b anyrnn.Block) anyrnn.Block {
+	if stack, ok := b.(anyrnn.Stack); ok {
+		res := make(anyrnn.Stack, len(stack))
+		for i, layer := range stack {
+			res[i] = &recordedBlock{Block: layer, Name: a.Add(fmt.Sprintf("%s.%d", name, i)), R: a}
+		}
+		return res
+	}
+	return &recordedBlock{Block: b, Name: a.Add(name), R: a}
+}
+
+// Net wraps each layer of a net so that its outputs are
+// recorded.
+func (a *activationRecorder) Net(name string, net anynet.Net) anynet.Net {
+	res := make(anynet.Net, len(net))
+	for i, layer := range net {
+		res[i] = &recordedLayer{Layer: layer, Name: a.Add(fmt.Sprintf("%s.%d", name, i)), R: a}
+	}
+	return res
+}
+
+func (a *activationRecorder) Record(name string, v anyvec.Vector) {
+	values := vectorFloats(v)
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.Histograms[name].Add(values...)
+}
+
+type recordedBlock struct {
+	anyrnn.Block
+	Name string
+	R    *activationRecorder
+}
+
+func (r *recordedBlock) Step(s anyrnn.State, in anyvec.Vector) anyrnn.Res {
+	res := r.Block.Step(s, in)
+	r.R.Record(r.Name, res.Output())
+	return res
+}
+
+type recordedLayer struct {
+	anynet.Layer
+	Name string
+	R    *activationRecorder
+}
+
+func (r *recordedLayer) Apply(in anydiff.Res, batchSize int) anydiff.Res {
+	res := r.Layer.Apply(in, batchSize)
+	r.R.Record(r.Name, res.Output())
+	return res
+}
+
+type recordedMixer struct {
+	anynet.Mixer
+	Name string
+	R    *activationRecorder
+}
+
+func (r *recordedMixer) Mix(in1, in2 anydiff.Res, batch int) anydiff.Res {
+	res := r.Mixer.Mix(in1, in2, batch)
+	r.R.Record(r.Name, res.Output())
+	return res
+}
@@ -0,0 +1,89 @@
+package algebrain
+
+import (
+	"math"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestHistogram(t *testing.T) {
+	h := NewHistogram(10)
+	h.Add(-10, -5, -4.5, 0, 0.5, 4.99, 5, 10, math.NaN())
+	expected := []int{3, 0, 0, 0, 0, 2, 0, 0, 0, 3}
+	if !reflect.DeepEqual(h.Counts, expected) {
+		t.Errorf("expected counts %v but got %v", expected, h.Counts)
+	}
+	if h.Total() != 8 {
+		t.Errorf("expected total 8 but got %d", h.Total())
+	}
+	if h.Fraction(5) != 0.25 {
+		t.Errorf("expected fraction 0.25 but got %f", h.Fraction(5))
+	}
+	if min, max := h.BinRange(5); min != 0 || max != 1 {
+		t.Errorf("expected range [0, 1] but got [%f, %f]", min, max)
+	}
+	if NewHistogram(3).Fraction(0) != 0 {
+		t.Error("expected fraction 0 for an empty histogram")
+	}
+}
+
+func TestNetworkActivationHistogram(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	samples := []*Sample{
+		{Query: "evaluate 1+2", Response: "Result: 3"},
+		{Query: "expand (x+1)^2", Response: "x^2+2*x+1"},
+		{Query: "d/dx x", Response: "1"},
+	}
+	const numBins = 20
+	histograms := net.ActivationHistogram(samples, numBins)
+
+	var names []string
+	for name := range histograms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	expectedNames := []string{"Decoder.0", "Decoder.1", "Encoder.Backward.0",
+		"Encoder.Backward.1", "Encoder.Forward.0", "Encoder.Forward.1", "Encoder.Mixer",
+		"Output.0", "Output.1"}
+	if !reflect.DeepEqual(names, expectedNames) {
+		t.Fatalf("expected layers %v but got %v", expectedNames, names)
+	}
+
+	var encSteps, decSteps int
+	for _, s := range samples {
+		encSteps += len(s.Query)
+		decSteps += len(s.Response) + 1
+	}
+	vocabSize := net.Tokenizer.VocabSize()
+	expectedTotals := map[string]int{
+		"Encoder.Forward.0":  encSteps * 0x100,
+		"Encoder.Forward.1":  encSteps * encodedSize,
+		"Encoder.Backward.0": encSteps * 0x100,
+		"Encoder.Backward.1": encSteps * encodedSize,
+		"Encoder.Mixer":      encSteps * encodedSize,
+		"Decoder.0":          decSteps * 0x100,
+		"Decoder.1":          decSteps * querySize,
+		"Output.0":           decSteps * vocabSize,
+		"Output.1":           decSteps * vocabSize,
+	}
+	for name, h := range histograms {
+		if len(h.Counts) != numBins {
+			t.Errorf("%s: expected %d bins but got %d", name, numBins, len(h.Counts))
+		}
+		if h.Total() != expectedTotals[name] {
+			t.Errorf("%s: expected %d values but got %d", name, expectedTotals[name], h.Total())
+		}
+	}
+
+	// LSTM outputs are in (-1, 1), so the outer bins are
+	// unused, while log probabilities are never positive.
+	if h := histograms["Decoder.1"]; h.Counts[0] != 0 || h.Counts[numBins-1] != 0 {
+		t.Errorf("unexpected LSTM counts: %v", h.Counts)
+	}
+	if h := histograms["Output.1"]; h.Counts[numBins/2] != 0 {
+		t.Errorf("unexpected log probability counts: %v", h.Counts)
+	}
+}
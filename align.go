@@ -0,0 +1,127 @@
+package algebrain
+
+import (
+	"math"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anyvec"
+)
+
+// QueryAligned is like Query, but it also returns, for
+// each rune of the response, the index of the query rune
+// which most influenced it.
+//
+// The alignment is approximated with input-gradient
+// saliency rather than read from the attention weights.
+// For each response token, the log probability of that
+// token (with teacher forcing) is differentiated with
+// respect to the input vectors, and each input token is
+// scored by the absolute dot product of its gradient and
+// its input vector.
+// With one-hot inputs, this is the absolute gradient of
+// the token's own entry.
+// The input token with the highest score wins.
+// Runes from the same token share an alignment, and each
+// query token is identified with the index of its first
+// rune.
+//
+// Indices refer to the query after preprocessing.
+// Every index is -1 if the query is empty.
+//
+// It panics if the query cannot be tokenized.
+func (n *Network) QueryAligned(q string) (string, []int) {
+	response := n.Query(q)
+	return response, n.alignResponse(n.Preprocessor.Apply(q), response)
+}
+
+// alignResponse computes the alignment for an already
+// decoded response to a preprocessed query.
+func (n *Network) alignResponse(query, response string) []int {
+	queryTokens, err := n.Tokenizer.Encode(query)
+	if err != nil {
+		panic(err)
+	}
+	respTokens, err := n.Tokenizer.Encode(response)
+	if err != nil {
+		panic(err)
+	}
+	runeOffsets := make([]int, len(queryTokens))
+	var offset int
+	for i, tok := range queryTokens {
+		runeOffsets[i] = offset
+		offset += len([]rune(n.Tokenizer.Decode([]int{tok})))
+	}
+
+	var alignment []int
+	for i, tok := range respTokens {
+		best := -1
+		if len(queryTokens) > 0 {
+			best = runeOffsets[n.salientInput(query, respTokens, i)]
+		}
+		for range []rune(n.Tokenizer.Decode([]int{tok})) {
+			alignment = append(alignment, best)
+		}
+	}
+	return alignment
+}
+
+// salientInput finds the index of the query token with
+// the largest influence on the log probability of the
+// response token at index respIdx.
+func (n *Network) salientInput(query string, respTokens []int, respIdx int) int {
+	c := n.creator()
+	sample := &Sample{Query: query, Response: n.Tokenizer.Decode(respTokens)}
+	inVecs, err := n.inputSequence(sample)
+	if err != nil {
+		panic(err)
+	}
+	decIn, err := sample.DecoderInVectors(c, n.Tokenizer)
+	if err != nil {
+		panic(err)
+	}
+
+	inVars := make([]*anydiff.Var, len(inVecs))
+	inBatches := make([]*anyseq.ResBatch, len(inVecs))
+	for i, v := range inVecs {
+		inVars[i] = anydiff.NewVar(v.Copy())
+		inBatches[i] = &anyseq.ResBatch{Packed: inVars[i], Present: []bool{true}}
+	}
+	masks := make([]anyvec.Vector, len(decIn))
+	vocabSize := n.Tokenizer.VocabSize()
+	for i := range masks {
+		if i == respIdx {
+			masks[i] = oneHotVector(c, respTokens[respIdx], vocabSize)
+		} else {
+			masks[i] = c.MakeVector(vocabSize)
+		}
+	}
+
+	out := n.applyTeacherForced(anyseq.ResSeq(c, inBatches),
+		anyseq.ConstSeqList(c, [][]anyvec.Vector{decIn}))
+	masked := anyseq.MapN(func(num int, v ...anydiff.Res) anydiff.Res {
+		return anydiff.Mul(v[0], v[1])
+	}, out, anyseq.ConstSeqList(c, [][]anyvec.Vector{masks}))
+	objective := anydiff.Sum(anyseq.Sum(masked))
+
+	grad := anydiff.NewGrad(inVars...)
+	objective.Propagate(c.MakeVectorData(c.MakeNumericList([]float64{1})), grad)
+
+	bestIdx, bestScore := 0, math.Inf(-1)
+	for i, v := range inVars {
+		tokenIdx := i
+		if n.ReverseInput {
+			tokenIdx = len(inVars) - (i + 1)
+		}
+		var score float64
+		input := vectorFloats(inVecs[i])
+		for j, g := range vectorFloats(grad[v]) {
+			score += g * input[j]
+		}
+		score = math.Abs(score)
+		if score > bestScore {
+			bestIdx, bestScore = tokenIdx, score
+		}
+	}
+	return bestIdx
+}
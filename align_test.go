@@ -0,0 +1,55 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestAlignResponse(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+	for _, reverse := range []bool{false, true} {
+		net.ReverseInput = reverse
+		const query = "evaluate 3+4"
+		const response = "Result: 7"
+		alignment := net.alignResponse(query, response)
+		if len(alignment) != len([]rune(response)) {
+			t.Fatalf("expected %d indices but got %d", len([]rune(response)), len(alignment))
+		}
+		for _, idx := range alignment {
+			if idx < 0 || idx >= len(query) {
+				t.Errorf("index %d out of range", idx)
+			} else if idx != 0 && idx < 9 {
+				// "evaluate" is a single token starting at 0.
+				t.Errorf("index %d is inside a word token", idx)
+			}
+		}
+		for i := 1; i < len("Result"); i++ {
+			if alignment[i] != alignment[0] {
+				t.Errorf("runes of one token have alignments %v", alignment[:len("Result")])
+				break
+			}
+		}
+	}
+
+	for _, idx := range net.alignResponse("", "Result: 7") {
+		if idx != -1 {
+			t.Errorf("expected -1 for empty query but got %d", idx)
+		}
+	}
+}
+
+func TestAlignResponseInputEncoder(t *testing.T) {
+	net := NewNetworkInputEncoder(anyvec64.CurrentCreator(), &CharTokenizer{}, binaryEncoder{})
+	const query = "evaluate 3+4"
+	const response = "Result: 7"
+	alignment := net.alignResponse(query, response)
+	if len(alignment) != len([]rune(response)) {
+		t.Fatalf("expected %d indices but got %d", len([]rune(response)), len(alignment))
+	}
+	for _, idx := range alignment {
+		if idx < 0 || idx >= len(query) {
+			t.Errorf("index %d out of range", idx)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package algebrain
+
+import (
+	"math/rand"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// DefaultAugmentOps is the default number of rewrites
+// performed by an AugmentedShiftGenerator.
+const DefaultAugmentOps = 2
+
+// An AugmentedShiftGenerator is like a ShiftGenerator, but
+// it rewrites the expression in each query with AugmentNode
+// so that equivalent expressions appear in different
+// forms.
+// Responses are unaffected by the rewrites, which use the
+// same source of randomness as the Generator.
+type AugmentedShiftGenerator struct {
+	ShiftGenerator
+
+	// Ops is the number of rewrites per query.
+	// If this is 0, DefaultAugmentOps is used.
+	Ops int
+}
+
+// Generate generates an augmented graph shifting sample.
+func (a *AugmentedShiftGenerator) Generate() *Sample {
+	ops := a.Ops
+	if ops == 0 {
+		ops = DefaultAugmentOps
+	}
+	return a.ShiftGenerator.generate(func(n mathexpr.Node) mathexpr.Node {
+		return AugmentNode(n, a.ShiftGenerator.Generator.Rand, ops)
+	})
+}
+
+// AugmentNode applies ops random rewrites to a copy of an
+// expression without changing its value.
+//
+// Each rewrite picks a random sub-expression and either
+// swaps the operands of an addition or multiplication,
+// adds or removes a double negation, or moves the
+// parentheses in a chain of additions or multiplications,
+// e.g. turning "(x+y)+z" into "x+(y+z)".
+//
+// If rng is nil, the global source from math/rand is used.
+func AugmentNode(n mathexpr.Node, rng *rand.Rand, ops int) mathexpr.Node {
+	intn := rand.Intn
+	if rng != nil {
+		intn = rng.Intn
+	}
+	res := mathexpr.Clone(n)
+	for i := 0; i < ops; i++ {
+		type site struct {
+			Node mathexpr.Node
+			Set  func(mathexpr.Node)
+		}
+		var sites []site
+		var visit func(n mathexpr.Node, set func(mathexpr.Node))
+		visit = func(n mathexpr.Node, set func(mathexpr.Node)) {
+			sites = append(sites, site{Node: n, Set: set})
+			for j, child := range n.Children() {
+				j := j
+				visit(child, func(c mathexpr.Node) {
+					n.SetChild(j, c)
+				})
+			}
+		}
+		visit(res, func(c mathexpr.Node) {
+			res = c
+		})
+		s := sites[intn(len(sites))]
+		rewrites := augmentRewrites(s.Node)
+		s.Set(rewrites[intn(len(rewrites))](s.Node))
+	}
+	return res
+}
+
+// augmentRewrites finds the value-preserving rewrites
+// which apply to the root of an expression.
+func augmentRewrites(n mathexpr.Node) []func(mathexpr.Node) mathexpr.Node {
+	res := []func(mathexpr.Node) mathexpr.Node{
+		func(n mathexpr.Node) mathexpr.Node {
+			return &mathexpr.NegOp{Node: &mathexpr.NegOp{Node: n}}
+		},
+	}
+	switch n := n.(type) {
+	case *mathexpr.NegOp:
+		if inner, ok := n.Node.(*mathexpr.NegOp); ok {
+			res = append(res, func(mathexpr.Node) mathexpr.Node {
+				return inner.Node
+			})
+		}
+	case *mathexpr.BinaryOp:
+		if n.Op != mathexpr.AddOp && n.Op != mathexpr.MultiplyOp {
+			break
+		}
+		res = append(res, func(mathexpr.Node) mathexpr.Node {
+			return &mathexpr.BinaryOp{Op: n.Op, Left: n.Right, Right: n.Left}
+		})
+		if left, ok := n.Left.(*mathexpr.BinaryOp); ok && left.Op == n.Op {
+			res = append(res, func(mathexpr.Node) mathexpr.Node {
+				return &mathexpr.BinaryOp{
+					Op:    n.Op,
+					Left:  left.Left,
+					Right: &mathexpr.BinaryOp{Op: n.Op, Left: left.Right, Right: n.Right},
+				}
+			})
+		}
+		if right, ok := n.Right.(*mathexpr.BinaryOp); ok && right.Op == n.Op {
+			res = append(res, func(mathexpr.Node) mathexpr.Node {
+				return &mathexpr.BinaryOp{
+					Op:    n.Op,
+					Left:  &mathexpr.BinaryOp{Op: n.Op, Left: n.Left, Right: right.Left},
+					Right: right.Right,
+				}
+			})
+		}
+	}
+	return res
+}
@@ -0,0 +1,98 @@
+package algebrain
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestAugmentNode(t *testing.T) {
+	gen := &mathexpr.Generator{
+		NoReals:   true,
+		VarNames:  []string{"x", "y"},
+		FuncNames: []string{"sin", "exp"},
+	}
+	rng := rand.New(rand.NewSource(1337))
+	var changed int
+	for i := 0; i < 200; i++ {
+		expr := gen.Generate(3)
+		original := expr.String()
+		augmented := AugmentNode(expr, rng, 3)
+		if expr.String() != original {
+			t.Fatalf("input was modified: %s became %s", original, expr)
+		}
+		if augmented.String() != original {
+			changed++
+		}
+		for j := 0; j < 10; j++ {
+			vars := map[string]float64{"x": rng.Float64()*4 - 2, "y": rng.Float64()*4 - 2}
+			expected, err := mathexpr.Evaluate(expr, vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			actual, err := mathexpr.Evaluate(augmented, vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !augmentedValuesEqual(expected, actual) {
+				t.Fatalf("%s became %s: %f vs %f at %v", original, augmented, expected,
+					actual, vars)
+			}
+		}
+	}
+	if changed == 0 {
+		t.Error("no expressions were changed")
+	}
+}
+
+func TestAugmentedShiftGenerator(t *testing.T) {
+	gen := &AugmentedShiftGenerator{
+		ShiftGenerator: ShiftGenerator{
+			Generator: &mathexpr.Generator{
+				NoReals:  true,
+				VarNames: []string{"x"},
+			},
+			MaxDepth: 2,
+		},
+	}
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		var amount float64
+		parts := strings.SplitN(strings.TrimPrefix(sample.Query, "shift x by "), " in ", 2)
+		amountExpr, err := mathexpr.ParseString(parts[0])
+		if err == nil {
+			amount, err = mathexpr.Evaluate(amountExpr, nil)
+		}
+		if err != nil {
+			t.Fatalf("bad query %q: %s", sample.Query, err)
+		}
+		queryExpr, err := mathexpr.ParseString(parts[1])
+		if err != nil {
+			t.Fatal(err)
+		}
+		responseExpr, err := mathexpr.ParseString(sample.Response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, x := range []float64{-1.5, 0.5, 2.5} {
+			expected, _ := mathexpr.Evaluate(queryExpr, map[string]float64{"x": x - amount})
+			actual, _ := mathexpr.Evaluate(responseExpr, map[string]float64{"x": x})
+			if !augmentedValuesEqual(expected, actual) {
+				t.Fatalf("%s -> %s: mismatch at x=%f", sample.Query, sample.Response, x)
+			}
+		}
+	}
+}
+
+func augmentedValuesEqual(x, y float64) bool {
+	if math.IsNaN(x) || math.IsNaN(y) {
+		return math.IsNaN(x) && math.IsNaN(y)
+	}
+	if math.IsInf(x, 0) || math.IsInf(y, 0) {
+		return x == y
+	}
+	return math.Abs(x-y) <= 1e-8*math.Max(1, math.Abs(x))
+}
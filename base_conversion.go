@@ -0,0 +1,70 @@
+package algebrain
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// DefaultBaseConversionMax is the default maximum number
+// used by a BaseConversionGenerator.
+const DefaultBaseConversionMax = 0x400
+
+var baseNames = map[int]string{
+	2:  "binary",
+	8:  "octal",
+	10: "decimal",
+	16: "hex",
+}
+
+var basePrefixes = map[int]string{
+	2:  "0b",
+	8:  "0o",
+	10: "",
+	16: "0x",
+}
+
+// A BaseConversionGenerator generates Samples with queries
+// like "convert 13 to binary", expecting "Result: 1101",
+// or "convert 0xFF to decimal", expecting "Result: 255".
+//
+// Supported bases are 2, 8, 10, and 16.
+// In queries, non-decimal numbers are written with a "0b",
+// "0o", or "0x" prefix.
+// Results are written in uppercase without a prefix, and
+// lowercase results are accepted as AltResponses.
+//
+// FromBase and ToBase must differ.
+type BaseConversionGenerator struct {
+	FromBase int
+	ToBase   int
+
+	// MaxValue is the maximum number to convert.
+	// If this is 0, DefaultBaseConversionMax is used.
+	MaxValue int
+}
+
+// Generate generates a base conversion sample.
+func (b *BaseConversionGenerator) Generate() *Sample {
+	fromPrefix, ok := basePrefixes[b.FromBase]
+	toName, ok1 := baseNames[b.ToBase]
+	if !ok || !ok1 || b.FromBase == b.ToBase {
+		panic("unsupported base conversion: " + strconv.Itoa(b.FromBase) + " to " +
+			strconv.Itoa(b.ToBase))
+	}
+	max := b.MaxValue
+	if max == 0 {
+		max = DefaultBaseConversionMax
+	}
+	num := int64(rand.Intn(max + 1))
+	input := fromPrefix + strings.ToUpper(strconv.FormatInt(num, b.FromBase))
+	output := strconv.FormatInt(num, b.ToBase)
+	res := &Sample{
+		Query:    "convert " + input + " to " + toName,
+		Response: "Result: " + strings.ToUpper(output),
+	}
+	if strings.ToUpper(output) != output {
+		res.AltResponses = []string{"Result: " + output}
+	}
+	return res
+}
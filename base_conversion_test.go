@@ -0,0 +1,60 @@
+package algebrain
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestBaseConversionGenerator(t *testing.T) {
+	queryExpr := regexp.MustCompile(`^convert (0[box])?([0-9A-F]+) to ([a-z]+)$`)
+	bases := []int{2, 8, 10, 16}
+	for _, from := range bases {
+		for _, to := range bases {
+			if from == to {
+				continue
+			}
+			gen := &BaseConversionGenerator{FromBase: from, ToBase: to, MaxValue: 300}
+			for i := 0; i < 100; i++ {
+				sample := gen.Generate()
+				match := queryExpr.FindStringSubmatch(sample.Query)
+				if match == nil || match[1] != basePrefixes[from] || match[3] != baseNames[to] {
+					t.Fatalf("%d to %d: unexpected query: %s", from, to, sample.Query)
+				}
+				input, err := strconv.ParseInt(match[2], from, 64)
+				if err != nil {
+					t.Fatalf("%s: %s", sample.Query, err)
+				}
+				if input < 0 || input > 300 {
+					t.Fatalf("%s: number out of range", sample.Query)
+				}
+				for _, response := range append([]string{sample.Response},
+					sample.AltResponses...) {
+					if !strings.HasPrefix(response, "Result: ") {
+						t.Fatalf("%s: unexpected response: %s", sample.Query, response)
+					}
+					output, err := strconv.ParseInt(response[len("Result: "):], to, 64)
+					if err != nil || output != input {
+						t.Fatalf("%s: incorrect response: %s", sample.Query, response)
+					}
+				}
+			}
+		}
+	}
+
+	for _, gen := range []*BaseConversionGenerator{
+		{FromBase: 10, ToBase: 10},
+		{FromBase: 3, ToBase: 10},
+		{FromBase: 10, ToBase: 0},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for %d to %d", gen.FromBase, gen.ToBase)
+				}
+			}()
+			gen.Generate()
+		}()
+	}
+}
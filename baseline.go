@@ -0,0 +1,55 @@
+package algebrain
+
+import "math"
+
+// CharacterLMPerplexity computes the per-character
+// perplexity of the responses under a unigram character
+// model fit to the same responses.
+//
+// It is a baseline for Perplexity: a network which does
+// not do substantially better has not learned to use the
+// queries.
+// Unlike Perplexity, terminators are not counted.
+func CharacterLMPerplexity(samples []*Sample) float64 {
+	counts := map[rune]float64{}
+	var total float64
+	for _, s := range samples {
+		for _, r := range s.Response {
+			counts[r]++
+			total++
+		}
+	}
+	var logLikelihood float64
+	for _, count := range counts {
+		logLikelihood += count * math.Log(count/total)
+	}
+	return math.Exp(-logLikelihood / total)
+}
+
+// BigramLMPerplexity is like CharacterLMPerplexity, but
+// each character is conditioned on the previous character
+// of the response (or on the start of the response).
+func BigramLMPerplexity(samples []*Sample) float64 {
+	type bigram struct {
+		Prev rune
+		Next rune
+	}
+	const start = -1
+	counts := map[bigram]float64{}
+	contextCounts := map[rune]float64{}
+	var total float64
+	for _, s := range samples {
+		prev := rune(start)
+		for _, r := range s.Response {
+			counts[bigram{Prev: prev, Next: r}]++
+			contextCounts[prev]++
+			total++
+			prev = r
+		}
+	}
+	var logLikelihood float64
+	for b, count := range counts {
+		logLikelihood += count * math.Log(count/contextCounts[b.Prev])
+	}
+	return math.Exp(-logLikelihood / total)
+}
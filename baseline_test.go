@@ -0,0 +1,34 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCharacterLMPerplexity(t *testing.T) {
+	samples := []*Sample{{Response: "a"}, {Response: "aaa"}, {Response: "aa"}}
+	if p := CharacterLMPerplexity(samples); math.Abs(p-1) > 1e-8 {
+		t.Errorf("expected perplexity 1 but got %f", p)
+	}
+	samples = []*Sample{{Response: "ab"}, {Response: "cd"}}
+	if p := CharacterLMPerplexity(samples); math.Abs(p-4) > 1e-8 {
+		t.Errorf("expected perplexity 4 but got %f", p)
+	}
+}
+
+func TestBigramLMPerplexity(t *testing.T) {
+	samples := []*Sample{{Response: "a"}, {Response: "aaa"}, {Response: "aa"}}
+	if p := BigramLMPerplexity(samples); math.Abs(p-1) > 1e-8 {
+		t.Errorf("expected perplexity 1 but got %f", p)
+	}
+
+	// Each response is fully determined by its first
+	// character, which is one of two options.
+	samples = []*Sample{{Response: "ab"}, {Response: "cd"}}
+	if p := BigramLMPerplexity(samples); math.Abs(p-math.Sqrt(2)) > 1e-8 {
+		t.Errorf("expected perplexity %f but got %f", math.Sqrt(2), p)
+	}
+	if BigramLMPerplexity(samples) > CharacterLMPerplexity(samples) {
+		t.Error("bigram perplexity should not exceed unigram perplexity")
+	}
+}
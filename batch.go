@@ -0,0 +1,115 @@
+package algebrain
+
+import (
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+)
+
+// Batch is a batch of fetched training samples.
+//
+// Sequences in a batch may have different lengths, as
+// indicated by the present maps of the sequences.
+type Batch struct {
+	EncIn  anyseq.Seq
+	DecIn  anyseq.Seq
+	DecOut anyseq.Seq
+}
+
+// MakeBatch encodes samples as sequences for the network.
+//
+// EncIn contains the (possibly reversed) queries, DecIn
+// contains the responses preceded by a Terminator, and
+// DecOut contains the responses followed by a Terminator.
+//
+// The DecOut vectors of each sample are scaled by the
+// sample's Weight, which scales its contribution to the
+// cost.
+func (n *Network) MakeBatch(samples []*Sample) (*Batch, error) {
+	return n.makeBatch(samples, true)
+}
+
+func (n *Network) makeBatch(samples []*Sample, weighted bool) (*Batch, error) {
+	var encIn, decIn, decOut [][]anyvec.Vector
+	for _, sample := range samples {
+		in, err := n.inputSequence(sample)
+		if err != nil {
+			return nil, essentials.AddCtx("make batch", err)
+		}
+		dIn, err := sample.DecoderInVectors(n.creator(), n.Tokenizer)
+		if err != nil {
+			return nil, essentials.AddCtx("make batch", err)
+		}
+		dOut, err := sample.DecoderOutVectors(n.creator(), n.Tokenizer)
+		if err != nil {
+			return nil, essentials.AddCtx("make batch", err)
+		}
+		if weighted && sample.Weight != 0 && sample.Weight != 1 {
+			dOut = weightedVectors(dOut, sample.Weight)
+		}
+		encIn = append(encIn, in)
+		decIn = append(decIn, dIn)
+		decOut = append(decOut, dOut)
+	}
+	return &Batch{
+		EncIn:  anyseq.ConstSeqList(n.creator(), encIn),
+		DecIn:  anyseq.ConstSeqList(n.creator(), decIn),
+		DecOut: anyseq.ConstSeqList(n.creator(), decOut),
+	}, nil
+}
+
+// weightedVectors scales copies of the (read-only) vectors.
+func weightedVectors(vecs []anyvec.Vector, weight float64) []anyvec.Vector {
+	res := make([]anyvec.Vector, len(vecs))
+	for i, v := range vecs {
+		res[i] = v.Copy()
+		res[i].Scale(v.Creator().MakeNumeric(weight))
+	}
+	return res
+}
+
+// DecodeBatch reverses MakeBatch, producing the samples
+// encoded in a batch.
+// It is mainly useful for debugging.
+//
+// Queries can only be decoded if the network uses one-hot
+// inputs, i.e. if InputEncoder is nil.
+func (n *Network) DecodeBatch(b *Batch) []*Sample {
+	queries := n.decodeSeq(b.EncIn)
+	responses := n.decodeSeq(b.DecOut)
+	res := make([]*Sample, len(queries))
+	for i, query := range queries {
+		if n.ReverseInput {
+			query = reversedTokens(query)
+		}
+		response := responses[i]
+		if len(response) > 0 && response[len(response)-1] == Terminator {
+			response = response[:len(response)-1]
+		}
+		res[i] = &Sample{
+			Query:    n.Tokenizer.Decode(query),
+			Response: n.Tokenizer.Decode(response),
+		}
+	}
+	return res
+}
+
+func (n *Network) decodeSeq(seq anyseq.Seq) [][]int {
+	var res [][]int
+	vocabSize := n.Tokenizer.VocabSize()
+	for _, batch := range seq.Output() {
+		if res == nil {
+			res = make([][]int, len(batch.Present))
+		}
+		var packedIdx int
+		for i, present := range batch.Present {
+			if !present {
+				continue
+			}
+			vec := batch.Packed.Slice(packedIdx*vocabSize, (packedIdx+1)*vocabSize)
+			res[i] = append(res[i], argMax(vec))
+			packedIdx++
+		}
+	}
+	return res
+}
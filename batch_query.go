@@ -0,0 +1,108 @@
+package algebrain
+
+import (
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anynet/anyrnn"
+	"github.com/unixpickle/anyvec"
+)
+
+// QueryBatch runs several queries at once, producing the
+// same responses as calling Query on each of them.
+//
+// The whole batch is encoded as one sequence list, and the
+// decoder inputs for every unfinished query are packed
+// into a single vector per timestep.
+// Thus, each timestep performs a few large operations
+// rather than many small ones, which is much faster on a
+// GPU or with a SIMD-heavy creator.
+// Finished queries are dropped from the batch every
+// BatchCompactInterval steps.
+//
+// It panics if a query cannot be tokenized.
+func (n *Network) QueryBatch(queries []string) []string {
+	if len(queries) == 0 {
+		return nil
+	}
+	c := n.creator()
+	inSeqs := make([][]anyvec.Vector, len(queries))
+	for i, q := range queries {
+		n.recordInputLength(q)
+		var err error
+		inSeqs[i], err = n.inputSequence(&Sample{Query: q})
+		if err != nil {
+			panic(err)
+		}
+	}
+	enc := n.Encoder.Apply(anyseq.ConstSeqList(c, inSeqs))
+	b := anyrnn.Stack{
+		n.Align.Block(enc),
+		&anyrnn.LayerBlock{Layer: n.Output},
+	}
+	state := b.Start(len(queries))
+
+	interval := n.BatchCompactInterval
+	if interval <= 0 {
+		interval = 1
+	}
+	vocabSize := n.Tokenizer.VocabSize()
+	tokens := make([][]int, len(queries))
+	lastTokens := make([]int, len(queries))
+	done := make([]bool, len(queries))
+
+	// rows indicates which queries are still in the state.
+	// Finished queries stay until the next compaction, and
+	// their outputs are ignored.
+	rows := make(anyrnn.PresentMap, len(queries))
+	for i := range rows {
+		rows[i] = true
+	}
+	for step := 1; ; step++ {
+		var inVecs []anyvec.Vector
+		for i, r := range rows {
+			if r {
+				inVecs = append(inVecs, oneHotVector(c, lastTokens[i], vocabSize))
+			}
+		}
+		result := b.Step(state, c.Concat(inVecs...))
+		out := result.Output()
+
+		var packedIdx int
+		var numRemaining int
+		for i, r := range rows {
+			if !r {
+				continue
+			}
+			idx := packedIdx
+			packedIdx++
+			if done[i] {
+				continue
+			}
+			token := argMax(out.Slice(idx*vocabSize, (idx+1)*vocabSize))
+			if token == Terminator || len(tokens[i]) >= maxResponseLen {
+				done[i] = true
+				continue
+			}
+			tokens[i] = append(tokens[i], token)
+			lastTokens[i] = token
+			numRemaining++
+		}
+		if numRemaining == 0 {
+			break
+		}
+		state = result.State()
+		if step%interval == 0 && numRemaining < packedIdx {
+			nextRows := make(anyrnn.PresentMap, len(rows))
+			for i, r := range rows {
+				nextRows[i] = r && !done[i]
+			}
+			state = state.Reduce(nextRows)
+			rows = nextRows
+		}
+	}
+
+	res := make([]string, len(queries))
+	for i, t := range tokens {
+		res[i] = n.Tokenizer.Decode(t)
+	}
+	return res
+}
@@ -0,0 +1,60 @@
+package algebrain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestQueryBatch(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	queries := []string{"evaluate 1+2", "scale x by 2 in x", "shift y by 3 in y^2+y",
+		"evaluate 1+2"}
+	actual := net.QueryBatch(queries)
+	if len(actual) != len(queries) {
+		t.Fatalf("expected %d responses but got %d", len(queries), len(actual))
+	}
+	for i, q := range queries {
+		if expected := net.Query(q); actual[i] != expected {
+			t.Errorf("query %q: expected %q but got %q", q, expected, actual[i])
+		}
+	}
+	for _, interval := range []int{3, 1 << 20} {
+		net.BatchCompactInterval = interval
+		compacted := net.QueryBatch(queries)
+		for i, q := range queries {
+			if compacted[i] != actual[i] {
+				t.Errorf("interval %d: query %q: expected %q but got %q", interval, q,
+					actual[i], compacted[i])
+			}
+		}
+	}
+	net.BatchCompactInterval = 0
+	if res := net.QueryBatch(nil); len(res) != 0 {
+		t.Errorf("expected no responses but got %d", len(res))
+	}
+}
+
+func BenchmarkQueryBatch(b *testing.B) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	for _, batchSize := range []int{16, 64, 256} {
+		queries := make([]string, batchSize)
+		for i := range queries {
+			queries[i] = fmt.Sprintf("evaluate %d+%d", i, i*7%13)
+		}
+		b.Run(fmt.Sprintf("Sequential%d", batchSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, q := range queries {
+					net.Query(q)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("Packed%d", batchSize), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				net.QueryBatch(queries)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestBatchRoundTrip(t *testing.T) {
+	samples := []*Sample{
+		{Query: "scale x by 2 in x", Response: "x*2"},
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "shift x by 3 in sin(x)", Response: ""},
+	}
+	for _, reverse := range []bool{false, true} {
+		net := NewNetwork(anyvec32.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+		net.ReverseInput = reverse
+		batch, err := net.MakeBatch(samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+		decoded := net.DecodeBatch(batch)
+		if len(decoded) != len(samples) {
+			t.Fatalf("expected %d samples but got %d", len(samples), len(decoded))
+		}
+		for i, expected := range samples {
+			if decoded[i].Query != expected.Query || decoded[i].Response != expected.Response {
+				t.Errorf("reverse=%v sample %d: expected %v but got %v", reverse, i,
+					expected, decoded[i])
+			}
+		}
+	}
+}
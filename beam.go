@@ -0,0 +1,121 @@
+package algebrain
+
+import (
+	"sort"
+
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anynet/anyrnn"
+	"github.com/unixpickle/anyvec"
+)
+
+// beamHypothesis is a partial or complete response in a
+// beam search.
+type beamHypothesis struct {
+	State   anyrnn.State
+	Tokens  []int
+	LogProb float64
+}
+
+// beamCandidate is a possible extension of a hypothesis.
+// A Token of Terminator completes the hypothesis.
+type beamCandidate struct {
+	Parent  *beamHypothesis
+	Token   int
+	State   anyrnn.State
+	LogProb float64
+}
+
+// QueryNBest runs a beam search with the given beam width,
+// producing up to num responses in order of decreasing
+// probability.
+//
+// The first response is usually, but not always, the same
+// as the response from Query.
+//
+// It panics if the query cannot be tokenized.
+func (n *Network) QueryNBest(q string, num int) []string {
+	n.recordInputLength(q)
+	inVecs, err := n.inputSequence(&Sample{Query: q})
+	if err != nil {
+		panic(err)
+	}
+	c := n.creator()
+	enc := n.Encoder.Apply(anyseq.ConstSeqList(c, [][]anyvec.Vector{inVecs}))
+	b := anyrnn.Stack{
+		n.Align.Block(enc),
+		&anyrnn.LayerBlock{Layer: n.Output},
+	}
+	vocabSize := n.Tokenizer.VocabSize()
+
+	beams := []*beamHypothesis{{State: b.Start(1)}}
+	var finished []*beamHypothesis
+	for len(beams) > 0 {
+		var candidates []*beamCandidate
+		for _, beam := range beams {
+			lastToken := Terminator
+			if len(beam.Tokens) > 0 {
+				lastToken = beam.Tokens[len(beam.Tokens)-1]
+			}
+			res := b.Step(beam.State, oneHotVector(c, lastToken, vocabSize))
+			if len(beam.Tokens) >= maxResponseLen {
+				// Like Query, truncate the response after the
+				// most likely next token.
+				token := argMax(res.Output())
+				candidates = append(candidates, &beamCandidate{
+					Parent:  beam,
+					Token:   Terminator,
+					LogProb: beam.LogProb + vectorEntry(res.Output(), token),
+				})
+				continue
+			}
+			for token, logProb := range vectorFloats(res.Output()) {
+				candidates = append(candidates, &beamCandidate{
+					Parent:  beam,
+					Token:   token,
+					State:   res.State(),
+					LogProb: beam.LogProb + logProb,
+				})
+			}
+		}
+		sort.SliceStable(candidates, func(i, j int) bool {
+			return candidates[i].LogProb > candidates[j].LogProb
+		})
+		if len(candidates) > num {
+			candidates = candidates[:num]
+		}
+
+		beams = nil
+		for _, candidate := range candidates {
+			h := &beamHypothesis{
+				State:   candidate.State,
+				Tokens:  candidate.Parent.Tokens,
+				LogProb: candidate.LogProb,
+			}
+			if candidate.Token == Terminator {
+				finished = append(finished, h)
+			} else {
+				h.Tokens = append(append([]int{}, h.Tokens...), candidate.Token)
+				beams = append(beams, h)
+			}
+		}
+
+		// Log probabilities only decrease, so no remaining
+		// beam can beat the finished hypotheses.
+		sort.SliceStable(finished, func(i, j int) bool {
+			return finished[i].LogProb > finished[j].LogProb
+		})
+		if len(finished) >= num && len(beams) > 0 &&
+			finished[num-1].LogProb >= beams[0].LogProb {
+			break
+		}
+	}
+
+	if len(finished) > num {
+		finished = finished[:num]
+	}
+	res := make([]string, len(finished))
+	for i, h := range finished {
+		res[i] = n.Tokenizer.Decode(h.Tokens)
+	}
+	return res
+}
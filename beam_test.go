@@ -0,0 +1,61 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+// beamTestNetwork creates a network which outputs the
+// same distribution at every step, where the terminator is
+// most likely and "a" is second most likely.
+func beamTestNetwork() *Network {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	outLayer := net.Output[0].(*anynet.FC)
+	outLayer.Weights.Vector.Scale(0.0)
+	biases := make([]float64, CharCount)
+	for i := range biases {
+		biases[i] = -1000
+	}
+	biases[Terminator] = 10
+	biases['a'] = 9
+	outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+	return net
+}
+
+func TestQueryNBest(t *testing.T) {
+	net := beamTestNetwork()
+	actual := net.QueryNBest("evaluate 1", 3)
+	expected := []string{"", "a", "aa"}
+	if len(actual) != len(expected) {
+		t.Fatalf("expected %v but got %v", expected, actual)
+	}
+	for i, x := range expected {
+		if actual[i] != x {
+			t.Errorf("response %d: expected %q but got %q", i, x, actual[i])
+		}
+	}
+
+	random := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	const query = "scale x by 2 in x"
+	best := random.QueryNBest(query, 1)
+	if len(best) != 1 || best[0] != random.Query(query) {
+		t.Errorf("expected greedy response %q but got %v", random.Query(query), best)
+	}
+}
+
+func TestTopKAccuracy(t *testing.T) {
+	net := beamTestNetwork()
+	samples := []*Sample{
+		{Query: "evaluate 1", Response: "a"},
+		{Query: "evaluate 2", Response: "aa"},
+	}
+	top1 := TopKAccuracy(net, samples, 1)
+	top2 := TopKAccuracy(net, samples, 2)
+	top3 := TopKAccuracy(net, samples, 3)
+	if top1 != 0 || top2 != 0.5 || math.Abs(top3-1) > 1e-8 {
+		t.Errorf("unexpected accuracies: top1=%f top2=%f top3=%f", top1, top2, top3)
+	}
+}
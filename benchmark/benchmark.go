@@ -0,0 +1,254 @@
+// Package benchmark defines a fixed suite of tasks for
+// comparing algebrain networks across versions.
+//
+// The task configurations and seeds are frozen, so a
+// report is only comparable to reports produced from the
+// same suite version.
+// Any change to the tasks must increment Version.
+package benchmark
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"strings"
+
+	"github.com/unixpickle/algebrain"
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// Version identifies the task definitions.
+const Version = 1
+
+// SamplesPerTask is the number of samples in each task.
+const SamplesPerTask = 1000
+
+// A Task is a fixed set of samples.
+type Task struct {
+	Name string
+	Seed int64
+
+	// NumSamples is the number of samples in the task.
+	NumSamples int
+
+	// NewGenerator creates the generator which produces
+	// the samples.
+	// The generator must draw all of its randomness from
+	// rng, so that the samples only depend on the Seed.
+	NewGenerator func(rng *rand.Rand) algebrain.Generator
+}
+
+// Tasks returns the tasks in the suite.
+//
+// The generators match the ones with the same names in the
+// train command, except that HardEval uses the MediumEval
+// configuration.
+func Tasks() []*Task {
+	return []*Task{
+		{
+			Name: "EasyShift",
+			Seed: 1001,
+			NewGenerator: func(rng *rand.Rand) algebrain.Generator {
+				return &algebrain.ShiftGenerator{
+					Generator: &mathexpr.Generator{
+						NoReals:  true,
+						VarNames: []string{"x"},
+						Rand:     rng,
+					},
+					MaxDepth: 1,
+				}
+			},
+		},
+		{
+			Name: "HardShift",
+			Seed: 1002,
+			NewGenerator: func(rng *rand.Rand) algebrain.Generator {
+				return &algebrain.ShiftGenerator{
+					Generator: &mathexpr.Generator{
+						NoReals:  true,
+						VarNames: []string{"x", "y", "z"},
+						Rand:     rng,
+					},
+					MaxDepth: 5,
+				}
+			},
+		},
+		{
+			Name: "EasyScale",
+			Seed: 1003,
+			NewGenerator: func(rng *rand.Rand) algebrain.Generator {
+				return &algebrain.ScaleGenerator{
+					Generator: &mathexpr.Generator{
+						NoReals:  true,
+						VarNames: []string{"x"},
+						Rand:     rng,
+					},
+					MaxDepth: 1,
+				}
+			},
+		},
+		{
+			Name: "HardScale",
+			Seed: 1004,
+			NewGenerator: func(rng *rand.Rand) algebrain.Generator {
+				return &algebrain.ScaleGenerator{
+					Generator: &mathexpr.Generator{
+						NoReals:  true,
+						VarNames: []string{"x", "y", "z"},
+						Rand:     rng,
+					},
+					MaxDepth: 5,
+				}
+			},
+		},
+		{
+			Name: "EasyEval",
+			Seed: 1005,
+			NewGenerator: func(rng *rand.Rand) algebrain.Generator {
+				return &algebrain.EvalGenerator{
+					Generator: &mathexpr.Generator{
+						NoReals: true,
+						Rand:    rng,
+					},
+					MaxDepth: 1,
+					AllInts:  true,
+				}
+			},
+		},
+		{
+			Name: "HardEval",
+			Seed: 1006,
+			NewGenerator: func(rng *rand.Rand) algebrain.Generator {
+				return &algebrain.EvalGenerator{
+					Generator: &mathexpr.Generator{
+						NoReals: true,
+						Stddev:  80,
+						Rand:    rng,
+					},
+					MaxDepth: 3,
+					AllInts:  true,
+				}
+			},
+		},
+	}
+}
+
+// Samples generates the task's samples.
+//
+// The generator uses its own source of randomness, seeded
+// with the task's Seed, so the result is the same every
+// time and the global math/rand source is unaffected.
+func (t *Task) Samples() []*algebrain.Sample {
+	generator := t.NewGenerator(rand.New(rand.NewSource(t.Seed)))
+	numSamples := t.NumSamples
+	if numSamples == 0 {
+		numSamples = SamplesPerTask
+	}
+	res := make([]*algebrain.Sample, numSamples)
+	for i := range res {
+		res[i] = generator.Generate()
+	}
+	return res
+}
+
+// A TaskReport summarizes the performance of a network on
+// one Task.
+type TaskReport struct {
+	Name string
+
+	// ExactAccuracy is the fraction of responses which
+	// exactly matched an acceptable response.
+	ExactAccuracy float64
+
+	// SemanticAccuracy is the fraction of responses which
+	// are mathematically equivalent to the expected
+	// response, e.g. "2*x" for "x*2".
+	SemanticAccuracy float64
+}
+
+// A Report summarizes the performance of a network on the
+// suite.
+type Report struct {
+	Version int
+	Tasks   []*TaskReport
+}
+
+// String formats the report as a table.
+func (r *Report) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "Benchmark version %d\n", r.Version)
+	fmt.Fprintf(&buf, "%-12s %10s %10s\n", "Task", "Exact", "Semantic")
+	for _, t := range r.Tasks {
+		fmt.Fprintf(&buf, "%-12s %10.4f %10.4f\n", t.Name, t.ExactAccuracy,
+			t.SemanticAccuracy)
+	}
+	return buf.String()
+}
+
+// Run scores the network on every task in the suite.
+func Run(n algebrain.Querier) *Report {
+	return runTasks(n, Tasks())
+}
+
+func runTasks(n algebrain.Querier, tasks []*Task) *Report {
+	res := &Report{Version: Version}
+	for _, task := range tasks {
+		report := &TaskReport{Name: task.Name}
+		samples := task.Samples()
+		for _, sample := range samples {
+			actual, err := n.QueryContext(context.Background(), sample.Query)
+			if err != nil {
+				continue
+			}
+			if sample.Accepts(actual, false) {
+				report.ExactAccuracy++
+			}
+			if sample.Accepts(actual, true) || semanticEqual(sample.Response, actual) {
+				report.SemanticAccuracy++
+			}
+		}
+		report.ExactAccuracy /= float64(len(samples))
+		report.SemanticAccuracy /= float64(len(samples))
+		res.Tasks = append(res.Tasks, report)
+	}
+	return res
+}
+
+// semanticEqual checks if two responses are numerically
+// equal, either as "Result: N" values or as expressions
+// evaluated at a few fixed points.
+func semanticEqual(expected, actual string) bool {
+	const resultPrefix = "Result: "
+	if strings.HasPrefix(expected, resultPrefix) {
+		if !strings.HasPrefix(actual, resultPrefix) {
+			return false
+		}
+		expected = strings.TrimPrefix(expected, resultPrefix)
+		actual = strings.TrimPrefix(actual, resultPrefix)
+	}
+	expectedExpr, err := mathexpr.ParseString(expected)
+	if err != nil {
+		return false
+	}
+	actualExpr, err := mathexpr.ParseString(actual)
+	if err != nil {
+		return false
+	}
+	for _, point := range []float64{-1.7, 0.3, 1.1, 2.9} {
+		vars := map[string]float64{"x": point, "y": point*0.7 + 0.2, "z": 1.3 - point}
+		x, err1 := mathexpr.Evaluate(expectedExpr, vars)
+		y, err2 := mathexpr.Evaluate(actualExpr, vars)
+		if err1 != nil || err2 != nil {
+			return false
+		}
+		if math.IsNaN(x) != math.IsNaN(y) {
+			return false
+		}
+		if !math.IsNaN(x) && math.Abs(x-y) > 1e-6*math.Max(1, math.Abs(x)) {
+			return false
+		}
+	}
+	return true
+}
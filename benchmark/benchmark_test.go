@@ -0,0 +1,123 @@
+package benchmark
+
+import (
+	"context"
+	"testing"
+
+	"github.com/unixpickle/algebrain"
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestTasksDeterministic(t *testing.T) {
+	for i, task := range Tasks() {
+		task.NumSamples = 20
+		samples1 := task.Samples()
+		samples2 := Tasks()[i]
+		samples2.NumSamples = 20
+		for j, sample := range samples2.Samples() {
+			if sample.Query != samples1[j].Query || sample.Response != samples1[j].Response {
+				t.Errorf("task %s: sample %d changed", task.Name, j)
+				break
+			}
+		}
+	}
+}
+
+func TestRun(t *testing.T) {
+	net := algebrain.NewNetwork(anyvec32.CurrentCreator(), &algebrain.CharTokenizer{})
+	tasks := Tasks()
+	for _, task := range tasks {
+		task.NumSamples = 2
+	}
+	report := runTasks(net, tasks)
+	if len(report.Tasks) != len(tasks) {
+		t.Fatalf("expected %d tasks but got %d", len(tasks), len(report.Tasks))
+	}
+	for i, task := range report.Tasks {
+		if task.Name != tasks[i].Name {
+			t.Errorf("task %d: expected name %s but got %s", i, tasks[i].Name, task.Name)
+		}
+		if task.ExactAccuracy < 0 || task.ExactAccuracy > task.SemanticAccuracy ||
+			task.SemanticAccuracy > 1 {
+			t.Errorf("task %s: invalid accuracies %f, %f", task.Name, task.ExactAccuracy,
+				task.SemanticAccuracy)
+		}
+	}
+}
+
+func TestSemanticEqual(t *testing.T) {
+	for _, c := range []struct {
+		Expected string
+		Actual   string
+		Equal    bool
+	}{
+		{"(x-2)^2+2", "(x-2)^2+2", true},
+		{"(x*2)^2", "(2*x)^2", true},
+		{"x*2+x", "3*x", true},
+		{"x*2", "x*3", false},
+		{"Result: 4", "Result: 4.0", true},
+		{"Result: 4", "Result: 5", false},
+		{"Result: 4", "4", false},
+		{"x*2", "x*", false},
+	} {
+		if actual := semanticEqual(c.Expected, c.Actual); actual != c.Equal {
+			t.Errorf("%s vs %s: expected %v but got %v", c.Expected, c.Actual, c.Equal, actual)
+		}
+	}
+}
+
+func BenchmarkQuery(b *testing.B) {
+	net := algebrain.NewNetwork(anyvec32.CurrentCreator(), &algebrain.CharTokenizer{})
+	task := Tasks()[0]
+	task.NumSamples = 100
+	samples := task.Samples()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		net.Query(samples[i%len(samples)].Query)
+	}
+}
+
+func BenchmarkTrain(b *testing.B) {
+	net := algebrain.NewNetwork(anyvec32.CurrentCreator(), &algebrain.CharTokenizer{})
+	trainer := &algebrain.Trainer{Network: net}
+	task := Tasks()[0]
+	task.NumSamples = 16
+	samples := algebrain.SampleList(task.Samples())
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		batch, err := trainer.Fetch(samples)
+		if err != nil {
+			b.Fatal(err)
+		}
+		trainer.Gradient(batch)
+	}
+}
+
+// answerKey answers every query with the expected response
+// of its sample.
+type answerKey map[string]string
+
+func (a answerKey) Query(q string) string {
+	return a[q]
+}
+
+func (a answerKey) QueryContext(ctx context.Context, q string) (string, error) {
+	return a[q], nil
+}
+
+func TestRunQuerier(t *testing.T) {
+	tasks := Tasks()[:2]
+	answers := answerKey{}
+	for _, task := range tasks {
+		task.NumSamples = 10
+		for _, sample := range task.Samples() {
+			answers[sample.Query] = sample.Response
+		}
+	}
+	for _, task := range runTasks(answers, tasks).Tasks {
+		if task.ExactAccuracy != 1 || task.SemanticAccuracy != 1 {
+			t.Errorf("task %s: expected perfect accuracy but got %f, %f", task.Name,
+				task.ExactAccuracy, task.SemanticAccuracy)
+		}
+	}
+}
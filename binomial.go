@@ -0,0 +1,182 @@
+package algebrain
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+	"github.com/unixpickle/essentials"
+)
+
+// Default bounds for a BinomialExpandGenerator.
+const (
+	DefaultBinomialMaxExponent    = 4
+	DefaultBinomialMaxCoefficient = 3
+	DefaultBinomialMaxConstant    = 5
+)
+
+// A BinomialExpandGenerator generates Samples with queries
+// like "expand (x+1)^3", expecting "x^3+3*x^2+3*x+1".
+//
+// The binomials have the form a*x+b, where a is positive
+// and b is non-zero.
+// Responses list the terms in decreasing order of degree,
+// omitting zero terms and unit coefficients.
+type BinomialExpandGenerator struct {
+	// VarName is the variable to use.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// MaxExponent is the maximum power, which is at least 2.
+	// If this is 0, DefaultBinomialMaxExponent is used.
+	MaxExponent int
+
+	// MaxCoefficient is the maximum value of a.
+	// If this is 0, DefaultBinomialMaxCoefficient is used.
+	MaxCoefficient int
+
+	// MaxConstant is the maximum absolute value of b.
+	// If this is 0, DefaultBinomialMaxConstant is used.
+	MaxConstant int
+}
+
+// Generate generates a binomial expansion sample.
+func (b *BinomialExpandGenerator) Generate() *Sample {
+	varName := b.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	maxExp := b.MaxExponent
+	if maxExp == 0 {
+		maxExp = DefaultBinomialMaxExponent
+	}
+	maxCoeff := b.MaxCoefficient
+	if maxCoeff == 0 {
+		maxCoeff = DefaultBinomialMaxCoefficient
+	}
+	maxConst := b.MaxConstant
+	if maxConst == 0 {
+		maxConst = DefaultBinomialMaxConstant
+	}
+	exponent := 2 + rand.Intn(essentials.MaxInt(maxExp-1, 1))
+	coeff := 1 + rand.Intn(maxCoeff)
+	constant := 1 + rand.Intn(maxConst)
+	if rand.Intn(2) == 0 {
+		constant = -constant
+	}
+	expr := binomialNode(varName, coeff, constant, exponent)
+	poly := polynomial{constant, coeff}.pow(exponent)
+	return &Sample{
+		Query:    "expand " + expr.String(),
+		Response: poly.String(varName),
+	}
+}
+
+func binomialNode(varName string, coeff, constant, exponent int) mathexpr.Node {
+	var term mathexpr.Node = mathexpr.RawNode(varName)
+	if coeff != 1 {
+		term = &mathexpr.BinaryOp{
+			Op:    mathexpr.MultiplyOp,
+			Left:  mathexpr.RawNode(strconv.Itoa(coeff)),
+			Right: term,
+		}
+	}
+	op := mathexpr.AddOp
+	if constant < 0 {
+		op = mathexpr.SubtractOp
+		constant = -constant
+	}
+	return &mathexpr.BinaryOp{
+		Op: mathexpr.PowOp,
+		Left: &mathexpr.BinaryOp{
+			Op:    op,
+			Left:  term,
+			Right: mathexpr.RawNode(strconv.Itoa(constant)),
+		},
+		Right: mathexpr.RawNode(strconv.Itoa(exponent)),
+	}
+}
+
+// A polynomial is a list of integer coefficients, where
+// the i-th entry is the coefficient for x^i.
+type polynomial []int
+
+func (p polynomial) mul(p1 polynomial) polynomial {
+	res := make(polynomial, len(p)+len(p1)-1)
+	for i, x := range p {
+		for j, y := range p1 {
+			res[i+j] += x * y
+		}
+	}
+	return res
+}
+
+func (p polynomial) pow(exponent int) polynomial {
+	res := polynomial{1}
+	for i := 0; i < exponent; i++ {
+		res = res.mul(p)
+	}
+	return res
+}
+
+func (p polynomial) evaluate(x float64) float64 {
+	var res float64
+	for i := len(p) - 1; i >= 0; i-- {
+		res = res*x + float64(p[i])
+	}
+	return res
+}
+
+func (p polynomial) numTerms() int {
+	var res int
+	for _, x := range p {
+		if x != 0 {
+			res++
+		}
+	}
+	return res
+}
+
+// node converts the polynomial to an expression which
+// prints the same way as String.
+func (p polynomial) node(varName string) mathexpr.Node {
+	res, err := mathexpr.ParseString(p.String(varName))
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// String formats the polynomial with the highest degree
+// terms first, e.g. "x^3-3*x^2+3*x-1".
+func (p polynomial) String(varName string) string {
+	var res strings.Builder
+	for i := len(p) - 1; i >= 0; i-- {
+		coeff := p[i]
+		if coeff == 0 {
+			continue
+		}
+		if coeff < 0 {
+			res.WriteString("-")
+			coeff = -coeff
+		} else if res.Len() > 0 {
+			res.WriteString("+")
+		}
+		if i == 0 {
+			res.WriteString(strconv.Itoa(coeff))
+			continue
+		}
+		if coeff != 1 {
+			res.WriteString(strconv.Itoa(coeff) + "*")
+		}
+		res.WriteString(varName)
+		if i > 1 {
+			res.WriteString("^" + strconv.Itoa(i))
+		}
+	}
+	if res.Len() == 0 {
+		return "0"
+	}
+	return res.String()
+}
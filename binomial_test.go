@@ -0,0 +1,50 @@
+package algebrain
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestPolynomialString(t *testing.T) {
+	cases := map[string]polynomial{
+		"x^3+3*x^2+3*x+1": polynomial{1, 1}.pow(3),
+		"x^2-2*x+1":       polynomial{-1, 1}.pow(2),
+		"4*x^2+12*x+9":    polynomial{3, 2}.pow(2),
+		"0":               polynomial{0},
+	}
+	for expected, poly := range cases {
+		if actual := poly.String("x"); actual != expected {
+			t.Errorf("expected %s but got %s", expected, actual)
+		}
+	}
+}
+
+func TestBinomialExpandGenerator(t *testing.T) {
+	gen := &BinomialExpandGenerator{MaxExponent: 5}
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		if !strings.HasPrefix(sample.Query, "expand (") {
+			t.Fatalf("unexpected query: %s", sample.Query)
+		}
+	}
+
+	for i := 0; i < 100; i++ {
+		coeff, constant, exponent := 1+rand.Intn(3), rand.Intn(11)-5, 2+rand.Intn(4)
+		expr := binomialNode("x", coeff, constant, exponent)
+		poly := polynomial{constant, coeff}.pow(exponent)
+		x := rand.Float64()*4 - 2
+		expected, err := mathexpr.Evaluate(expr, map[string]float64{"x": x})
+		if err != nil {
+			t.Fatal(err)
+		}
+		actual := poly.evaluate(x)
+		if math.Abs(actual-expected) > 1e-8*math.Max(1, math.Abs(expected)) {
+			t.Errorf("%s at x=%f: expected %f but got %f (from %s)", expr, x, expected,
+				actual, poly.String("x"))
+		}
+	}
+}
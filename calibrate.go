@@ -0,0 +1,144 @@
+package algebrain
+
+import (
+	"context"
+	"math"
+)
+
+const (
+	minCalibrationTemperature = 0.05
+	maxCalibrationTemperature = 20
+
+	calibrationIterations = 60
+)
+
+// calibrationStep is one decoder step of a teacher-forced
+// expected response.
+type calibrationStep struct {
+	LogProbs []float64
+	Target   int
+}
+
+// CalibrateTemperature fits a temperature for the
+// network's output distributions which minimizes the
+// negative log-likelihood of the expected responses
+// (including terminators), using teacher forcing.
+//
+// A result above 1 means that the network is
+// overconfident on the samples.
+// The samples should be held out from training.
+// The result can be stored in CalibrationTemperature to
+// calibrate the confidences from QueryScored.
+//
+// It panics if a sample cannot be tokenized.
+func CalibrateTemperature(n *Network, samples []*Sample) float64 {
+	return fitTemperature(calibrationSteps(n, samples))
+}
+
+// QueryScored is like Query, but it also returns the
+// network's confidence in the response, i.e. the
+// probability of the decoded tokens (including the
+// terminator) when the output distributions are scaled by
+// the CalibrationTemperature.
+//
+// It panics if the query cannot be tokenized.
+func (n *Network) QueryScored(q string) (string, float64) {
+	temperature := n.CalibrationTemperature
+	if temperature == 0 {
+		temperature = 1
+	}
+	var logProb float64
+	res, err := n.decode(context.Background(), q, func(logProbs []float64) int {
+		token := argMaxFloats(logProbs)
+		logProb += temperatureLogProb(logProbs, token, temperature)
+		return token
+	})
+	if err != nil {
+		panic(err)
+	}
+	return res.Response, math.Exp(logProb)
+}
+
+func calibrationSteps(n *Network, samples []*Sample) []calibrationStep {
+	vocabSize := n.Tokenizer.VocabSize()
+	var res []calibrationStep
+	for i := 0; i < len(samples); i += evaluationBatchSize {
+		bs := evaluationBatchSize
+		if i+bs > len(samples) {
+			bs = len(samples) - i
+		}
+		batch, err := n.makeBatch(samples[i:i+bs], false)
+		if err != nil {
+			panic(err)
+		}
+		outs := n.applyTeacherForced(batch.EncIn, batch.DecIn).Output()
+		for t, target := range batch.DecOut.Output() {
+			logProbs := vectorFloats(outs[t].Packed)
+			targets := vectorFloats(target.Packed)
+			for j := 0; j < target.NumPresent(); j++ {
+				res = append(res, calibrationStep{
+					LogProbs: logProbs[j*vocabSize : (j+1)*vocabSize],
+					Target:   argMaxFloats(targets[j*vocabSize : (j+1)*vocabSize]),
+				})
+			}
+		}
+	}
+	return res
+}
+
+// fitTemperature minimizes calibrationNLL with a golden
+// section search over the log of the temperature.
+//
+// The NLL is convex in the inverse temperature, so it has
+// a single minimum in the search range.
+func fitTemperature(steps []calibrationStep) float64 {
+	if len(steps) == 0 {
+		return 1
+	}
+	nll := func(logTemp float64) float64 {
+		return calibrationNLL(steps, math.Exp(logTemp))
+	}
+	ratio := (math.Sqrt(5) - 1) / 2
+	a := math.Log(minCalibrationTemperature)
+	b := math.Log(maxCalibrationTemperature)
+	c := b - ratio*(b-a)
+	d := a + ratio*(b-a)
+	fc, fd := nll(c), nll(d)
+	for i := 0; i < calibrationIterations; i++ {
+		if fc < fd {
+			b, d, fd = d, c, fc
+			c = b - ratio*(b-a)
+			fc = nll(c)
+		} else {
+			a, c, fc = c, d, fd
+			d = a + ratio*(b-a)
+			fd = nll(d)
+		}
+	}
+	return math.Exp((a + b) / 2)
+}
+
+// calibrationNLL computes the total negative
+// log-likelihood of the targets at a temperature.
+func calibrationNLL(steps []calibrationStep, temperature float64) float64 {
+	var res float64
+	for _, step := range steps {
+		res -= temperatureLogProb(step.LogProbs, step.Target, temperature)
+	}
+	return res
+}
+
+// temperatureLogProb computes the log probability of an
+// index in the distribution given by log probabilities
+// divided by a temperature.
+func temperatureLogProb(logProbs []float64, idx int, temperature float64) float64 {
+	max := math.Inf(-1)
+	for _, x := range logProbs {
+		max = math.Max(max, x)
+	}
+	var total float64
+	for _, x := range logProbs {
+		total += math.Exp((x - max) / temperature)
+	}
+	return (logProbs[idx]-max)/temperature - math.Log(total)
+}
@@ -0,0 +1,142 @@
+package algebrain
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec/anyvec64"
+	"github.com/unixpickle/serializer"
+)
+
+func TestFitTemperature(t *testing.T) {
+	// The network is certain of every step, but it is only
+	// right half of the time, so the best distribution over
+	// the two likely tokens is uniform.
+	var steps []calibrationStep
+	for i := 0; i < 10; i++ {
+		steps = append(steps, calibrationStep{
+			LogProbs: []float64{math.Log(0.98), math.Log(0.01), math.Log(0.01)},
+			Target:   i % 2,
+		})
+	}
+	temperature := fitTemperature(steps)
+	if temperature <= 1 {
+		t.Fatalf("expected temperature above 1 but got %f", temperature)
+	}
+	nll := calibrationNLL(steps, temperature)
+	for _, other := range []float64{1, temperature * 1.1, temperature / 1.1} {
+		if otherNLL := calibrationNLL(steps, other); otherNLL < nll {
+			t.Errorf("temperature %f has lower NLL than %f (%f < %f)", other, temperature,
+				otherNLL, nll)
+		}
+	}
+
+	if actual := fitTemperature(nil); actual != 1 {
+		t.Errorf("expected temperature 1 without steps but got %f", actual)
+	}
+}
+
+func TestCalibrateTemperature(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	samples := []*Sample{
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "scale x by 2 in x", Response: "x*2"},
+	}
+	temperature := CalibrateTemperature(net, samples)
+	if temperature < minCalibrationTemperature || temperature > maxCalibrationTemperature {
+		t.Fatalf("temperature out of range: %f", temperature)
+	}
+	steps := calibrationSteps(net, samples)
+	if len(steps) != len("Result: 2")+len("x*2")+2 {
+		t.Errorf("unexpected number of steps: %d", len(steps))
+	}
+	nll := calibrationNLL(steps, temperature)
+	if initial := calibrationNLL(steps, 1); initial < nll {
+		t.Errorf("calibration increased the NLL from %f to %f", initial, nll)
+	}
+}
+
+func TestQueryScored(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+
+	// Make the network terminate immediately, so that the
+	// confidence of its (empty) response does not underflow.
+	outLayer := net.Output[0].(*anynet.FC)
+	biases := make([]float64, CharCount)
+	biases[Terminator] = 5
+	outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+
+	query := "evaluate 1+2"
+	expected, err := net.decode(context.Background(), query, argMaxFloats)
+	if err != nil {
+		t.Fatal(err)
+	}
+	response, confidence := net.QueryScored(query)
+	if response != expected.Response {
+		t.Errorf("expected response %q but got %q", expected.Response, response)
+	}
+	if response != "" || confidence <= 0 || confidence >= 1 {
+		t.Fatalf("unexpected response %q with confidence %f", response, confidence)
+	}
+	if math.Abs(confidence-math.Exp(expected.LogProb)) > 1e-8 {
+		t.Errorf("expected confidence %f but got %f", math.Exp(expected.LogProb),
+			confidence)
+	}
+
+	// A high temperature makes the network less confident
+	// without changing its response.
+	net.CalibrationTemperature = 5
+	hotResponse, hotConfidence := net.QueryScored(query)
+	if hotResponse != response {
+		t.Errorf("temperature changed response from %q to %q", response, hotResponse)
+	}
+	if hotConfidence >= confidence {
+		t.Errorf("expected confidence below %f but got %f", confidence, hotConfidence)
+	}
+}
+
+func TestCalibrationTemperatureSerialization(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	net.CalibrationTemperature = 1.5
+	data, err := serializer.SerializeAny(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded *Network
+	if err := serializer.DeserializeAny(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if loaded.CalibrationTemperature != 1.5 {
+		t.Errorf("expected temperature 1.5 but got %f", loaded.CalibrationTemperature)
+	}
+	if loaded.InputEncoder != nil {
+		t.Error("unexpected input encoder")
+	}
+	if !NetworksEqual(loaded, net, 0) {
+		t.Error("parameters were not preserved")
+	}
+}
+
+func TestReliabilityDiagram(t *testing.T) {
+	confidences := []float64{0.05, 0.15, 0.95, 1, 0.92}
+	correct := []bool{false, true, true, true, false}
+	buckets := reliabilityDiagram(confidences, correct, 10)
+	if len(buckets) != 10 {
+		t.Fatalf("expected 10 buckets but got %d", len(buckets))
+	}
+	counts := []int{1, 1, 0, 0, 0, 0, 0, 0, 0, 3}
+	for i, bucket := range buckets {
+		if bucket.Count != counts[i] {
+			t.Errorf("bucket %d: expected count %d but got %d", i, counts[i], bucket.Count)
+		}
+	}
+	last := buckets[9]
+	if math.Abs(last.MeanConfidence-(0.95+1+0.92)/3) > 1e-8 {
+		t.Errorf("unexpected mean confidence: %f", last.MeanConfidence)
+	}
+	if math.Abs(last.Accuracy-2.0/3) > 1e-8 {
+		t.Errorf("unexpected accuracy: %f", last.Accuracy)
+	}
+}
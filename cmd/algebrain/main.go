@@ -0,0 +1,329 @@
+// Command algebrain trains, evaluates, and queries
+// algebrain networks.
+//
+// Usage:
+//
+//	algebrain train [flags]
+//	algebrain eval [flags]
+//	algebrain query [flags] [query ...]
+//	algebrain repl [flags]
+//
+// Run a subcommand with -help to see its flags.
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/unixpickle/algebrain"
+	"github.com/unixpickle/anynet/anysgd"
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/serializer"
+)
+
+// Exit codes.
+const (
+	exitSuccess = 0
+	exitFailure = 1
+	exitUsage   = 2
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "Usage: algebrain <train|eval|query|repl> [flags]")
+		return exitUsage
+	}
+	switch args[0] {
+	case "train":
+		return runTrain(args[1:], stdout, stderr)
+	case "eval":
+		return runEval(args[1:], stdout, stderr)
+	case "query":
+		return runQuery(args[1:], stdin, stdout, stderr)
+	case "repl":
+		return runREPL(args[1:], stdin, stdout, stderr)
+	}
+	fmt.Fprintln(stderr, "Unknown subcommand:", args[0])
+	return exitUsage
+}
+
+func runTrain(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("train", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	modelPath := fs.String("model", "out_net", "network file to create or continue training")
+	genNames := fs.String("generators", "", "comma-separated generators (default: all)")
+	samplesPerGen := fs.Int("samples", 1000, "samples per generator")
+	batchSize := fs.Int("batch", 8, "SGD batch size")
+	stepSize := fs.Float64("step", 0.001, "SGD step size")
+	epochs := fs.Int("epochs", 1, "number of passes over the samples")
+	checkpointDir := fs.String("checkpoint", "", "optional directory for per-epoch checkpoints")
+	seed := fs.Int64("seed", 123, "random seed for expression-based generators")
+	tokenizerName := fs.String("tokenizer", "char", "tokenizer for new networks (char or word)")
+	reverse := fs.Bool("reverse", false, "reverse queries for new networks")
+	if fs.Parse(args) != nil {
+		return exitUsage
+	} else if fs.NArg() != 0 || *epochs < 1 || *batchSize < 1 || *samplesPerGen < 1 {
+		fmt.Fprintln(stderr, "Invalid arguments for train.")
+		return exitUsage
+	}
+
+	_, gens, err := selectGenerators(*genNames)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitUsage
+	}
+	var tokenizer algebrain.Tokenizer
+	switch *tokenizerName {
+	case "char":
+		tokenizer = &algebrain.CharTokenizer{}
+	case "word":
+		tokenizer = &algebrain.WordTokenizer{Words: algebrain.StandardWords}
+	default:
+		fmt.Fprintln(stderr, "Unknown tokenizer:", *tokenizerName)
+		return exitUsage
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	var samples algebrain.SampleList
+	for _, g := range gens {
+		seedGenerator(g, rng)
+		for i := 0; i < *samplesPerGen; i++ {
+			samples = append(samples, g.Generate())
+		}
+	}
+
+	net, err := algebrain.LoadOrCreate(*modelPath, func() (*algebrain.Network, error) {
+		net := algebrain.NewNetwork(anyvec32.CurrentCreator(), tokenizer)
+		net.ReverseInput = *reverse
+		return net, nil
+	})
+	if err != nil {
+		fmt.Fprintln(stderr, "Failed to load network:", err)
+		return exitFailure
+	}
+
+	batchesPerEpoch := (len(samples) + *batchSize - 1) / *batchSize
+	trainer := &algebrain.Trainer{Network: net}
+	done := make(chan struct{})
+	var batchIdx int
+	var checkpointErr error
+	sgd := &anysgd.SGD{
+		Fetcher:     trainer,
+		Gradienter:  trainer,
+		Transformer: &anysgd.Adam{},
+		Samples:     samples,
+		Rater:       anysgd.ConstRater(*stepSize),
+		BatchSize:   *batchSize,
+		StatusFunc: func(b anysgd.Batch) {
+			if batchIdx > 0 && batchIdx%batchesPerEpoch == 0 {
+				epoch := batchIdx / batchesPerEpoch
+				fmt.Fprintf(stdout, "epoch %d: cost=%v\n", epoch, trainer.LastCost)
+				if *checkpointDir != "" && checkpointErr == nil {
+					path := filepath.Join(*checkpointDir, fmt.Sprintf("epoch_%d", epoch))
+					checkpointErr = serializer.SaveAny(path, net)
+				}
+				if epoch == *epochs || checkpointErr != nil {
+					close(done)
+				}
+			}
+			batchIdx++
+		},
+	}
+	if err := sgd.Run(done); err != nil {
+		fmt.Fprintln(stderr, "Training failed:", err)
+		return exitFailure
+	} else if checkpointErr != nil {
+		fmt.Fprintln(stderr, "Failed to save checkpoint:", checkpointErr)
+		return exitFailure
+	}
+	if err := serializer.SaveAny(*modelPath, net); err != nil {
+		fmt.Fprintln(stderr, "Failed to save network:", err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func runEval(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("eval", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	modelPath := fs.String("model", "out_net", "network file")
+	genNames := fs.String("generators", "", "comma-separated generators (default: all)")
+	samples := fs.Int("samples", 100, "samples per generator")
+	srcPath := fs.String("src", "", "query file from a dataset export (instead of generators)")
+	tgtPath := fs.String("tgt", "", "response file from a dataset export")
+	commutative := fs.Bool("commutative", false, "accept commutatively equal responses")
+	if fs.Parse(args) != nil {
+		return exitUsage
+	} else if fs.NArg() != 0 || *samples < 1 || (*srcPath == "") != (*tgtPath == "") ||
+		(*srcPath != "" && *genNames != "") {
+		fmt.Fprintln(stderr, "Invalid arguments for eval.")
+		return exitUsage
+	}
+
+	var names []string
+	var sampleSets [][]*algebrain.Sample
+	if *srcPath != "" {
+		dataset, err := algebrain.ReadParallelText(*srcPath, *tgtPath)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitFailure
+		}
+		names = []string{filepath.Base(*srcPath)}
+		sampleSets = [][]*algebrain.Sample{dataset}
+	} else {
+		var gens []algebrain.Generator
+		var err error
+		names, gens, err = selectGenerators(*genNames)
+		if err != nil {
+			fmt.Fprintln(stderr, err)
+			return exitUsage
+		}
+		for _, g := range gens {
+			set := make([]*algebrain.Sample, *samples)
+			for i := range set {
+				set[i] = g.Generate()
+			}
+			sampleSets = append(sampleSets, set)
+		}
+	}
+
+	net, code := loadNetwork(*modelPath, stderr)
+	if net == nil {
+		return code
+	}
+	suite := &algebrain.EvaluationSuite{Commutative: *commutative}
+	fmt.Fprint(stdout, suite.RunSamples(net, names, sampleSets))
+	return exitSuccess
+}
+
+func runQuery(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	modelPath := fs.String("model", "out_net", "network file")
+	if fs.Parse(args) != nil {
+		return exitUsage
+	}
+	net, code := loadNetwork(*modelPath, stderr)
+	if net == nil {
+		return code
+	}
+	query := func(q string) bool {
+		res, err := net.QueryContext(context.Background(), q)
+		if err != nil {
+			fmt.Fprintln(stderr, "Query failed:", err)
+			return false
+		}
+		fmt.Fprintln(stdout, res)
+		return true
+	}
+	if fs.NArg() > 0 {
+		if !query(strings.Join(fs.Args(), " ")) {
+			return exitFailure
+		}
+		return exitSuccess
+	}
+	res := exitSuccess
+	scanner := bufio.NewScanner(stdin)
+	for scanner.Scan() {
+		if !query(strings.TrimRight(scanner.Text(), "\r")) {
+			res = exitFailure
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintln(stderr, "Failed to read queries:", err)
+		return exitFailure
+	}
+	return res
+}
+
+func runREPL(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("repl", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	modelPath := fs.String("model", "out_net", "network file")
+	temperature := fs.Float64("temp", 0, "sampling temperature (0 for greedy)")
+	seed := fs.Int64("seed", 123, "random seed for sampling")
+	if fs.Parse(args) != nil {
+		return exitUsage
+	} else if fs.NArg() != 0 || *temperature < 0 {
+		fmt.Fprintln(stderr, "Invalid arguments for repl.")
+		return exitUsage
+	}
+	net, code := loadNetwork(*modelPath, stderr)
+	if net == nil {
+		return code
+	}
+	repl := &algebrain.REPL{
+		Network:     net,
+		Temperature: *temperature,
+		Rand:        rand.New(rand.NewSource(*seed)),
+		Prompt:      "Query> ",
+	}
+	if err := repl.Run(stdin, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return exitFailure
+	}
+	return exitSuccess
+}
+
+func loadNetwork(path string, stderr io.Writer) (*algebrain.Network, int) {
+	var net *algebrain.Network
+	if err := serializer.LoadAny(path, &net); err != nil {
+		fmt.Fprintln(stderr, "Failed to load network:", err)
+		return nil, exitFailure
+	}
+	return net, exitSuccess
+}
+
+func selectGenerators(names string) ([]string, []algebrain.Generator, error) {
+	allNames, gens := algebrain.EvaluationGenerators()
+	if names == "" {
+		names = strings.Join(allNames, ",")
+	}
+	var resNames []string
+	var res []algebrain.Generator
+	for _, name := range strings.Split(names, ",") {
+		if g, ok := gens[name]; ok {
+			resNames = append(resNames, name)
+			res = append(res, g)
+		} else {
+			return nil, nil, fmt.Errorf("unknown generator: %s (options: %s)", name,
+				strings.Join(allNames, ", "))
+		}
+	}
+	return resNames, res, nil
+}
+
+// seedGenerator makes the expression generator used by g,
+// if there is one, draw from rng.
+//
+// Other generators draw from the global source, so their
+// samples differ between runs regardless of the seed.
+func seedGenerator(g algebrain.Generator, rng *rand.Rand) {
+	switch g := g.(type) {
+	case *algebrain.ShiftGenerator:
+		g.Generator.Rand = rng
+	case *algebrain.ScaleGenerator:
+		g.Generator.Rand = rng
+	case *algebrain.MultiScaleGenerator:
+		g.Generator.Rand = rng
+	case *algebrain.PartialDerivativeGenerator:
+		g.Generator.Rand = rng
+	case *algebrain.EvalGenerator:
+		g.Generator.Rand = rng
+	case *algebrain.AugmentedShiftGenerator:
+		g.ShiftGenerator.Generator.Rand = rng
+	case *algebrain.SelfVerifyingGenerator:
+		seedGenerator(g.Generator, rng)
+	}
+}
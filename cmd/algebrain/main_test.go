@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRunUsage(t *testing.T) {
+	cases := [][]string{
+		{},
+		{"foo"},
+		{"train", "-epochs", "0"},
+		{"train", "-generators", "NotAGenerator"},
+		{"train", "-tokenizer", "bytes"},
+		{"eval", "-badflag"},
+		{"eval", "extra"},
+		{"eval", "-generators", "NotAGenerator"},
+		{"eval", "-src", "src.txt"},
+		{"eval", "-src", "src.txt", "-tgt", "tgt.txt", "-generators", "Eval"},
+		{"query", "-badflag"},
+		{"repl", "-temp", "-1"},
+	}
+	for _, args := range cases {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code != exitUsage {
+			t.Errorf("%v: expected exit code %d but got %d", args, exitUsage, code)
+		}
+		if stderr.Len() == 0 {
+			t.Errorf("%v: expected error output", args)
+		}
+	}
+}
+
+func TestRunMissingModel(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "missing")
+	for _, args := range [][]string{
+		{"eval", "-model", missing},
+		{"query", "-model", missing, "evaluate 1+1"},
+		{"repl", "-model", missing},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := run(args, strings.NewReader(""), &stdout, &stderr); code != exitFailure {
+			t.Errorf("%v: expected exit code %d but got %d", args, exitFailure, code)
+		}
+	}
+}
+
+func TestRunTrainQuery(t *testing.T) {
+	dir := t.TempDir()
+	modelPath := filepath.Join(dir, "net")
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"train", "-model", modelPath, "-generators", "Eval,BaseConversion",
+		"-samples", "2", "-batch", "2", "-epochs", "2", "-checkpoint", dir},
+		nil, &stdout, &stderr)
+	if code != exitSuccess {
+		t.Fatalf("train failed with code %d: %s", code, stderr.String())
+	}
+	for _, name := range []string{"net", "epoch_1", "epoch_2"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Error(err)
+		}
+	}
+
+	stdout.Reset()
+	code = run([]string{"eval", "-model", modelPath, "-samples", "1"}, nil, &stdout, &stderr)
+	if code != exitSuccess || !strings.Contains(stdout.String(), "Accuracy") {
+		t.Errorf("unexpected eval result: %d %q", code, stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"eval", "-model", modelPath, "-samples", "1", "-generators",
+		"Copy,Eval"}, nil, &stdout, &stderr)
+	if code != exitSuccess || !strings.Contains(stdout.String(), "Copy") ||
+		strings.Contains(stdout.String(), "Shift") {
+		t.Errorf("unexpected eval result: %d %q", code, stdout.String())
+	}
+
+	srcPath := filepath.Join(dir, "src-test.txt")
+	tgtPath := filepath.Join(dir, "tgt-test.txt")
+	os.WriteFile(srcPath, []byte("e v a l u a t e <space> 1 + 1\n"), 0644)
+	os.WriteFile(tgtPath, []byte("2\n"), 0644)
+	stdout.Reset()
+	code = run([]string{"eval", "-model", modelPath, "-src", srcPath, "-tgt", tgtPath}, nil,
+		&stdout, &stderr)
+	if code != exitSuccess || !strings.Contains(stdout.String(), "src-test.txt") {
+		t.Errorf("unexpected eval result: %d %q", code, stdout.String())
+	}
+
+	stdout.Reset()
+	code = run([]string{"query", "-model", modelPath, "evaluate", "1+1"}, nil, &stdout,
+		&stderr)
+	if code != exitSuccess || strings.Count(stdout.String(), "\n") != 1 {
+		t.Errorf("unexpected query result: %d %q", code, stdout.String())
+	}
+
+	stdout.Reset()
+	stdin := strings.NewReader("evaluate 1+1\nevaluate 2+2\n")
+	code = run([]string{"query", "-model", modelPath}, stdin, &stdout, &stderr)
+	if code != exitSuccess || strings.Count(stdout.String(), "\n") != 2 {
+		t.Errorf("unexpected query result: %d %q", code, stdout.String())
+	}
+
+	stdout.Reset()
+	stdin = strings.NewReader("evaluate 1+1\n:temp 0.5\nevaluate 1+1\n")
+	code = run([]string{"repl", "-model", modelPath}, stdin, &stdout, &stderr)
+	if code != exitSuccess || strings.Count(stdout.String(), "Query> ") != 4 {
+		t.Errorf("unexpected repl result: %d %q", code, stdout.String())
+	}
+
+	stdin = strings.NewReader("evaluate ∑\n")
+	if code := run([]string{"query", "-model", modelPath}, stdin, &stdout,
+		&stderr); code != exitFailure {
+		t.Errorf("expected failure for invalid query but got %d", code)
+	}
+}
+
+func TestRunTrainCorruptModel(t *testing.T) {
+	modelPath := filepath.Join(t.TempDir(), "net")
+	if err := os.WriteFile(modelPath, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var stdout, stderr bytes.Buffer
+	code := run([]string{"train", "-model", modelPath, "-generators", "Eval", "-samples", "2",
+		"-batch", "2"}, nil, &stdout, &stderr)
+	if code != exitFailure {
+		t.Errorf("expected exit code %d but got %d", exitFailure, code)
+	}
+	if data, _ := os.ReadFile(modelPath); string(data) != "corrupt" {
+		t.Error("corrupt model was overwritten")
+	}
+}
+
+func TestSeedGenerator(t *testing.T) {
+	names := "Shift,Scale,MultiScale,PartialDerivative,Eval,AugmentedShift,VerifiedShift"
+	generate := func() []string {
+		_, gens, err := selectGenerators(names)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rng := rand.New(rand.NewSource(1337))
+		var res []string
+		for _, g := range gens {
+			seedGenerator(g, rng)
+			for i := 0; i < 10; i++ {
+				sample := g.Generate()
+				res = append(res, sample.Query, sample.Response)
+			}
+		}
+		return res
+	}
+	if !reflect.DeepEqual(generate(), generate()) {
+		t.Error("samples differ for the same seed")
+	}
+}
@@ -0,0 +1,90 @@
+package algebrain
+
+import (
+	"fmt"
+	"math/big"
+	"math/rand"
+)
+
+// DefaultCombinatoricsMaxN is the default maximum number
+// of items used by a CombinatoricsGenerator.
+const DefaultCombinatoricsMaxN = 12
+
+// A CombinatoricsKind selects the kind of question asked
+// by a CombinatoricsGenerator.
+type CombinatoricsKind int
+
+const (
+	// CombinatoricsAny picks one of the other kinds at
+	// random for each sample.
+	CombinatoricsAny CombinatoricsKind = iota
+
+	// CombinatoricsFactorial asks questions like "how many
+	// ways to arrange 4 items", expecting "Result: 24".
+	CombinatoricsFactorial
+
+	// CombinatoricsPermutations asks questions like "how
+	// many ways to arrange 2 of 5 items", expecting
+	// "Result: 20".
+	CombinatoricsPermutations
+
+	// CombinatoricsCombinations asks questions like "5
+	// choose 2", expecting "Result: 10".
+	CombinatoricsCombinations
+)
+
+// A CombinatoricsGenerator generates Samples which count
+// arrangements and selections of items.
+//
+// Results are computed exactly, so MaxN may be large, but
+// results grow quickly and long responses may not fit in
+// a network's maximum response length.
+type CombinatoricsGenerator struct {
+	Kind CombinatoricsKind
+
+	// MaxN is the maximum number of items.
+	// If this is 0, DefaultCombinatoricsMaxN is used.
+	MaxN int
+}
+
+// Generate generates a combinatorics sample.
+func (c *CombinatoricsGenerator) Generate() *Sample {
+	maxN := c.MaxN
+	if maxN == 0 {
+		maxN = DefaultCombinatoricsMaxN
+	}
+	kind := c.Kind
+	if kind == CombinatoricsAny {
+		kind = CombinatoricsKind(rand.Intn(3) + 1)
+	}
+	n := rand.Intn(maxN) + 1
+	k := rand.Intn(n + 1)
+	var query string
+	var result *big.Int
+	switch kind {
+	case CombinatoricsFactorial:
+		query = fmt.Sprintf("how many ways to arrange %d items", n)
+		result = fallingFactorial(n, n)
+	case CombinatoricsPermutations:
+		query = fmt.Sprintf("how many ways to arrange %d of %d items", k, n)
+		result = fallingFactorial(n, k)
+	case CombinatoricsCombinations:
+		query = fmt.Sprintf("%d choose %d", n, k)
+		result = new(big.Int).Binomial(int64(n), int64(k))
+	default:
+		panic(fmt.Sprintf("unknown combinatorics kind: %d", kind))
+	}
+	return &Sample{
+		Query:    query,
+		Response: "Result: " + result.String(),
+	}
+}
+
+// fallingFactorial computes n*(n-1)*...*(n-k+1).
+func fallingFactorial(n, k int) *big.Int {
+	res := big.NewInt(1)
+	for i := 0; i < k; i++ {
+		res.Mul(res, big.NewInt(int64(n-i)))
+	}
+	return res
+}
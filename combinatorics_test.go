@@ -0,0 +1,65 @@
+package algebrain
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+func TestCombinatoricsGenerator(t *testing.T) {
+	for _, kind := range []CombinatoricsKind{CombinatoricsFactorial,
+		CombinatoricsPermutations, CombinatoricsCombinations} {
+		gen := &CombinatoricsGenerator{Kind: kind, MaxN: 30}
+		for i := 0; i < 200; i++ {
+			sample := gen.Generate()
+			var n, k int
+			var expected *big.Int
+			switch kind {
+			case CombinatoricsFactorial:
+				fmt.Sscanf(sample.Query, "how many ways to arrange %d items", &n)
+				expected = new(big.Int).MulRange(1, int64(n))
+			case CombinatoricsPermutations:
+				fmt.Sscanf(sample.Query, "how many ways to arrange %d of %d items", &k, &n)
+				expected = new(big.Int).MulRange(int64(n-k+1), int64(n))
+			case CombinatoricsCombinations:
+				fmt.Sscanf(sample.Query, "%d choose %d", &n, &k)
+				expected = new(big.Int).Binomial(int64(n), int64(k))
+			}
+			if n < 1 || n > 30 || k < 0 || k > n {
+				t.Fatalf("invalid query: %s", sample.Query)
+			}
+			if sample.Response != "Result: "+expected.String() {
+				t.Fatalf("%s: expected %s but got %s", sample.Query, expected, sample.Response)
+			}
+		}
+	}
+
+	kinds := map[string]bool{}
+	gen := &CombinatoricsGenerator{}
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		if strings.Contains(sample.Query, "choose") {
+			kinds["choose"] = true
+		} else if strings.Contains(sample.Query, " of ") {
+			kinds["of"] = true
+		} else {
+			kinds["arrange"] = true
+		}
+	}
+	if len(kinds) != 3 {
+		t.Errorf("expected all kinds but got %v", kinds)
+	}
+}
+
+func TestFallingFactorial(t *testing.T) {
+	if res := fallingFactorial(5, 2); res.Int64() != 20 {
+		t.Errorf("expected 20 but got %s", res)
+	}
+	if res := fallingFactorial(5, 0); res.Int64() != 1 {
+		t.Errorf("expected 1 but got %s", res)
+	}
+	if res := fallingFactorial(25, 25).String(); res != "15511210043330985984000000" {
+		t.Errorf("unexpected 25!: %s", res)
+	}
+}
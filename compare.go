@@ -0,0 +1,257 @@
+package algebrain
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strings"
+
+	"github.com/unixpickle/essentials"
+)
+
+// NetworksEqual checks if every parameter of a differs
+// from the corresponding parameter of b by at most tol.
+//
+// Networks with different numbers or shapes of parameters
+// are never equal.
+func NetworksEqual(a, b *Network, tol float64) bool {
+	maxDiff, _ := NetworksDiff(a, b)
+	return maxDiff <= tol
+}
+
+// NetworksDiff computes the maximum absolute difference
+// between corresponding parameters of two networks.
+// It also returns the index (in Parameters()) of the
+// parameter where the maximum occurs.
+//
+// If the networks' parameters do not line up, the result
+// is +Inf and an index of -1.
+// If any difference is NaN, the result is NaN along with
+// the index of the first parameter containing a NaN.
+func NetworksDiff(a, b *Network) (maxDiff float64, paramIdx int) {
+	params1, params2 := a.Parameters(), b.Parameters()
+	if len(params1) != len(params2) {
+		return math.Inf(1), -1
+	}
+	for i, p := range params1 {
+		if p.Vector.Len() != params2[i].Vector.Len() {
+			return math.Inf(1), -1
+		}
+	}
+	for i, p := range params1 {
+		values1 := vectorFloats(p.Vector)
+		values2 := vectorFloats(params2[i].Vector)
+		for j, x := range values1 {
+			diff := math.Abs(x - values2[j])
+			if math.IsNaN(diff) {
+				return diff, i
+			} else if diff > maxDiff {
+				maxDiff, paramIdx = diff, i
+			}
+		}
+	}
+	return
+}
+
+// A CompareEntry describes the responses of two networks
+// to one sample.
+type CompareEntry struct {
+	Query     string `json:"query"`
+	Expected  string `json:"expected"`
+	ResponseA string `json:"response_a"`
+	ResponseB string `json:"response_b"`
+}
+
+// A TagDelta compares the accuracy of two networks on the
+// samples with one tag.
+type TagDelta struct {
+	Tag       string  `json:"tag"`
+	Samples   int     `json:"samples"`
+	AccuracyA float64 `json:"accuracy_a"`
+	AccuracyB float64 `json:"accuracy_b"`
+}
+
+// Delta is the change in accuracy from A to B.
+func (t *TagDelta) Delta() float64 {
+	return t.AccuracyB - t.AccuracyA
+}
+
+// A CompareReport lists the samples where the responses of
+// two networks, A and B, differ in correctness.
+type CompareReport struct {
+	// Fixed contains samples which A got wrong and B got
+	// right.
+	Fixed []*CompareEntry `json:"fixed"`
+
+	// Regressed contains samples which A got right and B
+	// got wrong.
+	Regressed []*CompareEntry `json:"regressed"`
+
+	// BothWrong contains samples which both networks got
+	// wrong with different responses.
+	BothWrong []*CompareEntry `json:"both_wrong"`
+
+	// Unchanged is the number of other samples.
+	Unchanged int `json:"unchanged"`
+
+	// Tags contains per-tag accuracies, sorted by tag.
+	Tags []*TagDelta `json:"tags"`
+}
+
+// CompareNetworks evaluates two networks on the same
+// samples to find fixes and regressions.
+//
+// Each sample is tagged with the first word of its query,
+// e.g. "shift" or "evaluate", which identifies the task.
+func CompareNetworks(a, b Querier, samples []*Sample) *CompareReport {
+	responsesA, correctA := evaluateSamples(a, samples, false)
+	responsesB, correctB := evaluateSamples(b, samples, false)
+	res := &CompareReport{}
+	tags := map[string]*TagDelta{}
+	for i, sample := range samples {
+		entry := &CompareEntry{
+			Query:     sample.Query,
+			Expected:  sample.Response,
+			ResponseA: responsesA[i],
+			ResponseB: responsesB[i],
+		}
+		switch {
+		case !correctA[i] && correctB[i]:
+			res.Fixed = append(res.Fixed, entry)
+		case correctA[i] && !correctB[i]:
+			res.Regressed = append(res.Regressed, entry)
+		case !correctA[i] && responsesA[i] != responsesB[i]:
+			res.BothWrong = append(res.BothWrong, entry)
+		default:
+			res.Unchanged++
+		}
+
+		tag := sampleTag(sample)
+		delta, ok := tags[tag]
+		if !ok {
+			delta = &TagDelta{Tag: tag}
+			tags[tag] = delta
+			res.Tags = append(res.Tags, delta)
+		}
+		delta.Samples++
+		if correctA[i] {
+			delta.AccuracyA++
+		}
+		if correctB[i] {
+			delta.AccuracyB++
+		}
+	}
+	for _, delta := range res.Tags {
+		delta.AccuracyA /= float64(delta.Samples)
+		delta.AccuracyB /= float64(delta.Samples)
+	}
+	sort.Slice(res.Tags, func(i, j int) bool {
+		return res.Tags[i].Tag < res.Tags[j].Tag
+	})
+	return res
+}
+
+// String formats the report as a table of per-tag
+// accuracies, followed by the fixed, regressed, and
+// both-wrong samples.
+func (c *CompareReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%-16s %8s %10s %10s %10s\n", "Tag", "Samples", "AccuracyA",
+		"AccuracyB", "Delta")
+	for _, t := range c.Tags {
+		fmt.Fprintf(&buf, "%-16s %8d %10.4f %10.4f %+10.4f\n", t.Tag, t.Samples,
+			t.AccuracyA, t.AccuracyB, t.Delta())
+	}
+	fmt.Fprintf(&buf, "\nfixed=%d regressed=%d both_wrong=%d unchanged=%d\n", len(c.Fixed),
+		len(c.Regressed), len(c.BothWrong), c.Unchanged)
+	sections := []struct {
+		Name    string
+		Entries []*CompareEntry
+	}{{"Fixed", c.Fixed}, {"Regressed", c.Regressed}, {"Both wrong", c.BothWrong}}
+	for _, section := range sections {
+		if len(section.Entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "\n%s:\n", section.Name)
+		for _, e := range section.Entries {
+			fmt.Fprintf(&buf, "  %q expected %q: A=%q B=%q\n", e.Query, e.Expected,
+				e.ResponseA, e.ResponseB)
+		}
+	}
+	return buf.String()
+}
+
+// WriteJSON writes the report as a JSON document.
+func (c *CompareReport) WriteJSON(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(c); err != nil {
+		return essentials.AddCtx("write compare report", err)
+	}
+	return nil
+}
+
+// maxDisagreements is the maximum number of disagreements
+// listed in an AgreementReport.
+const maxDisagreements = 100
+
+// A Disagreement lists the different responses of two
+// networks to a query.
+type Disagreement struct {
+	Query     string `json:"query"`
+	ResponseA string `json:"response_a"`
+	ResponseB string `json:"response_b"`
+}
+
+// An AgreementReport describes how often two networks give
+// the same response.
+type AgreementReport struct {
+	// Agree indicates, for each query, whether the
+	// responses matched exactly.
+	Agree []bool `json:"agree"`
+
+	// Rate is the fraction of queries with matching
+	// responses, or 1 if there are no queries.
+	Rate float64 `json:"rate"`
+
+	// Disagreements lists the first (at most 100) queries
+	// with different responses, in order.
+	Disagreements []*Disagreement `json:"disagreements"`
+}
+
+// CompareOutputs queries two networks on the same probe
+// queries to measure how much their behavior differs.
+//
+// Unlike CompareNetworks, no expected responses are
+// needed, so any queries can be used, e.g. to check that
+// a change to training did not alter a model's behavior.
+func CompareOutputs(a, b Querier, queries []string) *AgreementReport {
+	res := &AgreementReport{Agree: make([]bool, len(queries)), Rate: 1}
+	var numAgree int
+	for i, q := range queries {
+		responseA, responseB := a.Query(q), b.Query(q)
+		if responseA == responseB {
+			res.Agree[i] = true
+			numAgree++
+		} else if len(res.Disagreements) < maxDisagreements {
+			res.Disagreements = append(res.Disagreements, &Disagreement{
+				Query:     q,
+				ResponseA: responseA,
+				ResponseB: responseB,
+			})
+		}
+	}
+	if len(queries) > 0 {
+		res.Rate = float64(numAgree) / float64(len(queries))
+	}
+	return res
+}
+
+func sampleTag(s *Sample) string {
+	fields := strings.Fields(s.Query)
+	if len(fields) == 0 {
+		return ""
+	}
+	return fields[0]
+}
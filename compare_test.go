@@ -0,0 +1,193 @@
+package algebrain
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec/anyvec64"
+	"github.com/unixpickle/serializer"
+)
+
+func TestNetworksEqual(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	if !NetworksEqual(net, net, 0) {
+		t.Error("network should equal itself")
+	}
+
+	data, err := serializer.SerializeAny(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var copied *Network
+	if err := serializer.DeserializeAny(data, &copied); err != nil {
+		t.Fatal(err)
+	}
+	if !NetworksEqual(net, copied, 0) {
+		t.Error("network should equal its copy")
+	}
+
+	param := copied.Parameters()[3].Vector
+	values := param.Data().([]float64)
+	values[2] += 0.5
+	param.SetData(values)
+	if NetworksEqual(net, copied, 0.49) || !NetworksEqual(net, copied, 0.51) {
+		t.Error("unexpected equality result for perturbed network")
+	}
+	if maxDiff, idx := NetworksDiff(net, copied); idx != 3 || math.Abs(maxDiff-0.5) > 1e-8 {
+		t.Errorf("unexpected diff: %f at %d", maxDiff, idx)
+	}
+
+	nanParam := copied.Parameters()[1].Vector
+	values = nanParam.Data().([]float64)
+	values[0] = math.NaN()
+	nanParam.SetData(values)
+	if NetworksEqual(net, copied, math.Inf(1)) {
+		t.Error("networks with NaNs should not be equal")
+	}
+	if maxDiff, idx := NetworksDiff(net, copied); idx != 1 || !math.IsNaN(maxDiff) {
+		t.Errorf("unexpected diff: %f at %d", maxDiff, idx)
+	}
+
+	other := NewNetwork(anyvec64.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+	if NetworksEqual(net, other, 1e10) {
+		t.Error("networks with different shapes should not be equal")
+	}
+}
+
+func TestCompareNetworks(t *testing.T) {
+	// Network a always responds with an empty string, and
+	// network b always responds with a maximum-length
+	// string of ones.
+	a := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	b := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	for net, token := range map[*Network]int{a: Terminator, b: '1'} {
+		outLayer := net.Output[0].(*anynet.FC)
+		biases := make([]float64, CharCount)
+		biases[token] = 1000
+		outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+	}
+	ones := strings.Repeat("1", maxResponseLen)
+	samples := []*Sample{
+		{Query: "shift x by 1 in x", Response: ""},
+		{Query: "evaluate 1", Response: ones},
+		{Query: "evaluate 2", Response: "Result: 2"},
+	}
+	report := CompareNetworks(a, b, samples)
+	if len(report.Regressed) != 1 || report.Regressed[0].Query != samples[0].Query {
+		t.Errorf("unexpected regressions: %v", report.Regressed)
+	}
+	if len(report.Fixed) != 1 || report.Fixed[0].Query != samples[1].Query {
+		t.Errorf("unexpected fixes: %v", report.Fixed)
+	}
+	if len(report.BothWrong) != 1 || report.BothWrong[0].Query != samples[2].Query {
+		t.Errorf("unexpected both-wrong samples: %v", report.BothWrong)
+	}
+	if report.Unchanged != 0 {
+		t.Errorf("expected 0 unchanged but got %d", report.Unchanged)
+	}
+	expectedTags := []TagDelta{
+		{Tag: "evaluate", Samples: 2, AccuracyA: 0, AccuracyB: 0.5},
+		{Tag: "shift", Samples: 1, AccuracyA: 1, AccuracyB: 0},
+	}
+	if len(report.Tags) != len(expectedTags) {
+		t.Fatalf("expected %d tags but got %d", len(expectedTags), len(report.Tags))
+	}
+	for i, expected := range expectedTags {
+		if *report.Tags[i] != expected {
+			t.Errorf("tag %d: expected %v but got %v", i, expected, *report.Tags[i])
+		}
+	}
+	if !strings.Contains(report.String(), "fixed=1 regressed=1 both_wrong=1 unchanged=0") {
+		t.Errorf("unexpected report string: %s", report)
+	}
+
+	var buf bytes.Buffer
+	if err := report.WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var decoded CompareReport
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if len(decoded.Fixed) != 1 || len(decoded.Tags) != 2 {
+		t.Error("unexpected JSON report")
+	}
+
+	if report := CompareNetworks(a, a, samples); report.Unchanged != len(samples) {
+		t.Errorf("expected %d unchanged but got %d", len(samples), report.Unchanged)
+	}
+}
+
+// mapQuerier answers queries from a fixed map, without a
+// network.
+type mapQuerier map[string]string
+
+func (m mapQuerier) Query(q string) string {
+	return m[q]
+}
+
+func (m mapQuerier) QueryContext(ctx context.Context, q string) (string, error) {
+	return m[q], nil
+}
+
+func TestCompareNetworksQuerier(t *testing.T) {
+	samples := []*Sample{
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "evaluate 2+2", Response: "Result: 4"},
+	}
+	a := mapQuerier{"evaluate 1+1": "Result: 2", "evaluate 2+2": "Result: 5"}
+	b := mapQuerier{"evaluate 1+1": "Result: 2", "evaluate 2+2": "Result: 4"}
+	report := CompareNetworks(a, b, samples)
+	if len(report.Fixed) != 1 || report.Fixed[0].Query != "evaluate 2+2" ||
+		len(report.Regressed) != 0 || report.Unchanged != 1 {
+		t.Errorf("unexpected report: %s", report)
+	}
+}
+
+func TestCompareOutputs(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	data, err := serializer.SerializeAny(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var copied *Network
+	if err := serializer.DeserializeAny(data, &copied); err != nil {
+		t.Fatal(err)
+	}
+	queries := []string{"evaluate 1+1", "scale x by 2 in x", "shift x by 1 in x"}
+	report := CompareOutputs(net, copied, queries)
+	if report.Rate != 1 || len(report.Disagreements) != 0 {
+		t.Errorf("copy should always agree: %+v", report)
+	}
+
+	a := mapQuerier{"q1": "x", "q2": "y", "q3": "z"}
+	b := mapQuerier{"q1": "x", "q2": "w", "q3": "z"}
+	report = CompareOutputs(a, b, []string{"q1", "q2", "q3", "q2"})
+	if math.Abs(report.Rate-0.5) > 1e-8 {
+		t.Errorf("expected rate 0.5 but got %f", report.Rate)
+	}
+	expectedAgree := []bool{true, false, true, false}
+	for i, x := range expectedAgree {
+		if report.Agree[i] != x {
+			t.Errorf("query %d: expected agreement %v", i, x)
+		}
+	}
+	if len(report.Disagreements) != 2 || *report.Disagreements[0] !=
+		(Disagreement{Query: "q2", ResponseA: "y", ResponseB: "w"}) {
+		t.Errorf("unexpected disagreements: %v", report.Disagreements)
+	}
+
+	queries = make([]string, maxDisagreements+10)
+	for i := range queries {
+		queries[i] = "q2"
+	}
+	if report := CompareOutputs(a, b, queries); len(report.Disagreements) != maxDisagreements {
+		t.Errorf("expected %d disagreements but got %d", maxDisagreements,
+			len(report.Disagreements))
+	}
+}
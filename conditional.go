@@ -0,0 +1,86 @@
+package algebrain
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+)
+
+// Default bounds for a ConditionalGenerator.
+const (
+	DefaultConditionalMaxValue       = 10
+	DefaultConditionalMaxCoefficient = 3
+)
+
+var conditionalComparisons = map[string]func(x, threshold int) bool{
+	">":  func(x, t int) bool { return x > t },
+	"<":  func(x, t int) bool { return x < t },
+	">=": func(x, t int) bool { return x >= t },
+	"<=": func(x, t int) bool { return x <= t },
+}
+
+// A ConditionalGenerator generates Samples with queries
+// like "evaluate if x>0 then x else -x where x=-3",
+// expecting "Result: 3".
+//
+// The condition compares the variable to a constant, and
+// each branch is a linear expression a*x+b.
+type ConditionalGenerator struct {
+	// VarName is the variable to use.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// MaxValue is the maximum absolute value of the
+	// variable and of the constants in the condition and
+	// the branches.
+	// If this is 0, DefaultConditionalMaxValue is used.
+	MaxValue int
+
+	// MaxCoefficient is the maximum absolute value of the
+	// coefficient a in each branch.
+	// If this is 0, DefaultConditionalMaxCoefficient is
+	// used.
+	MaxCoefficient int
+}
+
+// Generate generates a conditional evaluation sample.
+func (c *ConditionalGenerator) Generate() *Sample {
+	varName := c.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	maxValue := c.MaxValue
+	if maxValue == 0 {
+		maxValue = DefaultConditionalMaxValue
+	}
+	maxCoeff := c.MaxCoefficient
+	if maxCoeff == 0 {
+		maxCoeff = DefaultConditionalMaxCoefficient
+	}
+	randInt := func(max int) int {
+		return rand.Intn(2*max+1) - max
+	}
+
+	ops := []string{">", "<", ">=", "<="}
+	op := ops[rand.Intn(len(ops))]
+	threshold := randInt(maxValue)
+	value := randInt(maxValue)
+	thenBranch := polynomial{randInt(maxValue), randInt(maxCoeff)}
+	var elseBranch polynomial
+	for elseBranch == nil || elseBranch.String(varName) == thenBranch.String(varName) {
+		// The branches should differ so that the condition
+		// matters.
+		elseBranch = polynomial{randInt(maxValue), randInt(maxCoeff)}
+	}
+
+	result := elseBranch
+	if conditionalComparisons[op](value, threshold) {
+		result = thenBranch
+	}
+	return &Sample{
+		Query: fmt.Sprintf("evaluate if %s%s%d then %s else %s where %s=%d", varName, op,
+			threshold, thenBranch.String(varName), elseBranch.String(varName), varName,
+			value),
+		Response: "Result: " + strconv.Itoa(int(result.evaluate(float64(value)))),
+	}
+}
@@ -0,0 +1,54 @@
+package algebrain
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestConditionalGenerator(t *testing.T) {
+	gen := &ConditionalGenerator{}
+	for i := 0; i < 1000; i++ {
+		sample := gen.Generate()
+		var op, threshold, branch1, branch2, value string
+		rest := strings.TrimPrefix(sample.Query, "evaluate if x")
+		for _, o := range []string{">=", "<=", ">", "<"} {
+			if strings.HasPrefix(rest, o) {
+				op = o
+				break
+			}
+		}
+		rest = strings.TrimPrefix(rest, op)
+		parts := strings.Split(rest, " ")
+		if op == "" || len(parts) != 7 || parts[1] != "then" || parts[3] != "else" ||
+			parts[5] != "where" || !strings.HasPrefix(parts[6], "x=") {
+			t.Fatalf("unexpected query: %s", sample.Query)
+		}
+		threshold, branch1, branch2, value = parts[0], parts[2], parts[4], parts[6][2:]
+		if branch1 == branch2 {
+			t.Errorf("identical branches: %s", sample.Query)
+		}
+
+		x, _ := strconv.Atoi(value)
+		limit, _ := strconv.Atoi(threshold)
+		cond := map[string]bool{">": x > limit, "<": x < limit, ">=": x >= limit,
+			"<=": x <= limit}[op]
+		branch := branch2
+		if cond {
+			branch = branch1
+		}
+		expr, err := mathexpr.ParseString(branch)
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected, err := mathexpr.Evaluate(expr, map[string]float64{"x": float64(x)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if sample.Response != "Result: "+strconv.Itoa(int(expected)) {
+			t.Errorf("%s: expected %v but got %q", sample.Query, expected, sample.Response)
+		}
+	}
+}
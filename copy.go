@@ -0,0 +1,83 @@
+package algebrain
+
+import "math/rand"
+
+// Defaults for CopyGenerator and ReverseGenerator.
+const (
+	DefaultCopyAlphabet  = "abcdefghijklmnopqrstuvwxyz0123456789"
+	DefaultCopyMaxLength = 10
+)
+
+// A CopyGenerator generates Samples with queries like
+// "copy abc123", expecting "abc123".
+//
+// Copying is a sanity check for the encoder and attention
+// mechanism, since it requires no math.
+type CopyGenerator struct {
+	// Alphabet contains the characters to copy.
+	// It must only contain characters below CharCount.
+	// If this is empty, DefaultCopyAlphabet is used.
+	Alphabet string
+
+	// MaxLength is the maximum string length.
+	// Strings contain at least one character.
+	// If this is 0, DefaultCopyMaxLength is used.
+	MaxLength int
+}
+
+// Generate generates a copy sample.
+func (c *CopyGenerator) Generate() *Sample {
+	s := randomCopyString(c.Alphabet, c.MaxLength)
+	return &Sample{
+		Query:    "copy " + s,
+		Response: s,
+	}
+}
+
+// A ReverseGenerator generates Samples with queries like
+// "reverse abc123", expecting "321cba".
+type ReverseGenerator struct {
+	// Alphabet contains the characters to reverse.
+	// It must only contain characters below CharCount.
+	// If this is empty, DefaultCopyAlphabet is used.
+	Alphabet string
+
+	// MaxLength is the maximum string length.
+	// Strings contain at least one character.
+	// If this is 0, DefaultCopyMaxLength is used.
+	MaxLength int
+}
+
+// Generate generates a reversal sample.
+func (r *ReverseGenerator) Generate() *Sample {
+	s := randomCopyString(r.Alphabet, r.MaxLength)
+	reversed := []rune(s)
+	for i := 0; i < len(reversed)/2; i++ {
+		j := len(reversed) - (i + 1)
+		reversed[i], reversed[j] = reversed[j], reversed[i]
+	}
+	return &Sample{
+		Query:    "reverse " + s,
+		Response: string(reversed),
+	}
+}
+
+func randomCopyString(alphabet string, maxLen int) string {
+	if alphabet == "" {
+		alphabet = DefaultCopyAlphabet
+	}
+	if maxLen == 0 {
+		maxLen = DefaultCopyMaxLength
+	}
+	chars := []rune(alphabet)
+	for _, x := range chars {
+		if err := checkCharToken(x); err != nil {
+			panic("invalid alphabet: " + err.Error())
+		}
+	}
+	res := make([]rune, rand.Intn(maxLen)+1)
+	for i := range res {
+		res[i] = chars[rand.Intn(len(chars))]
+	}
+	return string(res)
+}
@@ -0,0 +1,36 @@
+package algebrain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCopyGenerator(t *testing.T) {
+	gen := &CopyGenerator{Alphabet: "ab", MaxLength: 5}
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		if sample.Query != "copy "+sample.Response {
+			t.Fatalf("unexpected sample: %v", sample)
+		}
+		if len(sample.Response) < 1 || len(sample.Response) > 5 ||
+			strings.Trim(sample.Response, "ab") != "" {
+			t.Fatalf("unexpected response: %q", sample.Response)
+		}
+	}
+}
+
+func TestReverseGenerator(t *testing.T) {
+	gen := &ReverseGenerator{}
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		input := strings.TrimPrefix(sample.Query, "reverse ")
+		if len(input) != len(sample.Response) {
+			t.Fatalf("unexpected sample: %v", sample)
+		}
+		for j := range input {
+			if input[j] != sample.Response[len(input)-(j+1)] {
+				t.Fatalf("unexpected sample: %v", sample)
+			}
+		}
+	}
+}
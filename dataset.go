@@ -0,0 +1,169 @@
+package algebrain
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/unixpickle/essentials"
+)
+
+// ParallelTextSpace is the symbol which represents a space
+// token in parallel text files.
+const ParallelTextSpace = "<space>"
+
+// A DatasetExporter writes samples as parallel text files
+// for seq2seq toolkits such as Fairseq and OpenNMT.
+//
+// Each split is written as a pair of files, e.g.
+// "src-train.txt" and "tgt-train.txt", with one sample per
+// line.
+// Each line lists the tokens of a query or response,
+// separated by spaces, with ParallelTextSpace in place of
+// space tokens.
+type DatasetExporter struct {
+	// Tokenizer determines the symbols in each line.
+	// If this is nil, a CharTokenizer is used.
+	Tokenizer Tokenizer
+
+	// ValidFraction and TestFraction are the fractions of
+	// the samples in the "valid" and "test" splits.
+	// The remaining samples make up the "train" split.
+	ValidFraction float64
+	TestFraction  float64
+
+	// Seed determines how samples are assigned to splits.
+	Seed int64
+}
+
+// Split divides the samples into the train, valid, and
+// test splits.
+// The result only depends on the Seed and the order of the
+// samples.
+func (d *DatasetExporter) Split(samples []*Sample) (train, valid, test []*Sample) {
+	shuffled := append([]*Sample{}, samples...)
+	rng := rand.New(rand.NewSource(d.Seed))
+	rng.Shuffle(len(shuffled), func(i, j int) {
+		shuffled[i], shuffled[j] = shuffled[j], shuffled[i]
+	})
+	numValid := int(math.Round(d.ValidFraction * float64(len(samples))))
+	numTest := int(math.Round(d.TestFraction * float64(len(samples))))
+	if numValid+numTest > len(samples) {
+		numTest = len(samples) - numValid
+	}
+	valid = shuffled[:numValid]
+	test = shuffled[numValid : numValid+numTest]
+	train = shuffled[numValid+numTest:]
+	return
+}
+
+// Export splits the samples and writes each split to dir.
+func (d *DatasetExporter) Export(dir string, samples []*Sample) error {
+	train, valid, test := d.Split(samples)
+	splits := []struct {
+		Name    string
+		Samples []*Sample
+	}{{"train", train}, {"valid", valid}, {"test", test}}
+	for _, split := range splits {
+		srcPath := filepath.Join(dir, "src-"+split.Name+".txt")
+		tgtPath := filepath.Join(dir, "tgt-"+split.Name+".txt")
+		if err := d.writeSplit(srcPath, tgtPath, split.Samples); err != nil {
+			return essentials.AddCtx("export dataset", err)
+		}
+	}
+	return nil
+}
+
+func (d *DatasetExporter) writeSplit(srcPath, tgtPath string, samples []*Sample) error {
+	var src, tgt strings.Builder
+	for _, s := range samples {
+		query, err := d.parallelTextLine(s.Query)
+		if err != nil {
+			return err
+		}
+		response, err := d.parallelTextLine(s.Response)
+		if err != nil {
+			return err
+		}
+		src.WriteString(query + "\n")
+		tgt.WriteString(response + "\n")
+	}
+	if err := os.WriteFile(srcPath, []byte(src.String()), 0644); err != nil {
+		return err
+	}
+	return os.WriteFile(tgtPath, []byte(tgt.String()), 0644)
+}
+
+func (d *DatasetExporter) parallelTextLine(s string) (string, error) {
+	tokenizer := d.Tokenizer
+	if tokenizer == nil {
+		tokenizer = &CharTokenizer{}
+	}
+	tokens, err := tokenizer.Encode(s)
+	if err != nil {
+		return "", err
+	}
+	symbols := make([]string, len(tokens))
+	for i, token := range tokens {
+		symbol := tokenizer.Decode([]int{token})
+		if symbol == " " {
+			symbol = ParallelTextSpace
+		} else if strings.TrimSpace(symbol) != symbol || symbol == ParallelTextSpace {
+			return "", fmt.Errorf("unsupported token: %q", symbol)
+		}
+		symbols[i] = symbol
+	}
+	return strings.Join(symbols, " "), nil
+}
+
+// ReadParallelText reads samples from a pair of files
+// written by a DatasetExporter.
+//
+// Every sample is checked with Validate, and the error for
+// an invalid sample includes its line number.
+func ReadParallelText(srcPath, tgtPath string) ([]*Sample, error) {
+	queries, err := readParallelTextFile(srcPath)
+	if err != nil {
+		return nil, essentials.AddCtx("read parallel text", err)
+	}
+	responses, err := readParallelTextFile(tgtPath)
+	if err != nil {
+		return nil, essentials.AddCtx("read parallel text", err)
+	}
+	if len(queries) != len(responses) {
+		return nil, errors.New("read parallel text: line count mismatch")
+	}
+	res := make([]*Sample, len(queries))
+	for i, query := range queries {
+		res[i] = &Sample{Query: query, Response: responses[i]}
+		if err := res[i].Validate(); err != nil {
+			return nil, fmt.Errorf("read parallel text: line %d: %v", i+1, err)
+		}
+	}
+	return res, nil
+}
+
+func readParallelTextFile(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var res []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		symbols := strings.Fields(scanner.Text())
+		for i, symbol := range symbols {
+			if symbol == ParallelTextSpace {
+				symbols[i] = " "
+			}
+		}
+		res = append(res, strings.Join(symbols, ""))
+	}
+	return res, scanner.Err()
+}
@@ -0,0 +1,103 @@
+package algebrain
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestDatasetExporter(t *testing.T) {
+	var samples []*Sample
+	gen := &PercentChangeGenerator{}
+	for i := 0; i < 100; i++ {
+		samples = append(samples, gen.Generate())
+	}
+	samples = append(samples, &Sample{Query: "scale x by 2 in x", Response: "x*2"})
+
+	for _, tokenizer := range []Tokenizer{nil, &WordTokenizer{Words: StandardWords}} {
+		exporter := &DatasetExporter{
+			Tokenizer:     tokenizer,
+			ValidFraction: 0.1,
+			TestFraction:  0.2,
+			Seed:          1337,
+		}
+		dir := t.TempDir()
+		if err := exporter.Export(dir, samples); err != nil {
+			t.Fatal(err)
+		}
+
+		seen := map[*Sample]bool{}
+		expectedSizes := map[string]int{"train": 71, "valid": 10, "test": 20}
+		train, valid, test := exporter.Split(samples)
+		for name, split := range map[string][]*Sample{"train": train, "valid": valid,
+			"test": test} {
+			if len(split) != expectedSizes[name] {
+				t.Errorf("%s: expected %d samples but got %d", name, expectedSizes[name],
+					len(split))
+			}
+			for _, s := range split {
+				if seen[s] {
+					t.Errorf("%s: sample appears in multiple splits", name)
+				}
+				seen[s] = true
+			}
+
+			loaded, err := ReadParallelText(filepath.Join(dir, "src-"+name+".txt"),
+				filepath.Join(dir, "tgt-"+name+".txt"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(loaded) != len(split) {
+				t.Fatalf("%s: expected %d samples but read %d", name, len(split), len(loaded))
+			}
+			for i, s := range loaded {
+				if s.Query != split[i].Query || s.Response != split[i].Response {
+					t.Errorf("%s: sample %d: expected %v but got %v", name, i, split[i], s)
+				}
+			}
+		}
+		if len(seen) != len(samples) {
+			t.Errorf("expected %d samples in total but got %d", len(samples), len(seen))
+		}
+
+		train1, _, _ := exporter.Split(samples)
+		for i, s := range train1 {
+			if s != train[i] {
+				t.Fatal("split is not deterministic")
+			}
+		}
+	}
+
+	dir := t.TempDir()
+	exporter := &DatasetExporter{Tokenizer: &WordTokenizer{Words: StandardWords}}
+	if err := exporter.Export(dir, samples[len(samples)-1:]); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "src-train.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "scale <space> x <space> by <space> 2 <space> in <space> x\n"
+	if string(data) != expected {
+		t.Errorf("expected %q but got %q", expected, string(data))
+	}
+}
+
+func TestReadParallelTextInvalid(t *testing.T) {
+	dir := t.TempDir()
+	srcPath := filepath.Join(dir, "src.txt")
+	tgtPath := filepath.Join(dir, "tgt.txt")
+	src := "e v a l u a t e <space> 1\ne v a l u a t e <space> π\n"
+	tgt := "R e s u l t : <space> 1\nR e s u l t : <space> 3\n"
+	if err := os.WriteFile(srcPath, []byte(src), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(tgtPath, []byte(tgt), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := ReadParallelText(srcPath, tgtPath)
+	if err == nil || !strings.Contains(err.Error(), "line 2") {
+		t.Errorf("expected error for line 2 but got %v", err)
+	}
+}
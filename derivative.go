@@ -0,0 +1,278 @@
+package algebrain
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+	"github.com/unixpickle/essentials"
+)
+
+// A PartialDerivativeGenerator generates Samples with
+// queries like "partial derivative of x^2*y+z with respect
+// to y", expecting "x^2".
+//
+// Every expression uses at least two variables, so the
+// Generator must have at least two VarNames, and MaxDepth
+// must be at least 1.
+type PartialDerivativeGenerator struct {
+	Generator *mathexpr.Generator
+	MaxDepth  int
+}
+
+// partialDerivativeAttempts is the number of expressions
+// a PartialDerivativeGenerator tries before giving up on
+// finding one with at least two variables.
+const partialDerivativeAttempts = 1000
+
+// Generate generates a partial derivative sample.
+func (p *PartialDerivativeGenerator) Generate() *Sample {
+	if len(p.Generator.VarNames) < 2 {
+		panic("partial derivatives require at least two variables")
+	} else if p.MaxDepth < 1 {
+		panic("partial derivatives require a MaxDepth of at least 1")
+	}
+	var expr mathexpr.Node
+	var used []string
+	for i := 0; len(used) < 2; i++ {
+		if i == partialDerivativeAttempts {
+			panic("partial derivatives: no expression used two variables")
+		}
+		expr = p.Generator.Generate(p.MaxDepth)
+		used = usedVarNames(expr, p.Generator.VarNames)
+	}
+	wrt := used[randomIntn(p.Generator, len(used))]
+	query := fmt.Sprintf("partial derivative of %s with respect to %s", expr, wrt)
+	output := mathexpr.ConstantFold(differentiateNode(expr, wrt)).String()
+	return &Sample{
+		Query:    query,
+		Response: output,
+	}
+}
+
+// Default bounds for a ChainRuleGenerator.
+const (
+	DefaultChainRuleMaxDegree      = 3
+	DefaultChainRuleMaxCoefficient = 3
+)
+
+// A ChainRuleGenerator generates Samples which ask for the
+// derivative of a composition f(g(x)), with queries like
+// "derivative of (x^2+1)^3 with respect to x", expecting
+// "(6*(x^2+1)^2)*x".
+//
+// Both f and g are polynomials with integer coefficients,
+// so the derivative never involves division or logarithms.
+// The outer polynomial f has degree at least 2, and the
+// inner polynomial g has at least two terms.
+type ChainRuleGenerator struct {
+	// VarName is the variable to use.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// MaxDegree is the maximum degree of f and g, which is
+	// at least 2.
+	// If this is 0, DefaultChainRuleMaxDegree is used.
+	MaxDegree int
+
+	// MaxCoefficient is the maximum absolute value of each
+	// coefficient.
+	// If this is 0, DefaultChainRuleMaxCoefficient is used.
+	MaxCoefficient int
+}
+
+// Generate generates a chain rule sample.
+func (c *ChainRuleGenerator) Generate() *Sample {
+	varName := c.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	maxDegree := c.MaxDegree
+	if maxDegree == 0 {
+		maxDegree = DefaultChainRuleMaxDegree
+	}
+	maxCoeff := c.MaxCoefficient
+	if maxCoeff == 0 {
+		maxCoeff = DefaultChainRuleMaxCoefficient
+	}
+	outerDegree := 2 + rand.Intn(essentials.MaxInt(maxDegree-1, 1))
+	outer := randomPolynomial(outerDegree, maxCoeff)
+	var inner polynomial
+	for inner == nil || inner.numTerms() < 2 {
+		inner = randomPolynomial(1+rand.Intn(essentials.MaxInt(maxDegree, 1)), maxCoeff)
+	}
+	expr := substituteNode(outer.node(varName), varName, inner.node(varName))
+	query := fmt.Sprintf("derivative of %s with respect to %s", expr, varName)
+	output := mathexpr.ConstantFold(differentiateNode(expr, varName)).String()
+	return &Sample{
+		Query:    query,
+		Response: output,
+	}
+}
+
+// randomPolynomial generates a polynomial of the given
+// degree with a positive leading coefficient.
+func randomPolynomial(degree, maxCoeff int) polynomial {
+	res := make(polynomial, degree+1)
+	for i := range res[:degree] {
+		res[i] = rand.Intn(2*maxCoeff+1) - maxCoeff
+	}
+	res[degree] = 1 + rand.Intn(maxCoeff)
+	return res
+}
+
+// substituteNode replaces every occurrence of a variable
+// with a copy of the given expression.
+//
+// Each occurrence gets its own copy, so that in-place
+// operations like mathexpr.ConstantFold on one site do
+// not affect the others.
+func substituteNode(n mathexpr.Node, varName string, sub mathexpr.Node) mathexpr.Node {
+	if raw, ok := n.(mathexpr.RawNode); ok && string(raw) == varName {
+		return mathexpr.Clone(sub)
+	}
+	for i, x := range n.Children() {
+		n.SetChild(i, substituteNode(x, varName, sub))
+	}
+	return n
+}
+
+// differentiateNode computes the derivative of n with
+// respect to the variable varName, treating every other
+// variable as a constant.
+//
+// The result may share sub-trees with n.
+func differentiateNode(n mathexpr.Node, varName string) mathexpr.Node {
+	if !dependsOn(n, varName) {
+		return mathexpr.RawNode("0")
+	}
+	switch n := n.(type) {
+	case mathexpr.RawNode:
+		return mathexpr.RawNode("1")
+	case *mathexpr.NegOp:
+		return derivNeg(differentiateNode(n.Node, varName))
+	case *mathexpr.BinaryOp:
+		f, g := n.Left, n.Right
+		df, dg := differentiateNode(f, varName), differentiateNode(g, varName)
+		switch n.Op {
+		case mathexpr.AddOp:
+			return derivBinary(mathexpr.AddOp, df, dg)
+		case mathexpr.SubtractOp:
+			return derivBinary(mathexpr.SubtractOp, df, dg)
+		case mathexpr.MultiplyOp:
+			return derivBinary(mathexpr.AddOp, derivBinary(mathexpr.MultiplyOp, df, g),
+				derivBinary(mathexpr.MultiplyOp, f, dg))
+		case mathexpr.DivideOp:
+			if isNumber(dg, 0) {
+				return derivBinary(mathexpr.DivideOp, df, g)
+			}
+			numerator := derivBinary(mathexpr.SubtractOp,
+				derivBinary(mathexpr.MultiplyOp, df, g),
+				derivBinary(mathexpr.MultiplyOp, f, dg))
+			return derivBinary(mathexpr.DivideOp, numerator,
+				derivBinary(mathexpr.PowOp, g, mathexpr.RawNode("2")))
+		case mathexpr.PowOp:
+			if !dependsOn(g, varName) {
+				// d/dx f^c = c*f^(c-1)*f'
+				exponent := mathexpr.ConstantFold(&mathexpr.BinaryOp{
+					Op:    mathexpr.SubtractOp,
+					Left:  g,
+					Right: mathexpr.RawNode("1"),
+				})
+				return derivBinary(mathexpr.MultiplyOp,
+					derivBinary(mathexpr.MultiplyOp, g, derivBinary(mathexpr.PowOp, f, exponent)),
+					df)
+			}
+			// d/dx f^g = f^g*(g'*ln(f)+g*f'/f)
+			lnF := &mathexpr.FuncOp{Name: "ln", Args: []mathexpr.Node{f}}
+			return derivBinary(mathexpr.MultiplyOp, n, derivBinary(mathexpr.AddOp,
+				derivBinary(mathexpr.MultiplyOp, dg, lnF),
+				derivBinary(mathexpr.DivideOp, derivBinary(mathexpr.MultiplyOp, g, df), f)))
+		}
+	case *mathexpr.FuncOp:
+		if len(n.Args) == 1 {
+			arg := n.Args[0]
+			var outer mathexpr.Node
+			switch n.Name {
+			case "sin":
+				outer = &mathexpr.FuncOp{Name: "cos", Args: []mathexpr.Node{arg}}
+			case "cos":
+				outer = derivNeg(&mathexpr.FuncOp{Name: "sin", Args: []mathexpr.Node{arg}})
+			case "tan":
+				cos := &mathexpr.FuncOp{Name: "cos", Args: []mathexpr.Node{arg}}
+				outer = derivBinary(mathexpr.DivideOp, mathexpr.RawNode("1"),
+					derivBinary(mathexpr.PowOp, cos, mathexpr.RawNode("2")))
+			case "exp":
+				outer = n
+			case "ln":
+				return derivBinary(mathexpr.DivideOp, differentiateNode(arg, varName), arg)
+			}
+			if outer != nil {
+				return derivBinary(mathexpr.MultiplyOp, outer, differentiateNode(arg, varName))
+			}
+		}
+	}
+	panic("cannot differentiate: " + n.String())
+}
+
+// derivBinary creates a binary operation, removing terms
+// which are trivially zero or one.
+func derivBinary(op string, left, right mathexpr.Node) mathexpr.Node {
+	switch op {
+	case mathexpr.AddOp:
+		if isNumber(left, 0) {
+			return right
+		} else if isNumber(right, 0) {
+			return left
+		}
+	case mathexpr.SubtractOp:
+		if isNumber(right, 0) {
+			return left
+		} else if isNumber(left, 0) {
+			return derivNeg(right)
+		}
+	case mathexpr.MultiplyOp:
+		if isNumber(left, 0) || isNumber(right, 0) {
+			return mathexpr.RawNode("0")
+		} else if isNumber(left, 1) {
+			return right
+		} else if isNumber(right, 1) {
+			return left
+		}
+	case mathexpr.DivideOp:
+		if isNumber(left, 0) {
+			return mathexpr.RawNode("0")
+		} else if isNumber(right, 1) {
+			return left
+		}
+	case mathexpr.PowOp:
+		if isNumber(right, 0) {
+			return mathexpr.RawNode("1")
+		} else if isNumber(right, 1) {
+			return left
+		}
+	}
+	return &mathexpr.BinaryOp{Op: op, Left: left, Right: right}
+}
+
+func derivNeg(n mathexpr.Node) mathexpr.Node {
+	if isNumber(n, 0) {
+		return n
+	} else if neg, ok := n.(*mathexpr.NegOp); ok {
+		return neg.Node
+	}
+	return &mathexpr.NegOp{Node: n}
+}
+
+func isNumber(n mathexpr.Node, value float64) bool {
+	raw, ok := n.(mathexpr.RawNode)
+	if !ok {
+		return false
+	}
+	val, err := mathexpr.Evaluate(raw, nil)
+	return err == nil && val == value
+}
+
+func dependsOn(n mathexpr.Node, varName string) bool {
+	return len(usedVarNames(n, []string{varName})) > 0
+}
@@ -0,0 +1,161 @@
+package algebrain
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestDifferentiateNode(t *testing.T) {
+	x, y, z := mathexpr.RawNode("x"), mathexpr.RawNode("y"), mathexpr.RawNode("z")
+	exprs := []mathexpr.Node{
+		&mathexpr.BinaryOp{
+			Op:    mathexpr.MultiplyOp,
+			Left:  &mathexpr.BinaryOp{Op: mathexpr.MultiplyOp, Left: x, Right: y},
+			Right: z,
+		},
+		&mathexpr.BinaryOp{
+			Op: mathexpr.AddOp,
+			Left: &mathexpr.BinaryOp{
+				Op:    mathexpr.MultiplyOp,
+				Left:  &mathexpr.BinaryOp{Op: mathexpr.PowOp, Left: x, Right: mathexpr.RawNode("2")},
+				Right: y,
+			},
+			Right: z,
+		},
+		&mathexpr.FuncOp{
+			Name: "sin",
+			Args: []mathexpr.Node{&mathexpr.BinaryOp{Op: mathexpr.MultiplyOp, Left: x, Right: z}},
+		},
+	}
+	expected := []string{"x*z", "x^2", "0"}
+	for i, expr := range exprs {
+		actual := mathexpr.ConstantFold(differentiateNode(expr, "y")).String()
+		if actual != expected[i] {
+			t.Errorf("case %d: expected %s but got %s", i, expected[i], actual)
+		}
+	}
+}
+
+func TestPartialDerivativeGenerator(t *testing.T) {
+	gen := &PartialDerivativeGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x", "y", "z"},
+		},
+		MaxDepth: 3,
+	}
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		if !strings.HasPrefix(sample.Query, "partial derivative of ") {
+			t.Fatalf("unexpected query: %s", sample.Query)
+		}
+	}
+
+	for _, bad := range []*PartialDerivativeGenerator{
+		{Generator: &mathexpr.Generator{VarNames: []string{"x", "y"}}},
+		{Generator: &mathexpr.Generator{VarNames: []string{"x"}}, MaxDepth: 3},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for MaxDepth %d and variables %v",
+						bad.MaxDepth, bad.Generator.VarNames)
+				}
+			}()
+			bad.Generate()
+		}()
+	}
+
+	// Check derivatives numerically with finite differences.
+	point := map[string]float64{"x": 0.7, "y": 1.3, "z": 0.4}
+	const epsilon = 1e-5
+	for i := 0; i < 200; i++ {
+		expr := gen.Generator.Generate(3)
+		exprStr := expr.String()
+		before, err1 := mathexpr.Evaluate(expr, point)
+		point["y"] += epsilon
+		after, err2 := mathexpr.Evaluate(expr, point)
+		point["y"] -= epsilon
+		if err1 != nil || err2 != nil {
+			t.Fatal(err1, err2)
+		}
+		expected := (after - before) / epsilon
+		deriv := mathexpr.ConstantFold(differentiateNode(expr, "y"))
+		actual, err := mathexpr.Evaluate(deriv, point)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.IsNaN(expected) || math.IsInf(expected, 0) || math.Abs(expected) > 1e3 ||
+			math.Abs(before) > 1e6 {
+			continue
+		}
+		if math.Abs(actual-expected) > 1e-2*math.Max(1, math.Abs(expected)) {
+			t.Errorf("derivative of %s: expected %f but got %f (from %s)", exprStr,
+				expected, actual, deriv)
+		}
+	}
+}
+
+func TestChainRuleGenerator(t *testing.T) {
+	x := mathexpr.RawNode("x")
+	expr := &mathexpr.BinaryOp{
+		Op:    mathexpr.PowOp,
+		Left:  &mathexpr.BinaryOp{Op: mathexpr.AddOp, Left: x, Right: mathexpr.RawNode("1")},
+		Right: mathexpr.RawNode("2"),
+	}
+	actual := mathexpr.ConstantFold(differentiateNode(expr, "x")).String()
+	if actual != "2*(x+1)" {
+		t.Errorf("expected 2*(x+1) but got %s", actual)
+	}
+
+	chain := &ChainRuleGenerator{}
+	const epsilon = 1e-6
+	for i := 0; i < 100; i++ {
+		sample := chain.Generate()
+		if !strings.HasPrefix(sample.Query, "derivative of ") ||
+			!strings.HasSuffix(sample.Query, " with respect to x") {
+			t.Fatalf("unexpected query: %s", sample.Query)
+		}
+		if strings.ContainsAny(sample.Response, "/l") {
+			t.Errorf("response should be polynomial: %s", sample.Response)
+		}
+		exprStr := strings.TrimSuffix(strings.TrimPrefix(sample.Query, "derivative of "),
+			" with respect to x")
+		composed, err1 := mathexpr.ParseString(exprStr)
+		deriv, err2 := mathexpr.ParseString(sample.Response)
+		if err1 != nil || err2 != nil {
+			t.Fatal(err1, err2)
+		}
+		for _, point := range []float64{-0.5, 0.3, 1.1} {
+			before, err1 := mathexpr.Evaluate(composed, map[string]float64{"x": point})
+			after, err2 := mathexpr.Evaluate(composed, map[string]float64{"x": point + epsilon})
+			actual, err3 := mathexpr.Evaluate(deriv, map[string]float64{"x": point})
+			if err1 != nil || err2 != nil || err3 != nil {
+				t.Fatal(err1, err2, err3)
+			}
+			expected := (after - before) / epsilon
+			if math.Abs(actual-expected) > 1e-3*math.Max(1, math.Abs(expected)) {
+				t.Errorf("derivative of %s at %f: expected %f but got %f (from %s)",
+					exprStr, point, expected, actual, sample.Response)
+			}
+		}
+	}
+}
+
+func TestSubstituteNode(t *testing.T) {
+	x := mathexpr.RawNode("x")
+	outer := &mathexpr.BinaryOp{Op: mathexpr.MultiplyOp, Left: x, Right: x}
+	inner := &mathexpr.BinaryOp{Op: mathexpr.AddOp, Left: mathexpr.RawNode("1"),
+		Right: mathexpr.RawNode("2")}
+	res := substituteNode(outer, "x", inner).(*mathexpr.BinaryOp)
+	if res.Left == res.Right || res.Left == mathexpr.Node(inner) {
+		t.Fatal("substituted sites should not share nodes")
+	}
+	res.Left = mathexpr.ConstantFold(res.Left)
+	if actual := res.String(); actual != "3*(1+2)" {
+		t.Errorf("expected 3*(1+2) but got %s", actual)
+	}
+}
@@ -0,0 +1,61 @@
+package algebrain
+
+import (
+	"strings"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// SampleComplexity estimates the difficulty of a sample as
+// the number of nodes in the expression of its query plus
+// the number of nodes in its response.
+//
+// The query's expression is taken to be the longest
+// suffix of the query that parses as an expression, e.g.
+// "x^2+y" in "shift x by 2 in x^2+y".
+// Responses with a "Result: " prefix are parsed without
+// the prefix.
+// Text which does not parse counts as a single node.
+func SampleComplexity(s *Sample) int {
+	queryComplexity := 1
+	words := strings.Split(s.Query, " ")
+	for i := range words {
+		suffix := strings.Join(words[i:], " ")
+		if n, err := mathexpr.ParseString(suffix); err == nil {
+			queryComplexity = mathexpr.Complexity(n)
+			break
+		}
+	}
+	respComplexity := 1
+	response := strings.TrimPrefix(s.Response, "Result: ")
+	if n, err := mathexpr.ParseString(response); err == nil {
+		respComplexity = mathexpr.Complexity(n)
+	}
+	return queryComplexity + respComplexity
+}
+
+// WeightByComplexity sets the Weight of each sample based
+// on its SampleComplexity, so that the simplest samples
+// get a weight of 1 and the most complex get maxWeight.
+// Weights in between are interpolated linearly.
+func WeightByComplexity(samples []*Sample, maxWeight float64) {
+	complexities := make([]int, len(samples))
+	var minComplexity, maxComplexity int
+	for i, s := range samples {
+		complexities[i] = SampleComplexity(s)
+		if i == 0 || complexities[i] < minComplexity {
+			minComplexity = complexities[i]
+		}
+		if i == 0 || complexities[i] > maxComplexity {
+			maxComplexity = complexities[i]
+		}
+	}
+	for i, s := range samples {
+		s.Weight = 1
+		if maxComplexity > minComplexity {
+			frac := float64(complexities[i]-minComplexity) /
+				float64(maxComplexity-minComplexity)
+			s.Weight += frac * (maxWeight - 1)
+		}
+	}
+}
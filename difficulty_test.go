@@ -0,0 +1,36 @@
+package algebrain
+
+import "testing"
+
+func TestSampleComplexity(t *testing.T) {
+	cases := []struct {
+		sample   *Sample
+		expected int
+	}{
+		{&Sample{Query: "shift x by 2 in x^2+y", Response: "(x-2)^2+y"}, 5 + 7},
+		{&Sample{Query: "evaluate 1+1", Response: "Result: 2"}, 3 + 1},
+		{&Sample{Query: "copy abc", Response: "abc"}, 1 + 1},
+		{&Sample{Query: "partial derivative of sin(x, y) with respect to y",
+			Response: "cos(x)"}, 1 + 2},
+	}
+	for i, c := range cases {
+		if actual := SampleComplexity(c.sample); actual != c.expected {
+			t.Errorf("case %d: expected %d but got %d", i, c.expected, actual)
+		}
+	}
+}
+
+func TestWeightByComplexity(t *testing.T) {
+	samples := []*Sample{
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "shift x by 2 in x^2+y", Response: "(x-2)^2+y"},
+		{Query: "copy abc", Response: "abc"},
+	}
+	WeightByComplexity(samples, 3)
+	expected := []float64{1.4, 3, 1}
+	for i, x := range expected {
+		if diff := samples[i].Weight - x; diff > 1e-8 || diff < -1e-8 {
+			t.Errorf("sample %d: expected weight %f but got %f", i, x, samples[i].Weight)
+		}
+	}
+}
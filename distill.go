@@ -0,0 +1,187 @@
+package algebrain
+
+import (
+	"errors"
+	"math"
+	"sync"
+
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+)
+
+// A Distillation trains a Trainer's network (the student)
+// to match the output distributions of a teacher network.
+//
+// The student's targets at each step are a mixture of the
+// teacher's distribution, computed with teacher forcing on
+// the expected response, and the one-hot expected token.
+// With the default cross-entropy cost, matching the
+// teacher's distribution is equivalent to minimizing the
+// KL divergence from the teacher to the student.
+//
+// The teacher's distributions are cached by sample, so
+// repeated epochs over the same SampleList only run the
+// teacher once per sample.
+// The cache grows with every new sample, so it is not used
+// when the Trainer has a Stream, whose samples are never
+// repeated.
+type Distillation struct {
+	// Teacher is the network to distill.
+	// It must have the same vocabulary as the student.
+	// It is never modified.
+	Teacher *Network
+
+	// HardWeight is the weight of the expected tokens in
+	// the targets, between 0 and 1.
+	// The teacher's distributions have weight
+	// 1-HardWeight.
+	HardWeight float64
+
+	// Temperature softens the teacher's distributions by
+	// dividing its log probabilities before normalizing.
+	// If this is 0, a temperature of 1 is used.
+	Temperature float64
+
+	lock  sync.Mutex
+	cache map[*Sample][][]float64
+}
+
+// ClearCache removes the cached teacher distributions,
+// e.g. to free memory between epochs over different
+// samples.
+func (d *Distillation) ClearCache() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.cache = nil
+}
+
+// distillBatch replaces the DecOut of a student's batch
+// with the distillation targets.
+//
+// If cache is false, the teacher is run on every sample,
+// and its distributions are not cached.
+func (d *Distillation) distillBatch(student *Network, samples []*Sample, b *Batch,
+	cache bool) error {
+	if d.Teacher.Tokenizer.VocabSize() != student.Tokenizer.VocabSize() {
+		return errors.New("distill batch: teacher vocabulary does not match student")
+	}
+	var soft [][][]float64
+	var err error
+	if cache {
+		soft, err = d.teacherDistributions(samples)
+	} else {
+		soft, err = d.runTeacher(samples)
+	}
+	if err != nil {
+		return essentials.AddCtx("distill batch", err)
+	}
+	c := student.creator()
+	targets := make([][]anyvec.Vector, len(samples))
+	for i, sample := range samples {
+		hard, err := sample.DecoderOutVectors(c, student.Tokenizer)
+		if err != nil {
+			return essentials.AddCtx("distill batch", err)
+		} else if len(hard) != len(soft[i]) {
+			return errors.New("distill batch: teacher tokenization does not match student")
+		}
+		weight := sample.Weight
+		if weight == 0 {
+			weight = 1
+		}
+		for t, hardVec := range hard {
+			target := make([]float64, len(soft[i][t]))
+			for j, p := range soft[i][t] {
+				target[j] = weight * (1 - d.HardWeight) * p
+			}
+			target[argMax(hardVec)] += weight * d.HardWeight
+			targets[i] = append(targets[i], c.MakeVectorData(c.MakeNumericList(target)))
+		}
+	}
+	b.DecOut = anyseq.ConstSeqList(c, targets)
+	return nil
+}
+
+// teacherDistributions gets the teacher's per-step output
+// distributions for each sample, running the teacher in
+// one batch on the samples which are not cached.
+func (d *Distillation) teacherDistributions(samples []*Sample) ([][][]float64, error) {
+	d.lock.Lock()
+	if d.cache == nil {
+		d.cache = map[*Sample][][]float64{}
+	}
+	var missing []*Sample
+	for _, s := range samples {
+		if _, ok := d.cache[s]; !ok {
+			missing = append(missing, s)
+		}
+	}
+	d.lock.Unlock()
+
+	if len(missing) > 0 {
+		dists, err := d.runTeacher(missing)
+		if err != nil {
+			return nil, err
+		}
+		d.lock.Lock()
+		for i, s := range missing {
+			d.cache[s] = dists[i]
+		}
+		d.lock.Unlock()
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	res := make([][][]float64, len(samples))
+	for i, s := range samples {
+		res[i] = d.cache[s]
+	}
+	return res, nil
+}
+
+func (d *Distillation) runTeacher(samples []*Sample) ([][][]float64, error) {
+	batch, err := d.Teacher.makeBatch(samples, false)
+	if err != nil {
+		return nil, err
+	}
+	temperature := d.Temperature
+	if temperature == 0 {
+		temperature = 1
+	}
+	vocabSize := d.Teacher.Tokenizer.VocabSize()
+	res := make([][][]float64, len(samples))
+	out := d.Teacher.applyTeacherForced(batch.EncIn, batch.DecIn)
+	for _, step := range out.Output() {
+		logProbs := vectorFloats(step.Packed)
+		var packedIdx int
+		for i, present := range step.Present {
+			if !present {
+				continue
+			}
+			dist := softmaxTemperature(logProbs[packedIdx*vocabSize:(packedIdx+1)*vocabSize],
+				temperature)
+			res[i] = append(res[i], dist)
+			packedIdx++
+		}
+	}
+	return res, nil
+}
+
+// softmaxTemperature computes the distribution given by
+// log probabilities divided by a temperature.
+func softmaxTemperature(logProbs []float64, temperature float64) []float64 {
+	max := math.Inf(-1)
+	for _, x := range logProbs {
+		max = math.Max(max, x)
+	}
+	res := make([]float64, len(logProbs))
+	var total float64
+	for i, x := range logProbs {
+		res[i] = math.Exp((x - max) / temperature)
+		total += res[i]
+	}
+	for i := range res {
+		res[i] /= total
+	}
+	return res
+}
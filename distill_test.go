@@ -0,0 +1,114 @@
+package algebrain
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestDistillation(t *testing.T) {
+	teacher := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	student := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	teacherParams := make([][]float64, len(teacher.Parameters()))
+	for i, p := range teacher.Parameters() {
+		teacherParams[i] = vectorFloats(p.Vector)
+	}
+
+	distill := &Distillation{Teacher: teacher, HardWeight: 0.25, Temperature: 2}
+	trainer := &Trainer{Network: student, Distillation: distill}
+	samples := SampleList{
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "scale x by 2 in x", Response: "x*2", Weight: 2},
+	}
+	batch, err := trainer.Fetch(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each target is a distribution scaled by the weight of
+	// its sample.
+	for i, step := range batch.(*Batch).DecOut.Output() {
+		var expected float64
+		if step.Present[0] {
+			expected++
+		}
+		if step.Present[1] {
+			expected += 2
+		}
+		if total := anyvec.Sum(step.Packed).(float64); math.Abs(total-expected) > 1e-8 {
+			t.Errorf("step %d: expected target mass %f but got %f", i, expected, total)
+		}
+	}
+	if len(distill.cache) != len(samples) {
+		t.Errorf("expected %d cached samples but got %d", len(samples), len(distill.cache))
+	}
+
+	cost := func() float64 {
+		return anyvec.Sum(trainer.TotalCost(batch).Output()).(float64)
+	}
+	initialCost := cost()
+	for i := 0; i < 5; i++ {
+		grad := trainer.Gradient(batch)
+		for _, p := range teacher.Parameters() {
+			if _, ok := grad[p]; ok {
+				t.Fatal("gradient includes a teacher parameter")
+			}
+		}
+		grad.Scale(anyvec64.CurrentCreator().MakeNumeric(-0.01))
+		grad.AddToVars()
+	}
+	if finalCost := cost(); !(finalCost < initialCost) {
+		t.Errorf("cost went from %f to %f", initialCost, finalCost)
+	}
+	for i, p := range teacher.Parameters() {
+		for j, x := range vectorFloats(p.Vector) {
+			if x != teacherParams[i][j] {
+				t.Fatal("teacher parameters were modified")
+			}
+		}
+	}
+}
+
+func TestDistillationStream(t *testing.T) {
+	teacher := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	student := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	distill := &Distillation{Teacher: teacher}
+	trainer := &Trainer{Network: student, Distillation: distill}
+	stream := &SampleStream{
+		Generator: &constGenerator{Sample: Sample{Query: "evaluate 2+2", Response: "Result: 4"}},
+		BatchSize: 2,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	trainer.StartStream(ctx, stream)
+	for i := 0; i < 3; i++ {
+		if _, err := trainer.Fetch(StreamSampleList(2)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cancel()
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	distill.lock.Lock()
+	defer distill.lock.Unlock()
+	if len(distill.cache) != 0 {
+		t.Errorf("expected no cached samples but got %d", len(distill.cache))
+	}
+}
+
+func TestSoftmaxTemperature(t *testing.T) {
+	logProbs := []float64{math.Log(0.5), math.Log(0.25), math.Log(0.25)}
+	if res := softmaxTemperature(logProbs, 1); math.Abs(res[0]-0.5) > 1e-8 ||
+		math.Abs(res[1]-0.25) > 1e-8 {
+		t.Errorf("unexpected distribution: %v", res)
+	}
+	res := softmaxTemperature(logProbs, 2)
+	expected := math.Sqrt(0.5) / (math.Sqrt(0.5) + 2*math.Sqrt(0.25))
+	if math.Abs(res[0]-expected) > 1e-8 {
+		t.Errorf("expected %f but got %f", expected, res[0])
+	}
+}
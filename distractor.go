@@ -0,0 +1,93 @@
+package algebrain
+
+import (
+	"math/rand"
+	"strings"
+)
+
+// DefaultDistractorAmount is the default maximum number of
+// phrases added by a DistractorGenerator.
+const DefaultDistractorAmount = 2
+
+// DefaultDistractorPrefixes and DefaultDistractorSuffixes
+// are the default phrases used by a DistractorGenerator.
+var (
+	DefaultDistractorPrefixes = []string{
+		"please",
+		"hey,",
+		"quick question:",
+		"can you",
+		"I need to",
+		"for homework,",
+		"my teacher wants me to",
+		"help me",
+	}
+	DefaultDistractorSuffixes = []string{
+		"please",
+		"thanks",
+		"thank you!",
+		"for my homework",
+		"as fast as you can",
+		"if you can",
+		"I'm stuck",
+		"asap",
+	}
+)
+
+// A DistractorGenerator wraps a Generator and surrounds
+// each query with irrelevant phrases, e.g. turning
+// "evaluate 2+2" into "please evaluate 2+2 for my
+// homework".
+// Responses are unchanged.
+//
+// The original query always appears intact, separated
+// from the phrases by spaces.
+type DistractorGenerator struct {
+	Generator Generator
+
+	// Prefixes and Suffixes are the phrases which may be
+	// added before and after the query.
+	// If these are nil, DefaultDistractorPrefixes and
+	// DefaultDistractorSuffixes are used.
+	//
+	// Phrases should only contain characters below
+	// CharCount.
+	Prefixes []string
+	Suffixes []string
+
+	// Amount is the maximum number of phrases added to a
+	// query.
+	// Every query gets at least one phrase.
+	// If this is 0, DefaultDistractorAmount is used.
+	Amount int
+}
+
+// Generate generates a sample with distractors.
+func (d *DistractorGenerator) Generate() *Sample {
+	prefixes := d.Prefixes
+	if prefixes == nil {
+		prefixes = DefaultDistractorPrefixes
+	}
+	suffixes := d.Suffixes
+	if suffixes == nil {
+		suffixes = DefaultDistractorSuffixes
+	}
+	amount := d.Amount
+	if amount == 0 {
+		amount = DefaultDistractorAmount
+	}
+
+	sample := *d.Generator.Generate()
+	var before, after []string
+	numPhrases := rand.Intn(amount) + 1
+	for i := 0; i < numPhrases; i++ {
+		if len(suffixes) == 0 || (len(prefixes) > 0 && rand.Intn(2) == 0) {
+			before = append(before, prefixes[rand.Intn(len(prefixes))])
+		} else {
+			after = append(after, suffixes[rand.Intn(len(suffixes))])
+		}
+	}
+	parts := append(append(before, sample.Query), after...)
+	sample.Query = strings.Join(parts, " ")
+	return &sample
+}
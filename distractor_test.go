@@ -0,0 +1,45 @@
+package algebrain
+
+import (
+	"strings"
+	"testing"
+)
+
+type constGenerator struct {
+	Sample Sample
+}
+
+func (c *constGenerator) Generate() *Sample {
+	res := c.Sample
+	return &res
+}
+
+func TestDistractorGenerator(t *testing.T) {
+	inner := &constGenerator{Sample: Sample{Query: "evaluate 2+2", Response: "Result: 4"}}
+	gen := &DistractorGenerator{Generator: inner, Amount: 3}
+	phrases := append(append([]string{}, DefaultDistractorPrefixes...),
+		DefaultDistractorSuffixes...)
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		if sample.Response != "Result: 4" {
+			t.Fatalf("response changed to %q", sample.Response)
+		}
+		idx := strings.Index(sample.Query, "evaluate 2+2")
+		if idx < 0 {
+			t.Fatalf("core query missing from %q", sample.Query)
+		}
+		noise := sample.Query[:idx] + sample.Query[idx+len("evaluate 2+2"):]
+		if strings.TrimSpace(noise) == "" {
+			t.Fatalf("no distractors in %q", sample.Query)
+		}
+		for _, phrase := range phrases {
+			noise = strings.Replace(noise, phrase, "", -1)
+		}
+		if strings.TrimSpace(noise) != "" {
+			t.Fatalf("unexpected text in %q: %q", sample.Query, noise)
+		}
+		if inner.Sample.Query != "evaluate 2+2" {
+			t.Fatal("inner sample was modified")
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package algebrain
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// diversitySubsetSize is the number of samples used to
+// estimate the mean pairwise edit distance of a corpus.
+const diversitySubsetSize = 100
+
+// A CorpusDiversityReport summarizes how varied a set of
+// training samples is.
+type CorpusDiversityReport struct {
+	Samples int
+
+	// QueryUniqueness and ResponseUniqueness are the
+	// numbers of unique queries and responses divided by
+	// the number of samples.
+	QueryUniqueness    float64
+	ResponseUniqueness float64
+
+	// CharFrequencies maps each character in the queries
+	// to the fraction of query characters which it makes
+	// up.
+	CharFrequencies map[rune]float64
+
+	// CharEntropy is the entropy (in bits) of
+	// CharFrequencies.
+	CharEntropy float64
+
+	// MeanEditDistance is the mean edit distance between
+	// the queries of pairs of samples, estimated from a
+	// random subset of at most 100 samples.
+	MeanEditDistance float64
+}
+
+// CorpusDiversity computes diversity statistics for a set
+// of samples.
+func CorpusDiversity(samples []*Sample) *CorpusDiversityReport {
+	res := &CorpusDiversityReport{
+		Samples:         len(samples),
+		CharFrequencies: map[rune]float64{},
+	}
+	if len(samples) == 0 {
+		return res
+	}
+
+	queries := map[string]bool{}
+	responses := map[string]bool{}
+	var numChars int
+	for _, s := range samples {
+		queries[s.Query] = true
+		responses[s.Response] = true
+		for _, r := range s.Query {
+			res.CharFrequencies[r]++
+			numChars++
+		}
+	}
+	res.QueryUniqueness = float64(len(queries)) / float64(len(samples))
+	res.ResponseUniqueness = float64(len(responses)) / float64(len(samples))
+	for r, count := range res.CharFrequencies {
+		freq := count / float64(numChars)
+		res.CharFrequencies[r] = freq
+		res.CharEntropy -= freq * math.Log2(freq)
+	}
+
+	subset := samples
+	if len(subset) > diversitySubsetSize {
+		subset = make([]*Sample, diversitySubsetSize)
+		for i, j := range rand.Perm(len(samples))[:diversitySubsetSize] {
+			subset[i] = samples[j]
+		}
+	}
+	var totalDist, numPairs int
+	for i, s1 := range subset {
+		for _, s2 := range subset[i+1:] {
+			totalDist += editDistance(s1.Query, s2.Query)
+			numPairs++
+		}
+	}
+	if numPairs > 0 {
+		res.MeanEditDistance = float64(totalDist) / float64(numPairs)
+	}
+	return res
+}
+
+// String formats the report, listing the most common
+// query characters.
+func (c *CorpusDiversityReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "samples:             %d\n", c.Samples)
+	fmt.Fprintf(&buf, "query uniqueness:    %.4f\n", c.QueryUniqueness)
+	fmt.Fprintf(&buf, "response uniqueness: %.4f\n", c.ResponseUniqueness)
+	fmt.Fprintf(&buf, "char entropy:        %.4f bits (%d chars)\n", c.CharEntropy,
+		len(c.CharFrequencies))
+	fmt.Fprintf(&buf, "mean edit distance:  %.4f\n", c.MeanEditDistance)
+
+	chars := make([]rune, 0, len(c.CharFrequencies))
+	for r := range c.CharFrequencies {
+		chars = append(chars, r)
+	}
+	sort.Slice(chars, func(i, j int) bool {
+		fi, fj := c.CharFrequencies[chars[i]], c.CharFrequencies[chars[j]]
+		return fi > fj || (fi == fj && chars[i] < chars[j])
+	})
+	if len(chars) > 10 {
+		chars = chars[:10]
+	}
+	for _, r := range chars {
+		fmt.Fprintf(&buf, "  %q: %.4f\n", r, c.CharFrequencies[r])
+	}
+	return buf.String()
+}
@@ -0,0 +1,52 @@
+package algebrain
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestCorpusDiversityIdentical(t *testing.T) {
+	samples := make([]*Sample, 100)
+	for i := range samples {
+		samples[i] = &Sample{Query: "evaluate 1+1", Response: "Result: 2"}
+	}
+	report := CorpusDiversity(samples)
+	if report.QueryUniqueness != 0.01 || report.ResponseUniqueness != 0.01 {
+		t.Errorf("unexpected uniqueness: %f, %f", report.QueryUniqueness,
+			report.ResponseUniqueness)
+	}
+	if report.MeanEditDistance != 0 {
+		t.Errorf("expected edit distance 0 but got %f", report.MeanEditDistance)
+	}
+	if math.Abs(report.CharFrequencies['1']-2.0/12) > 1e-8 {
+		t.Errorf("unexpected frequency for '1': %f", report.CharFrequencies['1'])
+	}
+	if !strings.Contains(report.String(), "query uniqueness:    0.0100") {
+		t.Errorf("unexpected report:\n%s", report)
+	}
+}
+
+func TestCorpusDiversityRandom(t *testing.T) {
+	alphabet := []rune("abcdefgh")
+	samples := make([]*Sample, 1000)
+	for i := range samples {
+		query := make([]rune, 20)
+		for j := range query {
+			query[j] = alphabet[rand.Intn(len(alphabet))]
+		}
+		samples[i] = &Sample{Query: string(query), Response: string(query[:3])}
+	}
+	report := CorpusDiversity(samples)
+	expected := math.Log2(float64(len(alphabet)))
+	if math.Abs(report.CharEntropy-expected) > 0.01 {
+		t.Errorf("expected entropy near %f but got %f", expected, report.CharEntropy)
+	}
+	if report.QueryUniqueness != 1 {
+		t.Errorf("expected unique queries but got %f", report.QueryUniqueness)
+	}
+	if report.MeanEditDistance < 10 || report.MeanEditDistance > 20 {
+		t.Errorf("unexpected mean edit distance: %f", report.MeanEditDistance)
+	}
+}
@@ -0,0 +1,170 @@
+package algebrain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+// A URLLoader downloads serialized networks and caches
+// them on disk.
+type URLLoader struct {
+	// CacheDir is the directory for downloaded networks.
+	// If this is empty, the "algebrain" directory inside
+	// os.UserCacheDir() is used, which respects
+	// $XDG_CACHE_HOME on Linux.
+	CacheDir string
+
+	// Client is used for downloads.
+	// If this is nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// LoadNetworkURL downloads a network with a URLLoader that
+// uses the default cache directory.
+func LoadNetworkURL(ctx context.Context, url, checksum string) (*Network, error) {
+	return (&URLLoader{}).Load(ctx, url, checksum)
+}
+
+// Load loads a network saved with serializer.SaveAny from
+// a URL, given the hex-encoded SHA-256 of the file.
+//
+// Files are cached by checksum, so later calls with the
+// same checksum do not use the network.
+// Interrupted downloads are resumed with a range request
+// if the server supports it, and restarted otherwise.
+func (u *URLLoader) Load(ctx context.Context, url, checksum string) (*Network, error) {
+	checksum = strings.ToLower(checksum)
+	if decoded, err := hex.DecodeString(checksum); err != nil ||
+		len(decoded) != sha256.Size {
+		return nil, errors.New("load network URL: invalid checksum")
+	}
+	path, err := u.cachePath(checksum)
+	if err != nil {
+		return nil, essentials.AddCtx("load network URL", err)
+	}
+	if sum, err := fileChecksum(path); err != nil || sum != checksum {
+		if err := u.download(ctx, url, path, checksum); err != nil {
+			return nil, essentials.AddCtx("load network URL", err)
+		}
+	}
+	var net *Network
+	if err := serializer.LoadAny(path, &net); err != nil {
+		return nil, essentials.AddCtx("load network URL", err)
+	}
+	return net, nil
+}
+
+func (u *URLLoader) cachePath(checksum string) (string, error) {
+	dir := u.CacheDir
+	if dir == "" {
+		cacheDir, err := os.UserCacheDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(cacheDir, "algebrain")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, checksum+".model"), nil
+}
+
+// download fetches the file into path+".partial" and
+// moves it to path once its checksum has been verified.
+//
+// If an existing partial file fails verification, the
+// download is restarted from scratch once.
+func (u *URLLoader) download(ctx context.Context, url, path, checksum string) error {
+	partialPath := path + ".partial"
+	for {
+		resumed, err := u.fetch(ctx, url, partialPath)
+		if err != nil {
+			return err
+		}
+		sum, err := fileChecksum(partialPath)
+		if err != nil {
+			return err
+		}
+		if sum == checksum {
+			return os.Rename(partialPath, path)
+		}
+		os.Remove(partialPath)
+		if !resumed {
+			return fmt.Errorf("checksum mismatch: expected %s but got %s", checksum, sum)
+		}
+	}
+}
+
+// fetch downloads a URL to a file, resuming from the end
+// of the file if it already exists.
+// It reports whether the download was resumed.
+func (u *URLLoader) fetch(ctx context.Context, url, path string) (resumed bool, err error) {
+	client := u.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	var offset int64
+	if info, err := os.Stat(path); err == nil {
+		offset = info.Size()
+	}
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return false, err
+	}
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_WRONLY | os.O_CREATE
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		flags |= os.O_APPEND
+		resumed = true
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is at least as long as the full
+		// file, so it can only be verified.
+		return true, nil
+	case http.StatusOK:
+		flags |= os.O_TRUNC
+	default:
+		return false, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return false, err
+	}
+	_, err = io.Copy(f, resp.Body)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	return resumed, err
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,109 @@
+package algebrain
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/serializer"
+)
+
+func TestURLLoader(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	data, err := serializer.SerializeAny(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hash := sha256.Sum256(data)
+	checksum := hex.EncodeToString(hash[:])
+
+	var requests int64
+	payload := data
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt64(&requests, 1)
+		http.ServeContent(w, r, "net", time.Time{}, bytes.NewReader(payload))
+	}))
+	defer server.Close()
+
+	loader := &URLLoader{CacheDir: t.TempDir()}
+	const query = "evaluate 1+2"
+	expected := net.Query(query)
+	for i := 0; i < 2; i++ {
+		loaded, err := loader.Load(context.Background(), server.URL, checksum)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := loaded.Query(query); actual != expected {
+			t.Errorf("expected %q but got %q", expected, actual)
+		}
+	}
+	if requests != 1 {
+		t.Errorf("expected 1 request but got %d", requests)
+	}
+
+	t.Run("Resume", func(t *testing.T) {
+		loader := &URLLoader{CacheDir: t.TempDir()}
+		partial := filepath.Join(loader.CacheDir, checksum+".model.partial")
+		if err := os.WriteFile(partial, data[:len(data)/2], 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loader.Load(context.Background(), server.URL, checksum); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("CorruptPartial", func(t *testing.T) {
+		loader := &URLLoader{CacheDir: t.TempDir()}
+		partial := filepath.Join(loader.CacheDir, checksum+".model.partial")
+		if err := os.WriteFile(partial, []byte("garbage"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := loader.Load(context.Background(), server.URL, checksum); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	t.Run("CorruptPayload", func(t *testing.T) {
+		payload = append([]byte{}, data...)
+		payload[len(payload)/2] ^= 1
+		defer func() {
+			payload = data
+		}()
+		loader := &URLLoader{CacheDir: t.TempDir()}
+		if _, err := loader.Load(context.Background(), server.URL, checksum); err == nil {
+			t.Fatal("expected checksum error")
+		}
+		entries, err := os.ReadDir(loader.CacheDir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(entries) != 0 {
+			t.Errorf("expected empty cache but got %d entries", len(entries))
+		}
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		loader := &URLLoader{CacheDir: t.TempDir()}
+		if _, err := loader.Load(ctx, server.URL, checksum); err == nil {
+			t.Fatal("expected cancellation error")
+		}
+	})
+
+	t.Run("InvalidChecksum", func(t *testing.T) {
+		loader := &URLLoader{CacheDir: t.TempDir()}
+		if _, err := loader.Load(context.Background(), server.URL, "abc"); err == nil {
+			t.Fatal("expected checksum error")
+		}
+	})
+}
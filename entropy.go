@@ -0,0 +1,62 @@
+package algebrain
+
+import (
+	"context"
+	"math"
+)
+
+// QueryEntropy is like Query, but it also returns the
+// Shannon entropy (in nats) of the output distribution at
+// each decoder step, including the step which produced
+// the terminator.
+// High-entropy steps indicate where the network was unsure
+// of its output.
+//
+// The entropy only covers the token outputs, i.e. the
+// first Tokenizer.VocabSize() entries of the distribution,
+// which are renormalized if the output layer has extra
+// entries.
+//
+// It panics if the query cannot be tokenized.
+func (n *Network) QueryEntropy(q string) (string, []float64) {
+	vocabSize := n.Tokenizer.VocabSize()
+	var entropies []float64
+	res, err := n.decode(context.Background(), q, func(logProbs []float64) int {
+		tokenLogProbs := logProbs
+		if len(tokenLogProbs) > vocabSize {
+			tokenLogProbs = tokenLogProbs[:vocabSize]
+		}
+		entropies = append(entropies, logProbEntropy(tokenLogProbs))
+		return argMaxFloats(logProbs)
+	})
+	if err != nil {
+		panic(err)
+	}
+	if res.Truncated {
+		// The last token was chosen but never emitted.
+		entropies = entropies[:len(entropies)-1]
+	}
+	return res.Response, entropies
+}
+
+// logProbEntropy computes the entropy of the distribution
+// given by (possibly unnormalized) log probabilities.
+func logProbEntropy(logProbs []float64) float64 {
+	maxLogProb := math.Inf(-1)
+	for _, x := range logProbs {
+		maxLogProb = math.Max(maxLogProb, x)
+	}
+	var total float64
+	for _, x := range logProbs {
+		total += math.Exp(x - maxLogProb)
+	}
+	logTotal := maxLogProb + math.Log(total)
+	var res float64
+	for _, x := range logProbs {
+		logProb := x - logTotal
+		if p := math.Exp(logProb); p > 0 {
+			res -= p * logProb
+		}
+	}
+	return res
+}
@@ -0,0 +1,48 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestQueryEntropy(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+
+	// Make the output distribution nearly uniform, with a
+	// slight preference for the terminator.
+	outLayer := net.Output[0].(*anynet.FC)
+	outLayer.Weights.Vector.Scale(0.0)
+	biases := make([]float64, CharCount)
+	biases[Terminator] = 1e-3
+	outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+
+	response, entropies := net.QueryEntropy("evaluate 1+2")
+	if response != "" {
+		t.Errorf("expected empty response but got %q", response)
+	}
+	if len(entropies) != 1 {
+		t.Fatalf("expected 1 entropy but got %d", len(entropies))
+	}
+	if expected := math.Log(CharCount); math.Abs(entropies[0]-expected) > 1e-4 {
+		t.Errorf("expected entropy %f but got %f", expected, entropies[0])
+	}
+}
+
+func TestLogProbEntropy(t *testing.T) {
+	for _, c := range []struct {
+		LogProbs []float64
+		Expected float64
+	}{
+		{[]float64{0, math.Inf(-1), math.Inf(-1)}, 0},
+		{[]float64{math.Log(0.5), math.Log(0.5)}, math.Log(2)},
+		{[]float64{3, 3, 3, 3}, math.Log(4)},
+		{[]float64{math.Log(0.25), math.Log(0.75)}, -0.25*math.Log(0.25) - 0.75*math.Log(0.75)},
+	} {
+		if actual := logProbEntropy(c.LogProbs); math.Abs(actual-c.Expected) > 1e-8 {
+			t.Errorf("%v: expected %f but got %f", c.LogProbs, c.Expected, actual)
+		}
+	}
+}
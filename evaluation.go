@@ -0,0 +1,390 @@
+package algebrain
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+)
+
+const (
+	evaluationBatchSize = 32
+
+	// reliabilityBuckets is the number of confidence
+	// buckets in an EvaluationReport.
+	reliabilityBuckets = 10
+)
+
+// A GeneratorReport summarizes the performance of a
+// Network on samples from one Generator.
+type GeneratorReport struct {
+	Name string
+
+	// ExactAccuracy is the fraction of responses which
+	// exactly matched the expected response or one of its
+	// alternatives.
+	ExactAccuracy float64
+
+	// MeanEditDistance is the average Levenshtein distance
+	// between the actual and closest expected responses.
+	MeanEditDistance float64
+
+	// Perplexity is the per-token perplexity of the
+	// expected responses.
+	Perplexity float64
+}
+
+// An EvaluationReport summarizes the performance of a
+// Network on a variety of tasks.
+type EvaluationReport struct {
+	Generators []*GeneratorReport
+
+	// Reliability compares the confidences from
+	// QueryScored to the observed accuracy, over the
+	// samples from every generator.
+	Reliability []*ReliabilityBucket
+}
+
+// A ReliabilityBucket summarizes the responses whose
+// confidence is in [MinConfidence, MaxConfidence).
+// The last bucket also includes a confidence of 1.
+//
+// For a well-calibrated network, MeanConfidence is close
+// to Accuracy.
+type ReliabilityBucket struct {
+	MinConfidence float64
+	MaxConfidence float64
+
+	Count          int
+	MeanConfidence float64
+	Accuracy       float64
+}
+
+// String formats the report as a table.
+func (e *EvaluationReport) String() string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%-16s %10s %10s %10s\n", "Generator", "Accuracy", "EditDist",
+		"Perplexity")
+	for _, g := range e.Generators {
+		fmt.Fprintf(&buf, "%-16s %10.4f %10.4f %10.4f\n", g.Name, g.ExactAccuracy,
+			g.MeanEditDistance, g.Perplexity)
+	}
+	if len(e.Reliability) > 0 {
+		fmt.Fprintf(&buf, "\n%-16s %10s %10s %10s\n", "Confidence", "Count", "Expected",
+			"Observed")
+		for _, b := range e.Reliability {
+			if b.Count == 0 {
+				continue
+			}
+			bounds := fmt.Sprintf("%.2f-%.2f", b.MinConfidence, b.MaxConfidence)
+			fmt.Fprintf(&buf, "%-16s %10d %10.4f %10.4f\n", bounds, b.Count,
+				b.MeanConfidence, b.Accuracy)
+		}
+	}
+	return buf.String()
+}
+
+// EvaluationGenerators returns the generators used by
+// RunEvaluationSuite, keyed by name.
+// The names are sorted in the order of the resulting
+// report.
+//
+// Every exported Generator in this package appears here,
+// except for MixtureGenerator, which only combines other
+// generators.
+func EvaluationGenerators() ([]string, map[string]Generator) {
+	names := []string{"Shift", "Scale", "MultiScale", "PartialDerivative", "Eval",
+		"BaseConversion", "PercentChange", "BinomialExpand", "ChainRule",
+		"AugmentedShift", "FunctionEval", "WordedEval", "Conditional", "Inequality",
+		"AbsValue", "ExponentRule", "ImplicitMult", "Matrix", "Pattern", "Combinatorics",
+		"UnitArithmetic", "Copy", "Reverse", "NoisyEval", "DistractorEval",
+		"VerifiedShift"}
+	return names, map[string]Generator{
+		"Shift": &ShiftGenerator{
+			Generator: &mathexpr.Generator{
+				NoReals:  true,
+				VarNames: []string{"x"},
+			},
+			MaxDepth: 3,
+		},
+		"Scale": &ScaleGenerator{
+			Generator: &mathexpr.Generator{
+				NoReals:  true,
+				VarNames: []string{"x"},
+			},
+			MaxDepth: 3,
+		},
+		"MultiScale": &MultiScaleGenerator{
+			Generator: &mathexpr.Generator{
+				NoReals:  true,
+				VarNames: []string{"x", "y"},
+			},
+			MaxDepth: 3,
+		},
+		"PartialDerivative": &PartialDerivativeGenerator{
+			Generator: &mathexpr.Generator{
+				NoReals:  true,
+				VarNames: []string{"x", "y"},
+			},
+			MaxDepth: 3,
+		},
+		"Eval": &EvalGenerator{
+			Generator: &mathexpr.Generator{
+				NoReals: true,
+			},
+			MaxDepth: 3,
+			AllInts:  true,
+		},
+		"BaseConversion": &BaseConversionGenerator{
+			FromBase: 10,
+			ToBase:   2,
+		},
+		"PercentChange": &PercentChangeGenerator{
+			MaxBase: 200,
+		},
+		"BinomialExpand": &BinomialExpandGenerator{
+			MaxExponent: 3,
+		},
+		"ChainRule": &ChainRuleGenerator{
+			MaxDegree: 2,
+		},
+		"AugmentedShift": &AugmentedShiftGenerator{
+			ShiftGenerator: ShiftGenerator{
+				Generator: &mathexpr.Generator{
+					NoReals:  true,
+					VarNames: []string{"x"},
+				},
+				MaxDepth: 3,
+			},
+		},
+		"FunctionEval":   &FunctionEvalGenerator{},
+		"WordedEval":     &WordedEvalGenerator{},
+		"Conditional":    &ConditionalGenerator{},
+		"Inequality":     &InequalityGenerator{AllInts: true},
+		"AbsValue":       &AbsValueSolveGenerator{},
+		"ExponentRule":   &ExponentRuleGenerator{},
+		"ImplicitMult":   &ImplicitMultGenerator{},
+		"Matrix":         &MatrixGenerator{},
+		"Pattern":        &PatternGenerator{},
+		"Combinatorics":  &CombinatoricsGenerator{},
+		"UnitArithmetic": &UnitArithmeticGenerator{},
+		"Copy":           &CopyGenerator{},
+		"Reverse":        &ReverseGenerator{},
+		"NoisyEval": &NoisyGenerator{
+			Generator: &EvalGenerator{
+				Generator: &mathexpr.Generator{
+					NoReals: true,
+				},
+				MaxDepth: 3,
+				AllInts:  true,
+			},
+			FlipProb: 0.05,
+		},
+		"DistractorEval": &DistractorGenerator{
+			Generator: &EvalGenerator{
+				Generator: &mathexpr.Generator{
+					NoReals: true,
+				},
+				MaxDepth: 3,
+				AllInts:  true,
+			},
+		},
+		"VerifiedShift": &SelfVerifyingGenerator{
+			Generator: &ShiftGenerator{
+				Generator: &mathexpr.Generator{
+					NoReals:  true,
+					VarNames: []string{"x"},
+				},
+				MaxDepth: 3,
+			},
+		},
+	}
+}
+
+// RunEvaluationSuite evaluates the network on samples from
+// each of the EvaluationGenerators.
+func RunEvaluationSuite(n *Network, samplesPerGenerator int) *EvaluationReport {
+	suite := &EvaluationSuite{SamplesPerGenerator: samplesPerGenerator}
+	return suite.Run(n)
+}
+
+// An EvaluationSuite configures an evaluation on samples
+// from each of the EvaluationGenerators.
+type EvaluationSuite struct {
+	SamplesPerGenerator int
+
+	// If Commutative is set, responses which are
+	// commutatively equal to an acceptable response are
+	// counted as correct.
+	Commutative bool
+}
+
+// Run evaluates the network.
+func (e *EvaluationSuite) Run(n *Network) *EvaluationReport {
+	names, gens := EvaluationGenerators()
+	sampleSets := make([][]*Sample, len(names))
+	for i, name := range names {
+		sampleSets[i] = make([]*Sample, e.SamplesPerGenerator)
+		for j := range sampleSets[i] {
+			sampleSets[i][j] = gens[name].Generate()
+		}
+	}
+	return e.RunSamples(n, names, sampleSets)
+}
+
+// RunSamples evaluates the network on fixed sets of
+// samples, such as those from ReadParallelText, instead
+// of generating them.
+// Each set is reported under the corresponding name.
+//
+// SamplesPerGenerator is ignored.
+func (e *EvaluationSuite) RunSamples(n *Network, names []string,
+	sampleSets [][]*Sample) *EvaluationReport {
+	res := &EvaluationReport{}
+	var allConfidences []float64
+	var allCorrect []bool
+	for i, name := range names {
+		samples := sampleSets[i]
+		report := &GeneratorReport{Name: name, Perplexity: Perplexity(n, samples)}
+		for _, sample := range samples {
+			actual, confidence := n.QueryScored(sample.Query)
+			correct := sample.Accepts(actual, e.Commutative)
+			if correct {
+				report.ExactAccuracy++
+			}
+			allConfidences = append(allConfidences, confidence)
+			allCorrect = append(allCorrect, correct)
+			dist := editDistance(actual, sample.Response)
+			for _, alt := range sample.AltResponses {
+				dist = essentials.MinInt(dist, editDistance(actual, alt))
+			}
+			report.MeanEditDistance += float64(dist)
+		}
+		report.ExactAccuracy /= float64(len(samples))
+		report.MeanEditDistance /= float64(len(samples))
+		res.Generators = append(res.Generators, report)
+	}
+	res.Reliability = reliabilityDiagram(allConfidences, allCorrect, reliabilityBuckets)
+	return res
+}
+
+// reliabilityDiagram groups responses into evenly spaced
+// confidence buckets.
+func reliabilityDiagram(confidences []float64, correct []bool,
+	numBuckets int) []*ReliabilityBucket {
+	res := make([]*ReliabilityBucket, numBuckets)
+	for i := range res {
+		res[i] = &ReliabilityBucket{
+			MinConfidence: float64(i) / float64(numBuckets),
+			MaxConfidence: float64(i+1) / float64(numBuckets),
+		}
+	}
+	for i, confidence := range confidences {
+		idx := essentials.MinInt(int(confidence*float64(numBuckets)), numBuckets-1)
+		bucket := res[idx]
+		bucket.Count++
+		bucket.MeanConfidence += confidence
+		if correct[i] {
+			bucket.Accuracy++
+		}
+	}
+	for _, bucket := range res {
+		if bucket.Count > 0 {
+			bucket.MeanConfidence /= float64(bucket.Count)
+			bucket.Accuracy /= float64(bucket.Count)
+		}
+	}
+	return res
+}
+
+// TopKAccuracy computes the fraction of samples for which
+// one of the top k responses from QueryNBest is
+// acceptable.
+// Responses which are commutatively equal to an acceptable
+// response are counted as correct.
+//
+// It panics if a sample cannot be tokenized.
+func TopKAccuracy(n *Network, samples []*Sample, k int) float64 {
+	var correct int
+	for _, sample := range samples {
+		for _, response := range n.QueryNBest(sample.Query, k) {
+			if sample.Accepts(response, true) {
+				correct++
+				break
+			}
+		}
+	}
+	return float64(correct) / float64(len(samples))
+}
+
+// evaluateSamples queries the network on each sample and
+// checks which responses are acceptable.
+func evaluateSamples(n Querier, samples []*Sample, commutative bool) (responses []string,
+	correct []bool) {
+	responses = make([]string, len(samples))
+	correct = make([]bool, len(samples))
+	for i, sample := range samples {
+		responses[i] = n.Query(sample.Query)
+		correct[i] = sample.Accepts(responses[i], commutative)
+	}
+	return
+}
+
+// Perplexity computes the per-token perplexity of the
+// expected responses (including terminators) under the
+// network, using teacher forcing.
+//
+// Sample weights are ignored.
+//
+// It panics if a sample cannot be tokenized.
+func Perplexity(n *Network, samples []*Sample) float64 {
+	trainer := &Trainer{Network: n}
+	var totalCost float64
+	var totalTokens int
+	for i := 0; i < len(samples); i += evaluationBatchSize {
+		bs := evaluationBatchSize
+		if i+bs > len(samples) {
+			bs = len(samples) - i
+		}
+		batch, err := n.makeBatch(samples[i:i+bs], false)
+		if err != nil {
+			panic(err)
+		}
+		var numTokens int
+		for _, x := range batch.DecOut.Output() {
+			numTokens += x.NumPresent()
+		}
+		// TotalCost is averaged over all of the tokens.
+		meanCost := n.creator().Float64(anyvec.Sum(trainer.TotalCost(batch).Output()))
+		totalCost += meanCost * float64(numTokens)
+		totalTokens += numTokens
+	}
+	return math.Exp(totalCost / float64(totalTokens))
+}
+
+// editDistance computes the Levenshtein distance between
+// two strings.
+func editDistance(s1, s2 string) int {
+	r1, r2 := []rune(s1), []rune(s2)
+	row := make([]int, len(r2)+1)
+	for j := range row {
+		row[j] = j
+	}
+	for i := 1; i <= len(r1); i++ {
+		prevDiag := row[0]
+		row[0] = i
+		for j := 1; j <= len(r2); j++ {
+			diag := prevDiag
+			prevDiag = row[j]
+			if r1[i-1] == r2[j-1] {
+				row[j] = diag
+			} else {
+				row[j] = 1 + essentials.MinInt(diag, row[j], row[j-1])
+			}
+		}
+	}
+	return row[len(r2)]
+}
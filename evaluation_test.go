@@ -0,0 +1,158 @@
+package algebrain
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		s1, s2   string
+		expected int
+	}{
+		{"", "", 0},
+		{"abc", "", 3},
+		{"", "ab", 2},
+		{"kitten", "sitting", 3},
+		{"x*2", "x*2", 0},
+		{"(x-3)^2", "x-3^2", 2},
+	}
+	for _, c := range cases {
+		if actual := editDistance(c.s1, c.s2); actual != c.expected {
+			t.Errorf("%q, %q: expected %d but got %d", c.s1, c.s2, c.expected, actual)
+		}
+	}
+}
+
+func TestRunEvaluationSuite(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	report := RunEvaluationSuite(net, 2)
+	names, _ := EvaluationGenerators()
+	if len(report.Generators) != len(names) {
+		t.Fatalf("expected %d generators but got %d", len(names), len(report.Generators))
+	}
+	for _, g := range report.Generators {
+		if g.ExactAccuracy < 0 || g.ExactAccuracy > 1 {
+			t.Errorf("%s: invalid accuracy %f", g.Name, g.ExactAccuracy)
+		}
+		if math.IsNaN(g.Perplexity) || g.Perplexity < 1 {
+			t.Errorf("%s: invalid perplexity %f", g.Name, g.Perplexity)
+		}
+	}
+	var reliabilityCount int
+	for _, b := range report.Reliability {
+		reliabilityCount += b.Count
+	}
+	if reliabilityCount != 2*len(names) {
+		t.Errorf("expected %d responses in reliability buckets but got %d", 2*len(names),
+			reliabilityCount)
+	}
+	if report.String() == "" {
+		t.Error("empty report string")
+	}
+}
+
+func TestEvaluationSuiteRunSamples(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	right := &Sample{Query: "evaluate 1+1", Response: net.Query("evaluate 1+1")}
+	wrong := &Sample{Query: "evaluate 2+2", Response: net.Query("evaluate 2+2") + "x"}
+	suite := &EvaluationSuite{}
+	report := suite.RunSamples(net, []string{"Right", "Mixed"},
+		[][]*Sample{{right}, {right, wrong}})
+	if len(report.Generators) != 2 {
+		t.Fatalf("expected 2 reports but got %d", len(report.Generators))
+	}
+	for i, expected := range []float64{1, 0.5} {
+		g := report.Generators[i]
+		if g.ExactAccuracy != expected {
+			t.Errorf("%s: expected accuracy %f but got %f", g.Name, expected, g.ExactAccuracy)
+		}
+	}
+	if report.Generators[1].MeanEditDistance != 0.5 {
+		t.Errorf("unexpected edit distance: %f", report.Generators[1].MeanEditDistance)
+	}
+}
+
+func TestEvaluationGeneratorsComplete(t *testing.T) {
+	names, generators := EvaluationGenerators()
+	if len(names) != len(generators) {
+		t.Fatalf("%d names but %d generators", len(names), len(generators))
+	}
+	registered := map[string]bool{}
+	for _, name := range names {
+		g, ok := generators[name]
+		if !ok {
+			t.Fatalf("missing generator: %s", name)
+		}
+		registered[reflect.Indirect(reflect.ValueOf(g)).Type().Name()] = true
+	}
+
+	paths, err := filepath.Glob("*.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range paths {
+		if strings.HasSuffix(path, "_test.go") {
+			continue
+		}
+		file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, decl := range file.Decls {
+			name, ok := generateReceiver(decl)
+			if ok && ast.IsExported(name) && name != "MixtureGenerator" && !registered[name] {
+				t.Errorf("%s is not in EvaluationGenerators", name)
+			}
+		}
+	}
+}
+
+// generateReceiver gets the receiver type of a
+// declaration if it is a Generate() *Sample method.
+func generateReceiver(decl ast.Decl) (string, bool) {
+	fn, ok := decl.(*ast.FuncDecl)
+	if !ok || fn.Recv == nil || fn.Name.Name != "Generate" ||
+		len(fn.Type.Params.List) != 0 || fn.Type.Results == nil ||
+		len(fn.Type.Results.List) != 1 {
+		return "", false
+	}
+	result, ok := fn.Type.Results.List[0].Type.(*ast.StarExpr)
+	if !ok {
+		return "", false
+	}
+	if ident, ok := result.X.(*ast.Ident); !ok || ident.Name != "Sample" {
+		return "", false
+	}
+	recv := fn.Recv.List[0].Type
+	if star, ok := recv.(*ast.StarExpr); ok {
+		recv = star.X
+	}
+	ident, ok := recv.(*ast.Ident)
+	if !ok {
+		return "", false
+	}
+	return ident.Name, true
+}
+
+func TestPerplexityIgnoresWeights(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	samples := []*Sample{
+		{Query: "scale x by 2 in x", Response: "x*2"},
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+	}
+	expected := Perplexity(net, samples)
+	samples[0].Weight = 5
+	if actual := Perplexity(net, samples); actual != expected {
+		t.Errorf("expected perplexity %f but got %f", expected, actual)
+	}
+}
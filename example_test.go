@@ -0,0 +1,31 @@
+package algebrain_test
+
+import (
+	"fmt"
+
+	"github.com/unixpickle/algebrain"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func ExampleSample_InputVectors() {
+	sample := &algebrain.Sample{Query: "evaluate 1+1", Response: "Result: 2"}
+	c := anyvec64.CurrentCreator()
+	vecs, err := sample.InputVectors(c, &algebrain.CharTokenizer{})
+	if err != nil {
+		panic(err)
+	}
+	fmt.Println(len(vecs), vecs[0].Len(), vecs[0].Creator() == c)
+	// Output: 12 128 true
+}
+
+func ExampleSample_DecoderOutVectors() {
+	sample := &algebrain.Sample{Query: "evaluate 1+1", Response: "Result: 2"}
+	tokenizer := &algebrain.WordTokenizer{Words: algebrain.StandardWords}
+	vecs, err := sample.DecoderOutVectors(anyvec64.CurrentCreator(), tokenizer)
+	if err != nil {
+		panic(err)
+	}
+	// "Result", ":", " ", "2", and a terminator.
+	fmt.Println(len(vecs), vecs[0].Len())
+	// Output: 5 140
+}
@@ -0,0 +1,132 @@
+package algebrain
+
+import (
+	"math/rand"
+	"strconv"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+	"github.com/unixpickle/essentials"
+)
+
+// DefaultExponentRuleMax is the default maximum absolute
+// exponent used by an ExponentRuleGenerator.
+const DefaultExponentRuleMax = 5
+
+// An ExponentRuleGenerator generates Samples with queries
+// like "simplify x^2*x^3" or "simplify (x^2)^3", expecting
+// "x^5" or "x^6".
+//
+// Each query uses one of the product, quotient, and power
+// rules for exponents.
+// Negative exponents are written like "x^(-2)", and a
+// zero exponent simplifies to "1".
+type ExponentRuleGenerator struct {
+	// VarName is the variable to use.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// MaxExponent is the maximum absolute exponent in the
+	// query, which is at least 2.
+	// If this is 0, DefaultExponentRuleMax is used.
+	MaxExponent int
+
+	// AllowNonPositive allows negative and zero exponents,
+	// both in queries and in responses.
+	// Otherwise, every exponent is positive.
+	AllowNonPositive bool
+}
+
+// Generate generates an exponent rule sample.
+func (e *ExponentRuleGenerator) Generate() *Sample {
+	varName := e.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	maxExp := e.MaxExponent
+	if maxExp == 0 {
+		maxExp = DefaultExponentRuleMax
+	}
+
+	var query mathexpr.Node
+	var result int
+	switch rand.Intn(3) {
+	case 0:
+		a, b := e.exponent(maxExp), e.exponent(maxExp)
+		query = &mathexpr.BinaryOp{
+			Op:    mathexpr.MultiplyOp,
+			Left:  powerNode(varName, a),
+			Right: powerNode(varName, b),
+		}
+		result = a + b
+	case 1:
+		a, b := e.exponent(maxExp), e.exponent(maxExp)
+		if !e.AllowNonPositive {
+			// Keep the result positive.
+			a = 2 + rand.Intn(essentials.MaxInt(maxExp-1, 1))
+			b = 1 + rand.Intn(a-1)
+		}
+		query = &mathexpr.BinaryOp{
+			Op:    mathexpr.DivideOp,
+			Left:  powerNode(varName, a),
+			Right: powerNode(varName, b),
+		}
+		result = a - b
+	default:
+		// The inner exponent is never 0 or 1, which would
+		// leave nothing to simplify.
+		a, b := 2+rand.Intn(essentials.MaxInt(maxExp-1, 1)), e.exponent(maxExp)
+		if e.AllowNonPositive && rand.Intn(2) == 0 {
+			a = -1 - rand.Intn(maxExp)
+		}
+		query = &mathexpr.BinaryOp{
+			Op:    mathexpr.PowOp,
+			Left:  powerNode(varName, a),
+			Right: intNode(b),
+		}
+		result = a * b
+	}
+	return &Sample{
+		Query:    "simplify " + query.String(),
+		Response: monomialString(varName, result),
+	}
+}
+
+func (e *ExponentRuleGenerator) exponent(maxExp int) int {
+	if e.AllowNonPositive {
+		return rand.Intn(2*maxExp+1) - maxExp
+	}
+	return 1 + rand.Intn(maxExp)
+}
+
+// powerNode creates the expression varName^exponent,
+// which is simply varName for an exponent of 1.
+func powerNode(varName string, exponent int) mathexpr.Node {
+	if exponent == 1 {
+		return mathexpr.RawNode(varName)
+	}
+	return &mathexpr.BinaryOp{
+		Op:    mathexpr.PowOp,
+		Left:  mathexpr.RawNode(varName),
+		Right: intNode(exponent),
+	}
+}
+
+// intNode creates a node for an integer, which is a NegOp
+// if the integer is negative.
+func intNode(x int) mathexpr.Node {
+	if x < 0 {
+		return &mathexpr.NegOp{Node: mathexpr.RawNode(strconv.Itoa(-x))}
+	}
+	return mathexpr.RawNode(strconv.Itoa(x))
+}
+
+// monomialString formats varName^exponent in the same way
+// as a polynomial with a single term.
+func monomialString(varName string, exponent int) string {
+	if exponent < 0 {
+		return powerNode(varName, exponent).String()
+	}
+	p := make(polynomial, exponent+1)
+	p[exponent] = 1
+	return p.String(varName)
+}
@@ -0,0 +1,101 @@
+package algebrain
+
+import (
+	"math"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestExponentRuleGenerator(t *testing.T) {
+	expected := map[string]string{
+		"simplify x^2*x^3":   "x^5",
+		"simplify x^5/x^2":   "x^3",
+		"simplify (x^2)^3":   "x^6",
+		"simplify x*x^2":     "x^3",
+		"simplify x^2/x":     "x",
+		"simplify (x^3)^1":   "x^3",
+		"simplify x^3/x^2":   "x",
+		"simplify (x^2)^2":   "x^4",
+		"simplify x^2*x^2":   "x^4",
+		"simplify x^4/x^3":   "x",
+		"simplify (x^2)^5":   "x^10",
+		"simplify x^5*x^5":   "x^10",
+		"simplify x^3*x^4":   "x^7",
+		"simplify x^5/x^4":   "x",
+		"simplify (x^5)^5":   "x^25",
+		"simplify (x^4)^2":   "x^8",
+		"simplify x^4/x^2":   "x^2",
+		"simplify x*x":       "x^2",
+		"simplify (x^3)^2":   "x^6",
+		"simplify x^5/x^3":   "x^2",
+		"simplify x^2/x^1":   "",
+		"simplify x^1*x^1":   "",
+		"simplify (x^1)^2":   "",
+		"simplify (x^0)^2":   "",
+		"simplify x^0*x^1":   "",
+		"simplify x^(-1)*x":  "",
+		"simplify (x^2)^(0)": "",
+	}
+	gen := &ExponentRuleGenerator{}
+	seen := map[string]bool{}
+	for i := 0; i < 5000; i++ {
+		sample := gen.Generate()
+		if exp, ok := expected[sample.Query]; ok {
+			if exp == "" {
+				t.Fatalf("unexpected query: %s", sample.Query)
+			}
+			seen[sample.Query] = true
+			if sample.Response != exp {
+				t.Errorf("%s: expected %q but got %q", sample.Query, exp, sample.Response)
+			}
+		}
+		if strings.Contains(sample.Response, "-") || sample.Response == "1" {
+			t.Fatalf("%s: non-positive exponent in %q", sample.Query, sample.Response)
+		}
+		checkExponentSample(t, sample)
+	}
+	for _, q := range []string{"simplify x^2*x^3", "simplify x^5/x^2", "simplify (x^2)^3"} {
+		if !seen[q] {
+			t.Errorf("never saw query: %s", q)
+		}
+	}
+
+	gen = &ExponentRuleGenerator{AllowNonPositive: true, VarName: "y"}
+	var sawNegative, sawZero bool
+	for i := 0; i < 5000; i++ {
+		sample := gen.Generate()
+		sawNegative = sawNegative || strings.Contains(sample.Response, "y^(-")
+		sawZero = sawZero || sample.Response == "1"
+		checkExponentSample(t, sample)
+	}
+	if !sawNegative || !sawZero {
+		t.Errorf("expected negative and zero exponents (negative=%v, zero=%v)", sawNegative,
+			sawZero)
+	}
+}
+
+// checkExponentSample checks that the query and response
+// agree at a random point.
+func checkExponentSample(t *testing.T, sample *Sample) {
+	query, err := mathexpr.ParseString(strings.TrimPrefix(sample.Query, "simplify "))
+	if err != nil {
+		t.Fatalf("%s: %v", sample.Query, err)
+	}
+	response, err := mathexpr.ParseString(sample.Response)
+	if err != nil {
+		t.Fatalf("%s: %v", sample.Response, err)
+	}
+	vars := map[string]float64{"x": 1 + rand.Float64(), "y": 1 + rand.Float64()}
+	expected, err1 := mathexpr.Evaluate(query, vars)
+	actual, err2 := mathexpr.Evaluate(response, vars)
+	if err1 != nil || err2 != nil {
+		t.Fatalf("%s: evaluation failed: %v, %v", sample.Query, err1, err2)
+	}
+	if math.Abs(actual-expected) > 1e-8*math.Max(1, math.Abs(expected)) {
+		t.Fatalf("%s -> %s: expected %f but got %f", sample.Query, sample.Response,
+			expected, actual)
+	}
+}
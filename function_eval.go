@@ -0,0 +1,134 @@
+package algebrain
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// Default bounds for a FunctionEvalGenerator.
+const (
+	DefaultFunctionEvalMaxDepth = 2
+	DefaultFunctionEvalMaxInput = 10
+)
+
+// maxFunctionEvalResult is the maximum absolute result of
+// a FunctionEvalGenerator's samples.
+const maxFunctionEvalResult = 1e6
+
+// DefaultFunctionNames are the function names used by a
+// FunctionEvalGenerator without FuncNames.
+var DefaultFunctionNames = []string{"f", "g", "h"}
+
+// A FunctionEvalGenerator generates Samples with queries
+// like "if f(x)=x^2+1 then f(3)=?", expecting "Result: 10".
+//
+// Definitions are only used with inputs where they are
+// defined, i.e. where no divisor is zero, and the result
+// is always an integer.
+type FunctionEvalGenerator struct {
+	// Generator creates the function definitions.
+	// Definitions with variables other than VarName are
+	// skipped.
+	// If this is nil, a generator of integer expressions
+	// in VarName is used.
+	Generator *mathexpr.Generator
+
+	// MaxDepth is the maximum depth of the definitions.
+	// If this is 0, DefaultFunctionEvalMaxDepth is used.
+	MaxDepth int
+
+	// VarName is the function's argument.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// FuncNames are the possible function names.
+	// If this is empty, DefaultFunctionNames is used.
+	FuncNames []string
+
+	// MaxInput is the maximum absolute input.
+	// If this is 0, DefaultFunctionEvalMaxInput is used.
+	MaxInput int
+}
+
+// Generate generates a function evaluation sample.
+func (f *FunctionEvalGenerator) Generate() *Sample {
+	varName := f.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	gen := f.Generator
+	if gen == nil {
+		gen = &mathexpr.Generator{NoReals: true, VarNames: []string{varName}}
+	}
+	maxDepth := f.MaxDepth
+	if maxDepth == 0 {
+		maxDepth = DefaultFunctionEvalMaxDepth
+	}
+	funcNames := f.FuncNames
+	if len(funcNames) == 0 {
+		funcNames = DefaultFunctionNames
+	}
+	maxInput := f.MaxInput
+	if maxInput == 0 {
+		maxInput = DefaultFunctionEvalMaxInput
+	}
+
+	for {
+		definition := gen.Generate(maxDepth)
+		if len(usedVarNames(definition, []string{varName})) == 0 {
+			continue
+		}
+		input := rand.Intn(2*maxInput+1) - maxInput
+		result, ok := functionEvalResult(substituteNode(mathexpr.Clone(definition), varName,
+			intNode(input)))
+		if !ok {
+			continue
+		}
+		name := funcNames[rand.Intn(len(funcNames))]
+		return &Sample{
+			Query: fmt.Sprintf("if %s(%s)=%s then %s(%d)=?", name, varName, definition,
+				name, input),
+			Response: "Result: " + strconv.Itoa(result),
+		}
+	}
+}
+
+// functionEvalResult evaluates an expression without
+// variables, failing if it divides by zero or if the
+// result is not a reasonably small integer.
+func functionEvalResult(n mathexpr.Node) (int, bool) {
+	if !definedDivisions(n) {
+		return 0, false
+	}
+	val, err := mathexpr.Evaluate(n, nil)
+	if err != nil || math.IsNaN(val) || math.Abs(val) > maxFunctionEvalResult {
+		return 0, false
+	}
+	rounded := math.Round(val)
+	if math.Abs(val-rounded) > 1e-8 {
+		return 0, false
+	}
+	return int(rounded), true
+}
+
+// definedDivisions checks that no divisor in an
+// expression evaluates to zero.
+//
+// This catches undefined points which do not show up in
+// the final value, like 1/(1/0).
+func definedDivisions(n mathexpr.Node) bool {
+	for _, child := range n.Children() {
+		if !definedDivisions(child) {
+			return false
+		}
+	}
+	if b, ok := n.(*mathexpr.BinaryOp); ok && b.Op == mathexpr.DivideOp {
+		divisor, err := mathexpr.Evaluate(b.Right, nil)
+		return err == nil && divisor != 0
+	}
+	return true
+}
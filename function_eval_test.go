@@ -0,0 +1,76 @@
+package algebrain
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestFunctionEvalGenerator(t *testing.T) {
+	gen := &FunctionEvalGenerator{FuncNames: []string{"f", "g"}, MaxInput: 5}
+	expr := regexp.MustCompile(`^if ([fg])\(x\)=(.*) then ([fg])\((-?[0-9]+)\)=\?$`)
+	seenNames := map[string]bool{}
+	for i := 0; i < 500; i++ {
+		sample := gen.Generate()
+		if err := sample.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		match := expr.FindStringSubmatch(sample.Query)
+		if match == nil {
+			t.Fatalf("unexpected query: %q", sample.Query)
+		}
+		if match[1] != match[3] {
+			t.Fatalf("mismatched function names: %q", sample.Query)
+		}
+		seenNames[match[1]] = true
+		definition, err := mathexpr.ParseString(match[2])
+		if err != nil {
+			t.Fatalf("query %q: %s", sample.Query, err)
+		}
+		input, _ := strconv.Atoi(match[4])
+		if input < -5 || input > 5 {
+			t.Fatalf("input out of range: %q", sample.Query)
+		}
+		val, err := mathexpr.Evaluate(definition, map[string]float64{"x": float64(input)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := "Result: " + strconv.Itoa(int(math.Round(val)))
+		if sample.Response != expected {
+			t.Fatalf("query %q: expected %q but got %q", sample.Query, expected,
+				sample.Response)
+		}
+	}
+	if len(seenNames) != 2 {
+		t.Errorf("unexpected function names: %v", seenNames)
+	}
+}
+
+func TestFunctionEvalResult(t *testing.T) {
+	cases := map[string]struct {
+		Result int
+		OK     bool
+	}{
+		"(-3)^2+1":  {10, true},
+		"7/2*2":     {7, true},
+		"7/2":       {0, false},
+		"1/(3-3)":   {0, false},
+		"1/(1/0)":   {0, false},
+		"0^(-1)":    {0, false},
+		"10^10":     {0, false},
+		"-(4*5)+20": {0, true},
+	}
+	for in, c := range cases {
+		n, err := mathexpr.ParseString(in)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result, ok := functionEvalResult(n)
+		if ok != c.OK || (ok && result != c.Result) {
+			t.Errorf("%s: expected (%d, %v) but got (%d, %v)", in, c.Result, c.OK, result, ok)
+		}
+	}
+}
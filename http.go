@@ -0,0 +1,165 @@
+package algebrain
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+// Default limits for an HTTPHandler.
+const (
+	DefaultHTTPMaxRequestSize = 1 << 16
+	DefaultHTTPTimeout        = 10 * time.Second
+)
+
+// An HTTPHandler serves a JSON API for a Network.
+//
+// The API has the following endpoints:
+//
+//	POST /query   {"query": "..."} gives
+//	              {"response": "...", "logprob": ..., "steps": ...}
+//	GET  /healthz gives {"status": "ok"}
+//	GET  /model   gives the model fingerprint and a
+//	              summary of the architecture
+//
+// Failed requests get a JSON object with an "error" field.
+type HTTPHandler struct {
+	Network *Network
+
+	// MaxRequestSize is the maximum request body size in
+	// bytes.
+	// If this is 0, DefaultHTTPMaxRequestSize is used.
+	MaxRequestSize int64
+
+	// Timeout limits the time spent on each query.
+	// If this is 0, DefaultHTTPTimeout is used.
+	Timeout time.Duration
+
+	// Metrics, if non-nil, records request and decoding
+	// metrics.
+	Metrics *HTTPMetrics
+
+	model *ModelInfo
+}
+
+// ModelInfo summarizes a Network.
+type ModelInfo struct {
+	// Fingerprint is the hex SHA-256 hash of the
+	// serialized Network.
+	Fingerprint string `json:"fingerprint"`
+
+	Tokenizer    string `json:"tokenizer"`
+	VocabSize    int    `json:"vocab_size"`
+	Parameters   int    `json:"parameters"`
+	ReverseInput bool   `json:"reverse_input"`
+}
+
+// NewHTTPHandler creates an HTTPHandler for the Network.
+//
+// The Network is serialized to compute its fingerprint, so
+// it should not be modified while it is being served.
+func NewHTTPHandler(n *Network) (*HTTPHandler, error) {
+	data, err := serializer.SerializeAny(n)
+	if err != nil {
+		return nil, essentials.AddCtx("create HTTP handler", err)
+	}
+	hash := sha256.Sum256(data)
+	info := &ModelInfo{
+		Fingerprint:  hex.EncodeToString(hash[:]),
+		Tokenizer:    n.Tokenizer.SerializerType(),
+		VocabSize:    n.Tokenizer.VocabSize(),
+		Parameters:   n.ParameterCount(),
+		ReverseInput: n.ReverseInput,
+	}
+	return &HTTPHandler{Network: n, model: info}, nil
+}
+
+// ServeHTTP serves an API request.
+func (h *HTTPHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if h.Metrics != nil {
+		endpoint := r.URL.Path
+		if endpoint != "/query" && endpoint != "/healthz" && endpoint != "/model" {
+			endpoint = "other"
+		}
+		defer h.Metrics.startRequest(endpoint)()
+	}
+	switch r.URL.Path {
+	case "/query":
+		if r.Method != http.MethodPost {
+			writeHTTPError(w, http.StatusMethodNotAllowed, errors.New("expected POST"))
+			return
+		}
+		h.serveQuery(w, r)
+	case "/healthz", "/model":
+		if r.Method != http.MethodGet {
+			writeHTTPError(w, http.StatusMethodNotAllowed, errors.New("expected GET"))
+		} else if r.URL.Path == "/healthz" {
+			writeHTTPJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+		} else {
+			writeHTTPJSON(w, http.StatusOK, h.model)
+		}
+	default:
+		writeHTTPError(w, http.StatusNotFound, errors.New("unknown endpoint"))
+	}
+}
+
+func (h *HTTPHandler) serveQuery(w http.ResponseWriter, r *http.Request) {
+	maxSize := h.MaxRequestSize
+	if maxSize == 0 {
+		maxSize = DefaultHTTPMaxRequestSize
+	}
+	timeout := h.Timeout
+	if timeout == 0 {
+		timeout = DefaultHTTPTimeout
+	}
+
+	var req struct {
+		Query string `json:"query"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSize)).Decode(&req); err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeHTTPError(w, http.StatusRequestEntityTooLarge, err)
+		} else {
+			writeHTTPError(w, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	res, err := h.Network.decode(ctx, req.Query, argMaxFloats)
+	if err != nil {
+		if ctx.Err() != nil {
+			writeHTTPError(w, http.StatusGatewayTimeout, err)
+		} else {
+			writeHTTPError(w, http.StatusBadRequest, err)
+		}
+		return
+	}
+	if h.Metrics != nil {
+		h.Metrics.recordDecode(res)
+	}
+	writeHTTPJSON(w, http.StatusOK, map[string]interface{}{
+		"response": res.Response,
+		"logprob":  res.LogProb,
+		"steps":    res.Steps,
+	})
+}
+
+func writeHTTPJSON(w http.ResponseWriter, status int, obj interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(obj)
+}
+
+func writeHTTPError(w http.ResponseWriter, status int, err error) {
+	writeHTTPJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,90 @@
+package algebrain
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestHTTPHandler(t *testing.T) {
+	handler, err := NewHTTPHandler(NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.MaxRequestSize = 100
+	server := httptest.NewServer(handler)
+	defer server.Close()
+
+	request := func(method, path, body string) (int, map[string]interface{}) {
+		req, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var obj map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&obj); err != nil {
+			t.Fatalf("%s %s: %s", method, path, err)
+		}
+		return resp.StatusCode, obj
+	}
+
+	if status, obj := request("GET", "/healthz", ""); status != http.StatusOK ||
+		obj["status"] != "ok" {
+		t.Errorf("unexpected healthz response: %d %v", status, obj)
+	}
+	if status, obj := request("GET", "/model", ""); status != http.StatusOK ||
+		len(obj["fingerprint"].(string)) != 64 || obj["vocab_size"] != float64(CharCount) {
+		t.Errorf("unexpected model response: %d %v", status, obj)
+	}
+
+	status, obj := request("POST", "/query", `{"query": "evaluate 1+1"}`)
+	if status != http.StatusOK {
+		t.Fatalf("unexpected status %d: %v", status, obj)
+	}
+	if obj["response"] != handler.Network.Query("evaluate 1+1") {
+		t.Errorf("unexpected response: %v", obj["response"])
+	}
+	if obj["steps"].(float64) < 1 || obj["logprob"].(float64) > 0 {
+		t.Errorf("unexpected steps or logprob: %v", obj)
+	}
+
+	errorCases := []struct {
+		method string
+		path   string
+		body   string
+		status int
+	}{
+		{"GET", "/query", "", http.StatusMethodNotAllowed},
+		{"POST", "/healthz", "", http.StatusMethodNotAllowed},
+		{"GET", "/foo", "", http.StatusNotFound},
+		{"POST", "/query", `{"query": "evaluate ∑"}`, http.StatusBadRequest},
+		{"POST", "/query", `{"query": `, http.StatusBadRequest},
+		{"POST", "/query", `{"query": "` + strings.Repeat("1", 200) + `"}`,
+			http.StatusRequestEntityTooLarge},
+	}
+	for _, c := range errorCases {
+		status, obj := request(c.method, c.path, c.body)
+		if status != c.status {
+			t.Errorf("%s %s: expected status %d but got %d", c.method, c.path, c.status,
+				status)
+		}
+		if _, ok := obj["error"].(string); !ok {
+			t.Errorf("%s %s: missing error message", c.method, c.path)
+		}
+	}
+
+	handler.Timeout = time.Nanosecond
+	if status, _ := request("POST", "/query", `{"query": "evaluate 1+1"}`); status !=
+		http.StatusGatewayTimeout {
+		t.Errorf("expected timeout but got status %d", status)
+	}
+}
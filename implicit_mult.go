@@ -0,0 +1,160 @@
+package algebrain
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Default bounds for an ImplicitMultGenerator.
+const (
+	DefaultImplicitMultMaxCoefficient = 5
+	DefaultImplicitMultMaxValue       = 5
+)
+
+// An ImplicitMultGenerator generates Samples with queries
+// that use implicit multiplication, like "expand
+// 2x(x+1)", expecting "2*x^2+2*x", or "evaluate
+// (x+1)(x-2)^2 where x=3", expecting "Result: 4".
+//
+// Queries are products of an optional coefficient, an
+// optional power of the variable, and parenthesized
+// linear factors, which may be squared.
+// These include the forms that are easy to misread, such
+// as "2x^2" (which is not (2x)^2) and "x(x+1)" (which is
+// not a function call).
+// Responses always use explicit multiplication.
+type ImplicitMultGenerator struct {
+	// VarName is the variable to use.
+	// It should be a single letter, so that products like
+	// "2x" are unambiguous.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// MaxCoefficient is the maximum absolute value of the
+	// leading coefficient and of the coefficients in each
+	// linear factor.
+	// If this is 0, DefaultImplicitMultMaxCoefficient is
+	// used.
+	MaxCoefficient int
+
+	// MaxValue is the maximum absolute value of the
+	// constants in each linear factor and of the variable
+	// in evaluation queries.
+	// If this is 0, DefaultImplicitMultMaxValue is used.
+	MaxValue int
+}
+
+// Generate generates an implicit multiplication sample.
+func (i *ImplicitMultGenerator) Generate() *Sample {
+	varName := i.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	maxCoeff := i.MaxCoefficient
+	if maxCoeff == 0 {
+		maxCoeff = DefaultImplicitMultMaxCoefficient
+	}
+	maxValue := i.MaxValue
+	if maxValue == 0 {
+		maxValue = DefaultImplicitMultMaxValue
+	}
+	randNonZero := func(max int) int {
+		res := rand.Intn(max) + 1
+		if rand.Intn(2) == 0 {
+			res = -res
+		}
+		return res
+	}
+
+	var product implicitProduct
+	for product.numFactors() < 2 {
+		product = implicitProduct{Coeff: 1, Power: rand.Intn(3)}
+		if rand.Intn(2) == 0 {
+			product.Coeff = randNonZero(maxCoeff)
+		}
+		for j := rand.Intn(3); j > 0; j-- {
+			product.Factors = append(product.Factors, implicitFactor{
+				Linear:   polynomial{randNonZero(maxValue), rand.Intn(maxCoeff) + 1},
+				Exponent: 1 + rand.Intn(2),
+			})
+		}
+	}
+
+	query := product.String(varName)
+	poly := product.polynomial()
+	if rand.Intn(2) == 0 {
+		return &Sample{
+			Query:    "expand " + query,
+			Response: poly.String(varName),
+		}
+	}
+	value := rand.Intn(2*maxValue+1) - maxValue
+	return &Sample{
+		Query:    fmt.Sprintf("evaluate %s where %s=%d", query, varName, value),
+		Response: "Result: " + strconv.Itoa(int(poly.evaluate(float64(value)))),
+	}
+}
+
+// An implicitProduct is a product like -3x^2(x+1)(2x-1)^2,
+// written with implicit multiplication.
+type implicitProduct struct {
+	Coeff   int
+	Power   int
+	Factors []implicitFactor
+}
+
+// An implicitFactor is a linear polynomial raised to an
+// Exponent.
+type implicitFactor struct {
+	Linear   polynomial
+	Exponent int
+}
+
+// numFactors counts the juxtaposed factors of the product,
+// not including coefficients of 1 or -1.
+func (i *implicitProduct) numFactors() int {
+	res := len(i.Factors)
+	if i.Coeff != 1 && i.Coeff != -1 {
+		res++
+	}
+	if i.Power > 0 {
+		res++
+	}
+	return res
+}
+
+func (i *implicitProduct) polynomial() polynomial {
+	res := make(polynomial, i.Power+1)
+	res[i.Power] = i.Coeff
+	for _, f := range i.Factors {
+		res = res.mul(f.Linear.pow(f.Exponent))
+	}
+	return res
+}
+
+// String writes the product without any multiplication
+// symbols, e.g. "-x(2x+1)^2".
+func (i *implicitProduct) String(varName string) string {
+	var res strings.Builder
+	if i.Coeff == -1 {
+		res.WriteString("-")
+	} else if i.Coeff != 1 {
+		res.WriteString(strconv.Itoa(i.Coeff))
+	}
+	if i.Power > 0 {
+		res.WriteString(varName)
+		if i.Power > 1 {
+			res.WriteString("^" + strconv.Itoa(i.Power))
+		}
+	}
+	for _, f := range i.Factors {
+		linear := strings.Replace(f.Linear.String(varName), "*", "", -1)
+		res.WriteString("(" + linear + ")")
+		if f.Exponent > 1 {
+			res.WriteString("^" + strconv.Itoa(f.Exponent))
+		}
+	}
+	return res.String()
+}
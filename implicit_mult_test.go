@@ -0,0 +1,130 @@
+package algebrain
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"unicode"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestImplicitProduct(t *testing.T) {
+	cases := []struct {
+		Product  implicitProduct
+		Query    string
+		Expanded string
+	}{
+		{implicitProduct{Coeff: 2, Power: 1}, "2x", "2*x"},
+		{implicitProduct{Coeff: 2, Power: 2}, "2x^2", "2*x^2"},
+		{
+			implicitProduct{Coeff: 2, Factors: []implicitFactor{{polynomial{1, 1}, 1}}},
+			"2(x+1)", "2*x+2",
+		},
+		{
+			implicitProduct{Coeff: 1, Factors: []implicitFactor{{polynomial{1, 1}, 1},
+				{polynomial{2, 1}, 1}}},
+			"(x+1)(x+2)", "x^2+3*x+2",
+		},
+		{
+			implicitProduct{Coeff: 1, Power: 1, Factors: []implicitFactor{{polynomial{3, 1}, 1}}},
+			"x(x+3)", "x^2+3*x",
+		},
+		{
+			implicitProduct{Coeff: 3, Factors: []implicitFactor{{polynomial{-1, 1}, 2}}},
+			"3(x-1)^2", "3*x^2-6*x+3",
+		},
+		{
+			implicitProduct{Coeff: -1, Power: 1, Factors: []implicitFactor{{polynomial{1, 2}, 1}}},
+			"-x(2x+1)", "-2*x^2-x",
+		},
+		{
+			implicitProduct{Coeff: 1, Power: 2, Factors: []implicitFactor{{polynomial{-2, 1}, 2},
+				{polynomial{1, 3}, 1}}},
+			"x^2(x-2)^2(3x+1)", "3*x^5-11*x^4+8*x^3+4*x^2",
+		},
+	}
+	for _, c := range cases {
+		if query := c.Product.String("x"); query != c.Query {
+			t.Errorf("expected query %s but got %s", c.Query, query)
+		}
+		if expanded := c.Product.polynomial().String("x"); expanded != c.Expanded {
+			t.Errorf("%s: expected %s but got %s", c.Query, c.Expanded, expanded)
+		}
+	}
+}
+
+func TestImplicitMultGenerator(t *testing.T) {
+	evalExpr := regexp.MustCompile(`^evaluate (.*) where x=(-?[0-9]+)$`)
+	gen := &ImplicitMultGenerator{}
+	var numExpand, numEval int
+	for i := 0; i < 1000; i++ {
+		sample := gen.Generate()
+		var query string
+		var points []float64
+		var expected mathexpr.Node
+		if strings.HasPrefix(sample.Query, "expand ") {
+			numExpand++
+			query = strings.TrimPrefix(sample.Query, "expand ")
+			points = []float64{-2, -1, 0, 1, 2, 3}
+			var err error
+			expected, err = mathexpr.ParseString(sample.Response)
+			if err != nil {
+				t.Fatalf("%s -> %s: %s", sample.Query, sample.Response, err)
+			}
+		} else if match := evalExpr.FindStringSubmatch(sample.Query); match != nil {
+			numEval++
+			query = match[1]
+			value, _ := strconv.ParseFloat(match[2], 64)
+			points = []float64{value}
+			expected = mathexpr.RawNode(strings.TrimPrefix(sample.Response, "Result: "))
+		} else {
+			t.Fatalf("unexpected query: %s", sample.Query)
+		}
+		if strings.Contains(query, "*") || explicitMultiplication(query) == query {
+			t.Fatalf("query lacks implicit multiplication: %s", sample.Query)
+		}
+		if explicitMultiplication(sample.Response) != sample.Response {
+			t.Fatalf("response uses implicit multiplication: %s", sample.Response)
+		}
+
+		actual, err := mathexpr.ParseString(explicitMultiplication(query))
+		if err != nil {
+			t.Fatalf("%s: %s", sample.Query, err)
+		}
+		for _, x := range points {
+			vars := map[string]float64{"x": x}
+			expectedVal, err1 := mathexpr.Evaluate(expected, vars)
+			actualVal, err2 := mathexpr.Evaluate(actual, vars)
+			if err1 != nil || err2 != nil || math.Abs(expectedVal-actualVal) > 1e-8 {
+				t.Fatalf("%s -> %s: disagree at x=%f", sample.Query, sample.Response, x)
+			}
+		}
+	}
+	if numExpand == 0 || numEval == 0 {
+		t.Errorf("unexpected query counts: %d expand, %d evaluate", numExpand, numEval)
+	}
+}
+
+// explicitMultiplication inserts a "*" wherever a number,
+// variable, or closing parenthesis is followed by a
+// variable or opening parenthesis.
+// Variables are assumed to be single letters, so adjacent
+// letters are left alone.
+func explicitMultiplication(s string) string {
+	var res []rune
+	for i, r := range s {
+		if i > 0 {
+			prev := rune(s[i-1])
+			if (unicode.IsLetter(prev) || unicode.IsDigit(prev) || prev == ')') &&
+				(unicode.IsLetter(r) || r == '(') &&
+				!(unicode.IsLetter(prev) && unicode.IsLetter(r)) {
+				res = append(res, '*')
+			}
+		}
+		res = append(res, r)
+	}
+	return string(res)
+}
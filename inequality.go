@@ -0,0 +1,101 @@
+package algebrain
+
+import (
+	"math/big"
+	"math/rand"
+	"strconv"
+)
+
+// Default bounds for an InequalityGenerator.
+const (
+	DefaultInequalityMaxValue       = 10
+	DefaultInequalityMaxCoefficient = 5
+)
+
+// inequalityFlips maps each inequality operator to the
+// operator with its sides swapped, which is used when
+// dividing by a negative coefficient.
+var inequalityFlips = map[string]string{
+	"<":  ">",
+	"<=": ">=",
+	">":  "<",
+	">=": "<=",
+}
+
+// An InequalityGenerator generates Samples with queries
+// like "solve 3*x-2<7", expecting "x<3", or "solve
+// -2*x>=6", expecting "x<=-3".
+//
+// Inequalities have the form a*x+b<c (or with >, <=, or
+// >=), with a nonzero.
+// Responses always put the variable on the left, and
+// non-integer bounds are reduced fractions like "7/3".
+type InequalityGenerator struct {
+	// VarName is the variable to use.
+	// If this is empty, "x" is used.
+	VarName string
+
+	// MaxValue is the maximum absolute value of the
+	// constant b, and of c (or of the bound, if AllInts is
+	// set).
+	// If this is 0, DefaultInequalityMaxValue is used.
+	MaxValue int
+
+	// MaxCoefficient is the maximum absolute value of the
+	// coefficient a.
+	// If this is 0, DefaultInequalityMaxCoefficient is
+	// used.
+	MaxCoefficient int
+
+	// AllInts ensures that every bound is an integer.
+	AllInts bool
+}
+
+// Generate generates a linear inequality sample.
+func (i *InequalityGenerator) Generate() *Sample {
+	varName := i.VarName
+	if varName == "" {
+		varName = "x"
+	}
+	maxValue := i.MaxValue
+	if maxValue == 0 {
+		maxValue = DefaultInequalityMaxValue
+	}
+	maxCoeff := i.MaxCoefficient
+	if maxCoeff == 0 {
+		maxCoeff = DefaultInequalityMaxCoefficient
+	}
+	randInt := func(max int) int {
+		return rand.Intn(2*max+1) - max
+	}
+
+	coeff := rand.Intn(maxCoeff) + 1
+	if rand.Intn(2) == 0 {
+		coeff = -coeff
+	}
+	constant := randInt(maxValue)
+	var rhs int
+	if i.AllInts {
+		rhs = coeff*randInt(maxValue) + constant
+	} else {
+		rhs = randInt(maxValue)
+	}
+
+	ops := []string{"<", "<=", ">", ">="}
+	op := ops[rand.Intn(len(ops))]
+	bound, resultOp := solveLinearInequality(coeff, constant, rhs, op)
+	return &Sample{
+		Query:    "solve " + polynomial{constant, coeff}.String(varName) + op + strconv.Itoa(rhs),
+		Response: varName + resultOp + bound.RatString(),
+	}
+}
+
+// solveLinearInequality solves a*x+b op c for x, giving
+// the bound and the operator relating x to it.
+func solveLinearInequality(a, b, c int, op string) (*big.Rat, string) {
+	bound := big.NewRat(int64(c-b), int64(a))
+	if a < 0 {
+		op = inequalityFlips[op]
+	}
+	return bound, op
+}
@@ -0,0 +1,101 @@
+package algebrain
+
+import (
+	"math/big"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+func TestSolveLinearInequality(t *testing.T) {
+	cases := []struct {
+		A, B, C int
+		Op      string
+		Bound   string
+		ResOp   string
+	}{
+		{3, -2, 7, "<", "3", "<"},
+		{3, -2, 7, "<=", "3", "<="},
+		{-2, 0, 6, "<", "-3", ">"},
+		{-2, 0, 6, "<=", "-3", ">="},
+		{-2, 1, 6, ">", "-5/2", "<"},
+		{-3, 0, 6, ">=", "-2", "<="},
+		{3, 0, 7, ">", "7/3", ">"},
+		{4, 2, 0, ">=", "-1/2", ">="},
+		{5, 3, 3, "<", "0", "<"},
+	}
+	for _, c := range cases {
+		bound, op := solveLinearInequality(c.A, c.B, c.C, c.Op)
+		if bound.RatString() != c.Bound || op != c.ResOp {
+			t.Errorf("%d*x+%d%s%d: expected x%s%s but got x%s%s", c.A, c.B, c.Op, c.C,
+				c.ResOp, c.Bound, op, bound.RatString())
+		}
+	}
+}
+
+func TestInequalityGenerator(t *testing.T) {
+	queryExpr := regexp.MustCompile(`^solve (.*?)(<=|>=|<|>)(-?[0-9]+)$`)
+	responseExpr := regexp.MustCompile(`^x(<=|>=|<|>)(-?[0-9]+(/[0-9]+)?)$`)
+	for _, allInts := range []bool{false, true} {
+		gen := &InequalityGenerator{AllInts: allInts}
+		var flipped, fraction bool
+		for i := 0; i < 1000; i++ {
+			sample := gen.Generate()
+			queryMatch := queryExpr.FindStringSubmatch(sample.Query)
+			responseMatch := responseExpr.FindStringSubmatch(sample.Response)
+			if queryMatch == nil || responseMatch == nil {
+				t.Fatalf("unexpected sample: %s -> %s", sample.Query, sample.Response)
+			}
+			lhs, err := mathexpr.ParseString(queryMatch[1])
+			if err != nil {
+				t.Fatalf("%s: %s", sample.Query, err)
+			}
+			rhs, _ := strconv.Atoi(queryMatch[3])
+			bound, ok := new(big.Rat).SetString(responseMatch[2])
+			if !ok {
+				t.Fatalf("invalid bound: %s", sample.Response)
+			}
+			if allInts && !bound.IsInt() {
+				t.Fatalf("non-integer bound: %s -> %s", sample.Query, sample.Response)
+			}
+			fraction = fraction || !bound.IsInt()
+			flipped = flipped || queryMatch[2] != responseMatch[1]
+
+			// Check points just below, at, and above the bound.
+			boundVal, _ := bound.Float64()
+			for _, x := range []float64{boundVal - 0.25, boundVal, boundVal + 0.25} {
+				val, err := mathexpr.Evaluate(lhs, map[string]float64{"x": x})
+				if err != nil {
+					t.Fatal(err)
+				}
+				if inequalityHolds(val, float64(rhs), queryMatch[2]) !=
+					inequalityHolds(x, boundVal, responseMatch[1]) {
+					t.Fatalf("%s -> %s: disagree at x=%f", sample.Query, sample.Response, x)
+				}
+			}
+		}
+		if !flipped {
+			t.Errorf("AllInts=%v: never flipped an inequality", allInts)
+		}
+		if !allInts && !fraction {
+			t.Error("never generated a fractional bound")
+		}
+	}
+}
+
+func inequalityHolds(x, y float64, op string) bool {
+	const epsilon = 1e-9
+	switch op {
+	case "<":
+		return x < y-epsilon
+	case "<=":
+		return x <= y+epsilon
+	case ">":
+		return x > y+epsilon
+	case ">=":
+		return x >= y-epsilon
+	}
+	panic("unknown operator: " + op)
+}
@@ -0,0 +1,76 @@
+package algebrain
+
+import "github.com/unixpickle/anyvec"
+
+// An InputEncoder converts query tokens into the vectors
+// which are fed to a Network's encoder, e.g. to replace
+// one-hot vectors with learned or precomputed embeddings.
+//
+// The vector size determines the input size of the
+// encoder's first layers, so a Network must be created
+// with NewNetworkInputEncoder to use a given InputEncoder.
+//
+// To save a Network with an InputEncoder, the InputEncoder
+// must implement serializer.Serializer and be registered
+// with the serializer package.
+type InputEncoder interface {
+	// InputSize returns the length of the encoded vectors
+	// for a vocabulary of the given size.
+	InputSize(vocabSize int) int
+
+	// EncodeToken encodes a token from a vocabulary of the
+	// given size.
+	// The result may be shared between calls, so callers
+	// must not modify it.
+	EncodeToken(c anyvec.Creator, token, vocabSize int) anyvec.Vector
+}
+
+// OneHotEncoder is an InputEncoder which produces one-hot
+// vectors.
+// It is equivalent to the default input encoding.
+type OneHotEncoder struct{}
+
+// InputSize returns vocabSize.
+func (o OneHotEncoder) InputSize(vocabSize int) int {
+	return vocabSize
+}
+
+// EncodeToken returns a one-hot vector.
+func (o OneHotEncoder) EncodeToken(c anyvec.Creator, token, vocabSize int) anyvec.Vector {
+	return oneHotVector(c, token, vocabSize)
+}
+
+// encodeQuery encodes query tokens with the network's
+// InputEncoder, defaulting to one-hot vectors.
+func (n *Network) encodeQuery(tokens []int) []anyvec.Vector {
+	vocabSize := n.Tokenizer.VocabSize()
+	if n.InputEncoder == nil {
+		return oneHotSequence(n.creator(), tokens, vocabSize)
+	}
+	res := make([]anyvec.Vector, len(tokens))
+	for i, token := range tokens {
+		res[i] = n.InputEncoder.EncodeToken(n.creator(), token, vocabSize)
+	}
+	return res
+}
+
+// EncodedInputVectors is like InputVectors, but the tokens
+// are encoded with e.
+// If reverse is true, the tokens of the query are in
+// reverse order.
+func (s *Sample) EncodedInputVectors(c anyvec.Creator, t Tokenizer, e InputEncoder,
+	reverse bool) ([]anyvec.Vector, error) {
+	tokens, err := t.Encode(s.Query)
+	if err != nil {
+		return nil, err
+	}
+	if reverse {
+		tokens = reversedTokens(tokens)
+	}
+	vocabSize := t.VocabSize()
+	res := make([]anyvec.Vector, len(tokens))
+	for i, token := range tokens {
+		res[i] = e.EncodeToken(c, token, vocabSize)
+	}
+	return res, nil
+}
@@ -0,0 +1,71 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+// binaryEncoder encodes tokens as vectors of bits.
+type binaryEncoder struct{}
+
+func (b binaryEncoder) InputSize(vocabSize int) int {
+	return 8
+}
+
+func (b binaryEncoder) EncodeToken(c anyvec.Creator, token, vocabSize int) anyvec.Vector {
+	bits := make([]float64, 8)
+	for i := range bits {
+		bits[i] = float64((token >> uint(i)) & 1)
+	}
+	return c.MakeVectorData(c.MakeNumericList(bits))
+}
+
+func TestInputEncoder(t *testing.T) {
+	c := anyvec64.CurrentCreator()
+	net := NewNetworkInputEncoder(c, &CharTokenizer{}, binaryEncoder{})
+	for _, reverse := range []bool{false, true} {
+		net.ReverseInput = reverse
+		net.Query("evaluate 1+2")
+
+		batch, err := net.MakeBatch([]*Sample{{Query: "abc", Response: "x"}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		steps := batch.EncIn.Output()
+		if len(steps) != 3 {
+			t.Fatalf("expected 3 steps but got %d", len(steps))
+		}
+		first := vectorFloats(steps[0].Packed)
+		expected := vectorFloats(binaryEncoder{}.EncodeToken(c, 'a', CharCount))
+		if reverse {
+			expected = vectorFloats(binaryEncoder{}.EncodeToken(c, 'c', CharCount))
+		}
+		if len(first) != len(expected) {
+			t.Fatalf("expected input size %d but got %d", len(expected), len(first))
+		}
+		for i, x := range expected {
+			if first[i] != x {
+				t.Errorf("reverse=%v: unexpected first input %v", reverse, first)
+				break
+			}
+		}
+		trainer := &Trainer{Network: net}
+		trainer.Gradient(batch)
+	}
+
+	if _, err := net.MarshalToJSON(); err == nil {
+		t.Error("expected JSON export to fail with a custom encoder")
+	}
+}
+
+func TestOneHotEncoder(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	const query = "scale x by 2 in x^2"
+	expected := net.Query(query)
+	net.InputEncoder = OneHotEncoder{}
+	if actual := net.Query(query); actual != expected {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+}
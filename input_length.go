@@ -0,0 +1,50 @@
+package algebrain
+
+import (
+	"sync/atomic"
+	"unicode/utf8"
+)
+
+// InputLengthStats computes statistics about the query
+// lengths recorded in the InputLengthHistogram.
+// Every statistic is 0 if no queries have been recorded.
+func (n *Network) InputLengthStats() (mean, p50, p95, p99 float64) {
+	var counts [inputLengthBuckets]int64
+	var total, sum int64
+	for i := range counts {
+		counts[i] = atomic.LoadInt64(&n.InputLengthHistogram[i])
+		total += counts[i]
+		sum += counts[i] * int64(i)
+	}
+	if total == 0 {
+		return
+	}
+	percentile := func(p int64) float64 {
+		rank := (p*total + 99) / 100
+		var seen int64
+		for i, count := range counts {
+			seen += count
+			if seen >= rank {
+				return float64(i)
+			}
+		}
+		return float64(len(counts) - 1)
+	}
+	return float64(sum) / float64(total), percentile(50), percentile(95), percentile(99)
+}
+
+// ResetInputLengthHistogram clears the
+// InputLengthHistogram.
+func (n *Network) ResetInputLengthHistogram() {
+	for i := range n.InputLengthHistogram {
+		atomic.StoreInt64(&n.InputLengthHistogram[i], 0)
+	}
+}
+
+func (n *Network) recordInputLength(q string) {
+	length := utf8.RuneCountInString(q)
+	if length >= inputLengthBuckets {
+		length = inputLengthBuckets - 1
+	}
+	atomic.AddInt64(&n.InputLengthHistogram[length], 1)
+}
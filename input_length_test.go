@@ -0,0 +1,43 @@
+package algebrain
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestInputLengthStats(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+
+	// Make every response empty to keep the test fast.
+	outLayer := net.Output[0].(*anynet.FC)
+	biases := make([]float64, CharCount)
+	biases[Terminator] = 1000
+	outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+
+	if mean, p50, p95, p99 := net.InputLengthStats(); mean != 0 || p50 != 0 || p95 != 0 ||
+		p99 != 0 {
+		t.Error("expected zero stats for an unused network")
+	}
+
+	// Lengths 1 through 100, each once.
+	for i := 1; i <= 100; i++ {
+		net.Query(strings.Repeat("1", i))
+	}
+	mean, p50, p95, p99 := net.InputLengthStats()
+	if mean != 50.5 || p50 != 50 || p95 != 95 || p99 != 99 {
+		t.Errorf("unexpected stats: mean=%f p50=%f p95=%f p99=%f", mean, p50, p95, p99)
+	}
+
+	net.Query(strings.Repeat("1", inputLengthBuckets+10))
+	if count := net.InputLengthHistogram[inputLengthBuckets-1]; count != 1 {
+		t.Errorf("expected long query in last bucket but got count %d", count)
+	}
+
+	net.ResetInputLengthHistogram()
+	if mean, _, _, _ := net.InputLengthStats(); mean != 0 {
+		t.Errorf("expected zero mean after reset but got %f", mean)
+	}
+}
@@ -0,0 +1,138 @@
+package algebrain
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+)
+
+// jsonNetwork is the document format used by MarshalToJSON
+// and MarshalToMessagePack.
+type jsonNetwork struct {
+	Architecture           jsonArchitecture     `json:"architecture"`
+	Tokenizer              jsonTokenizer        `json:"tokenizer"`
+	ReverseInput           bool                 `json:"reverse_input"`
+	Preprocessor           *Preprocessor        `json:"preprocessor,omitempty"`
+	Parameters             map[string][]float64 `json:"parameters"`
+	CalibrationTemperature float64              `json:"calibration_temperature,omitempty"`
+}
+
+type jsonArchitecture struct {
+	QuerySize   int `json:"query_size"`
+	EncodedSize int `json:"encoded_size"`
+	VocabSize   int `json:"vocab_size"`
+}
+
+type jsonTokenizer struct {
+	Type  string   `json:"type"`
+	Words []string `json:"words,omitempty"`
+}
+
+// MarshalToJSON encodes the network as a JSON document
+// containing the architecture, the tokenizer, the
+// calibration temperature, and every parameter (keyed by
+// NamedParameters) as an array of numbers.
+//
+// Unlike the serializer format, the document can easily
+// be read from other languages.
+func (n *Network) MarshalToJSON() ([]byte, error) {
+	doc, err := n.portableDocument()
+	if err != nil {
+		return nil, essentials.AddCtx("marshal JSON", err)
+	}
+	data, err := json.Marshal(doc)
+	if err != nil {
+		return nil, essentials.AddCtx("marshal JSON", err)
+	}
+	return data, nil
+}
+
+// UnmarshalNetworkFromJSON decodes a network encoded with
+// MarshalToJSON, creating its parameters with c.
+func UnmarshalNetworkFromJSON(c anyvec.Creator, data []byte) (*Network, error) {
+	var doc jsonNetwork
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, essentials.AddCtx("unmarshal JSON", err)
+	}
+	res, err := networkFromPortable(c, &doc)
+	if err != nil {
+		return nil, essentials.AddCtx("unmarshal JSON", err)
+	}
+	return res, nil
+}
+
+// portableDocument creates the document shared by the
+// portable formats.
+func (n *Network) portableDocument() (*jsonNetwork, error) {
+	if n.InputEncoder != nil {
+		return nil, errors.New("custom input encoders are not supported")
+	}
+	doc := &jsonNetwork{
+		Architecture: jsonArchitecture{
+			QuerySize:   querySize,
+			EncodedSize: encodedSize,
+			VocabSize:   n.Tokenizer.VocabSize(),
+		},
+		ReverseInput:           n.ReverseInput,
+		Preprocessor:           n.Preprocessor,
+		Parameters:             map[string][]float64{},
+		CalibrationTemperature: n.CalibrationTemperature,
+	}
+	switch t := n.Tokenizer.(type) {
+	case *CharTokenizer:
+		doc.Tokenizer.Type = "char"
+	case *WordTokenizer:
+		doc.Tokenizer.Type = "word"
+		doc.Tokenizer.Words = t.Words
+	default:
+		return nil, fmt.Errorf("unsupported tokenizer: %T", t)
+	}
+	for name, param := range n.NamedParameters() {
+		doc.Parameters[name] = vectorFloats(param.Vector)
+	}
+	return doc, nil
+}
+
+// networkFromPortable creates a network from a document
+// created by portableDocument.
+func networkFromPortable(c anyvec.Creator, doc *jsonNetwork) (*Network, error) {
+	if doc.Architecture.QuerySize != querySize || doc.Architecture.EncodedSize != encodedSize {
+		return nil, errors.New("unsupported architecture")
+	}
+	var tokenizer Tokenizer
+	switch doc.Tokenizer.Type {
+	case "char":
+		tokenizer = &CharTokenizer{}
+	case "word":
+		tokenizer = &WordTokenizer{Words: doc.Tokenizer.Words}
+	default:
+		return nil, fmt.Errorf("unknown tokenizer: %s", doc.Tokenizer.Type)
+	}
+	if tokenizer.VocabSize() != doc.Architecture.VocabSize {
+		return nil, errors.New("vocabulary size mismatch")
+	}
+
+	res := NewNetwork(c, tokenizer)
+	res.ReverseInput = doc.ReverseInput
+	res.Preprocessor = doc.Preprocessor
+	res.CalibrationTemperature = doc.CalibrationTemperature
+	params := res.NamedParameters()
+	if len(params) != len(doc.Parameters) {
+		return nil, fmt.Errorf("expected %d parameters but got %d", len(params),
+			len(doc.Parameters))
+	}
+	for name, param := range params {
+		values, ok := doc.Parameters[name]
+		if !ok {
+			return nil, fmt.Errorf("missing parameter: %s", name)
+		} else if len(values) != param.Vector.Len() {
+			return nil, fmt.Errorf("parameter %s has length %d (expected %d)", name,
+				len(values), param.Vector.Len())
+		}
+		param.Vector.SetData(c.MakeNumericList(values))
+	}
+	return res, nil
+}
@@ -0,0 +1,47 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestNetworkJSON(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+	net.ReverseInput = true
+	net.Preprocessor = &Preprocessor{Lowercase: true}
+	net.CalibrationTemperature = 1.5
+	data, err := net.MarshalToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := UnmarshalNetworkFromJSON(anyvec64.CurrentCreator(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.ReverseInput || !loaded.Preprocessor.Equal(net.Preprocessor) ||
+		loaded.CalibrationTemperature != net.CalibrationTemperature {
+		t.Error("configuration was not preserved")
+	}
+	if loaded.Tokenizer.VocabSize() != net.Tokenizer.VocabSize() {
+		t.Error("tokenizer was not preserved")
+	}
+	expected := net.NamedParameters()
+	for name, param := range loaded.NamedParameters() {
+		actualData := param.Vector.Data().([]float64)
+		expectedData := expected[name].Vector.Data().([]float64)
+		for i, x := range expectedData {
+			if actualData[i] != x {
+				t.Fatalf("parameter %s: index %d: expected %f but got %f", name, i, x,
+					actualData[i])
+			}
+		}
+	}
+	if loaded.Query("evaluate 1+1") != net.Query("evaluate 1+1") {
+		t.Error("loaded network gives different results")
+	}
+
+	if _, err := UnmarshalNetworkFromJSON(anyvec64.CurrentCreator(), data[:len(data)/2]); err == nil {
+		t.Error("expected error for truncated document")
+	}
+}
@@ -0,0 +1,146 @@
+package mathexpr
+
+import (
+	"errors"
+	"math"
+	"strconv"
+)
+
+// StandardConstValues maps the StandardConstNames to their
+// values.
+var StandardConstValues = map[string]float64{
+	"e":  math.E,
+	"pi": math.Pi,
+}
+
+// StandardFuncs maps the StandardFuncNames to their
+// implementations.
+var StandardFuncs = map[string]func(float64) float64{
+	"sin": math.Sin,
+	"cos": math.Cos,
+	"tan": math.Tan,
+	"exp": math.Exp,
+	"ln":  math.Log,
+}
+
+// Evaluate computes the numerical value of an expression.
+//
+// Variables are looked up in vars, falling back on the
+// StandardConstValues.
+// Functions must be in StandardFuncs and take exactly one
+// argument.
+func Evaluate(n Node, vars map[string]float64) (float64, error) {
+	switch n := n.(type) {
+	case *BinaryOp:
+		left, err := Evaluate(n.Left, vars)
+		if err != nil {
+			return 0, err
+		}
+		right, err := Evaluate(n.Right, vars)
+		if err != nil {
+			return 0, err
+		}
+		switch n.Op {
+		case AddOp:
+			return left + right, nil
+		case SubtractOp:
+			return left - right, nil
+		case MultiplyOp:
+			return left * right, nil
+		case DivideOp:
+			return left / right, nil
+		case PowOp:
+			return math.Pow(left, right), nil
+		}
+		return 0, errors.New("unknown operator: " + n.Op)
+	case *NegOp:
+		val, err := Evaluate(n.Node, vars)
+		return -val, err
+	case *FuncOp:
+		f, ok := StandardFuncs[n.Name]
+		if !ok {
+			return 0, errors.New("unknown function: " + n.Name)
+		} else if len(n.Args) != 1 {
+			return 0, errors.New("expected one argument to " + n.Name)
+		}
+		arg, err := Evaluate(n.Args[0], vars)
+		if err != nil {
+			return 0, err
+		}
+		return f(arg), nil
+	case RawNode:
+		if val, ok := vars[string(n)]; ok {
+			return val, nil
+		} else if val, ok := StandardConstValues[string(n)]; ok {
+			return val, nil
+		}
+		val, err := strconv.ParseFloat(string(n), 64)
+		if err != nil {
+			return 0, errors.New("unknown variable: " + string(n))
+		}
+		return val, nil
+	}
+	return 0, errors.New("unsupported node: " + n.String())
+}
+
+// NumericallyEquivalent checks if two expressions have the
+// same value at a fixed set of pseudo-random points.
+//
+// Every variable which is not in StandardConstValues is
+// assigned a value at each point.
+// Values must agree to a relative tolerance of 1e-6, and
+// both expressions must be undefined (NaN) at the same
+// points.
+// Expressions which cannot be evaluated are never
+// equivalent.
+func NumericallyEquivalent(n1, n2 Node) bool {
+	var varNames []string
+	seen := map[string]bool{}
+	var findVars func(n Node)
+	findVars = func(n Node) {
+		if raw, ok := n.(RawNode); ok {
+			name := string(raw)
+			_, isConst := StandardConstValues[name]
+			_, err := strconv.ParseFloat(name, 64)
+			if !isConst && err != nil && !seen[name] {
+				seen[name] = true
+				varNames = append(varNames, name)
+			}
+		}
+		for _, child := range n.Children() {
+			findVars(child)
+		}
+	}
+	findVars(n1)
+	findVars(n2)
+
+	const numPoints = 8
+	for i := 0; i < numPoints; i++ {
+		vars := map[string]float64{}
+		for j, name := range varNames {
+			// Irregular values avoid coincidences at simple
+			// points like 0 and 1.
+			vars[name] = math.Sin(float64(i*7+j*13+1)) * 3.7
+		}
+		x, err := Evaluate(n1, vars)
+		if err != nil {
+			return false
+		}
+		y, err := Evaluate(n2, vars)
+		if err != nil {
+			return false
+		}
+		if math.IsNaN(x) || math.IsNaN(y) {
+			if math.IsNaN(x) != math.IsNaN(y) {
+				return false
+			}
+		} else if math.IsInf(x, 0) || math.IsInf(y, 0) {
+			if x != y {
+				return false
+			}
+		} else if math.Abs(x-y) > 1e-6*math.Max(1, math.Max(math.Abs(x), math.Abs(y))) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,32 @@
+package mathexpr
+
+import "testing"
+
+func TestNumericallyEquivalent(t *testing.T) {
+	cases := []struct {
+		A          string
+		B          string
+		Equivalent bool
+	}{
+		{"(x+1)^2", "x^2+2*x+1", true},
+		{"x*y", "y*x", true},
+		{"sin(x)^2+cos(x)^2", "1", true},
+		{"2*pi", "pi+pi", true},
+		{"x+y", "x-y", false},
+		{"x^2", "x^3", false},
+		{"x", "x+0.001", false},
+	}
+	for _, c := range cases {
+		a, err := ParseString(c.A)
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := ParseString(c.B)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if actual := NumericallyEquivalent(a, b); actual != c.Equivalent {
+			t.Errorf("%s vs %s: expected %v but got %v", c.A, c.B, c.Equivalent, actual)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package mathexpr
+
+import (
+	"math"
+	"strconv"
+)
+
+// ConstantFold simplifies an expression by evaluating the
+// sub-expressions which only involve numbers.
+//
+// Chains of multiplications are also simplified, so that
+// all of the numerical factors in a chain are multiplied
+// together into a single leading factor.
+// For example, "(x*2)*(y*3)" becomes "(6*x)*y", and any
+// chain with a zero factor becomes "0".
+//
+// The original expression may be modified.
+func ConstantFold(n Node) Node {
+	if b, ok := n.(*BinaryOp); ok && b.Op == MultiplyOp {
+		return foldProduct(b)
+	}
+	for i, child := range n.Children() {
+		n.SetChild(i, ConstantFold(child))
+	}
+	switch n := n.(type) {
+	case *BinaryOp:
+		left, leftOk := numericValue(n.Left)
+		right, rightOk := numericValue(n.Right)
+		if !leftOk || !rightOk {
+			return n
+		}
+		var res float64
+		switch n.Op {
+		case AddOp:
+			res = left + right
+		case SubtractOp:
+			res = left - right
+		case DivideOp:
+			// Only fold exact divisions to avoid introducing
+			// long decimals.
+			if right == 0 || left/right != math.Trunc(left/right) {
+				return n
+			}
+			res = left / right
+		case PowOp:
+			res = math.Pow(left, right)
+			if math.IsInf(res, 0) || math.IsNaN(res) {
+				return n
+			}
+		default:
+			return n
+		}
+		return numericNode(res)
+	case *NegOp:
+		if val, ok := numericValue(n.Node); ok {
+			return numericNode(-val)
+		}
+	}
+	return n
+}
+
+func foldProduct(b *BinaryOp) Node {
+	product := 1.0
+	var others []Node
+	var gather func(n Node)
+	gather = func(n Node) {
+		if b, ok := n.(*BinaryOp); ok && b.Op == MultiplyOp {
+			gather(b.Left)
+			gather(b.Right)
+			return
+		}
+		n = ConstantFold(n)
+		if val, ok := numericValue(n); ok {
+			product *= val
+		} else {
+			others = append(others, n)
+		}
+	}
+	gather(b)
+	if product == 0 {
+		return numericNode(0)
+	}
+	res := others
+	if product != 1 || len(others) == 0 {
+		res = append([]Node{numericNode(product)}, others...)
+	}
+	node := res[0]
+	for _, x := range res[1:] {
+		node = &BinaryOp{Op: MultiplyOp, Left: node, Right: x}
+	}
+	return node
+}
+
+// numericValue gets the value of a number, which is either
+// a numerical RawNode or a negated numerical RawNode.
+func numericValue(n Node) (float64, bool) {
+	switch n := n.(type) {
+	case RawNode:
+		val, err := strconv.ParseFloat(string(n), 64)
+		return val, err == nil
+	case *NegOp:
+		if raw, ok := n.Node.(RawNode); ok {
+			val, err := strconv.ParseFloat(string(raw), 64)
+			return -val, err == nil
+		}
+	}
+	return 0, false
+}
+
+func numericNode(val float64) Node {
+	if val < 0 {
+		return &NegOp{Node: numericNode(-val)}
+	}
+	return RawNode(strconv.FormatFloat(val, 'f', -1, 64))
+}
@@ -0,0 +1,55 @@
+package mathexpr
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConstantFold(t *testing.T) {
+	inputs := []Node{
+		&BinaryOp{Left: RawNode("2"), Right: RawNode("3"), Op: "+"},
+		&BinaryOp{
+			Left:  &BinaryOp{Left: RawNode("x"), Right: RawNode("2"), Op: "*"},
+			Right: &BinaryOp{Left: RawNode("y"), Right: RawNode("3"), Op: "*"},
+			Op:    "*",
+		},
+		&BinaryOp{Left: RawNode("2"), Right: RawNode("5"), Op: "-"},
+		&BinaryOp{Left: RawNode("3"), Right: RawNode("2"), Op: "/"},
+		&BinaryOp{Left: RawNode("6"), Right: RawNode("2"), Op: "/"},
+		&FuncOp{Name: "sin", Args: []Node{
+			&BinaryOp{Left: RawNode("1"), Right: RawNode("x"), Op: "*"},
+		}},
+	}
+	expected := []string{"5", "(6*x)*y", "-3", "3/2", "3", "sin(x)"}
+	for i, input := range inputs {
+		actual := ConstantFold(input).String()
+		if actual != expected[i] {
+			t.Errorf("case %d: expected %s but got %s", i, expected[i], actual)
+		}
+	}
+}
+
+func TestConstantFoldEquivalence(t *testing.T) {
+	gen := &Generator{VarNames: []string{"x", "y"}}
+	vars := map[string]float64{"x": 0.3, "y": 1.7}
+	for i := 0; i < 100; i++ {
+		expr := gen.Generate(4)
+		exprStr := expr.String()
+		expected, err := Evaluate(expr, vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.IsNaN(expected) || math.IsInf(expected, 0) {
+			continue
+		}
+		folded := ConstantFold(expr)
+		actual, err := Evaluate(folded, vars)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.Abs(actual-expected) > 1e-5*math.Max(1, math.Abs(expected)) {
+			t.Errorf("%s: expected %f but got %f (from %s)", exprStr, expected, actual,
+				folded)
+		}
+	}
+}
@@ -29,6 +29,11 @@ type Generator struct {
 	// If this is 0, DefaultGeneratorStddev is used.
 	Stddev float64
 
+	// Numbers, if non-nil, specifies how to generate
+	// numerical constants.
+	// It overrides NoReals and Stddev.
+	Numbers *NumberSpec
+
 	// FuncNames stores the allowed function names.
 	FuncNames []string
 
@@ -37,17 +42,24 @@ type Generator struct {
 
 	// VarNames stores the allowed variable names.
 	VarNames []string
+
+	// Rand, if non-nil, is the source of randomness.
+	// By default, the global math/rand source is used.
+	//
+	// Unlike the global source, a Rand is not safe for
+	// concurrent use.
+	Rand *rand.Rand
 }
 
 // Generate generates a random node with a given maximum
 // nesting depth.
 // If maxDepth is 0, the result must have no children.
 func (g *Generator) Generate(maxDepth int) Node {
-	if maxDepth == 0 || rand.Intn(maxDepth+1) == 0 {
+	if maxDepth == 0 || g.source().Intn(maxDepth+1) == 0 {
 		return g.randomRawNode()
-	} else if len(g.FuncNames) != 0 && rand.Intn(3) == 0 {
+	} else if len(g.FuncNames) != 0 && g.source().Intn(3) == 0 {
 		return g.randomFuncOp(maxDepth)
-	} else if rand.Intn(2) == 0 {
+	} else if g.source().Intn(2) == 0 {
 		return g.randomBinaryOp(maxDepth)
 	} else {
 		return g.randomNegOp(maxDepth)
@@ -59,7 +71,7 @@ func (g *Generator) randomNegOp(maxDepth int) *NegOp {
 }
 
 func (g *Generator) randomFuncOp(maxDepth int) *FuncOp {
-	f := g.FuncNames[rand.Intn(len(g.FuncNames))]
+	f := g.FuncNames[g.source().Intn(len(g.FuncNames))]
 	return &FuncOp{
 		Name: f,
 		Args: []Node{g.Generate(maxDepth - 1)},
@@ -68,7 +80,7 @@ func (g *Generator) randomFuncOp(maxDepth int) *FuncOp {
 
 func (g *Generator) randomBinaryOp(maxDepth int) *BinaryOp {
 	ops := []string{MultiplyOp, DivideOp, SubtractOp, AddOp, PowOp}
-	op := ops[rand.Intn(len(ops))]
+	op := ops[g.source().Intn(len(ops))]
 	return &BinaryOp{
 		Op:    op,
 		Left:  g.Generate(maxDepth - 1),
@@ -76,29 +88,67 @@ func (g *Generator) randomBinaryOp(maxDepth int) *BinaryOp {
 	}
 }
 
-func (g *Generator) randomRawNode() RawNode {
-	options := []RawNode{}
+func (g *Generator) randomRawNode() Node {
+	options := []Node{}
 	if len(g.ConstNames) > 0 {
-		idx := rand.Intn(len(g.ConstNames))
+		idx := g.source().Intn(len(g.ConstNames))
 		options = append(options, RawNode(g.ConstNames[idx]))
 	}
 	if len(g.VarNames) > 0 {
-		idx := rand.Intn(len(g.VarNames))
+		idx := g.source().Intn(len(g.VarNames))
 		options = append(options, RawNode(g.VarNames[idx]))
 	}
 	options = append(options, g.randomNumNode())
-	return options[rand.Intn(len(options))]
+	return options[g.source().Intn(len(options))]
 }
 
-func (g *Generator) randomNumNode() RawNode {
+func (g *Generator) randomNumNode() Node {
+	if g.Numbers != nil {
+		return g.Numbers.GenerateRand(g.Rand)
+	}
 	s := g.Stddev
 	if s == 0 {
 		s = DefaultGeneratorStddev
 	}
-	num := math.Abs(rand.NormFloat64() * s)
+	num := math.Abs(g.source().NormFloat64() * s)
 	if g.NoReals {
 		return RawNode(strconv.Itoa(int(num + 0.5)))
 	} else {
 		return RawNode(strconv.FormatFloat(num, 'f', -1, 64))
 	}
 }
+
+func (g *Generator) source() randSource {
+	return sourceOrGlobal(g.Rand)
+}
+
+// randSource is the part of *rand.Rand used to generate
+// expressions.
+type randSource interface {
+	Intn(n int) int
+	Float64() float64
+	NormFloat64() float64
+}
+
+// globalRand is a randSource which uses the global
+// math/rand source.
+type globalRand struct{}
+
+func (globalRand) Intn(n int) int {
+	return rand.Intn(n)
+}
+
+func (globalRand) Float64() float64 {
+	return rand.Float64()
+}
+
+func (globalRand) NormFloat64() float64 {
+	return rand.NormFloat64()
+}
+
+func sourceOrGlobal(rng *rand.Rand) randSource {
+	if rng == nil {
+		return globalRand{}
+	}
+	return rng
+}
@@ -0,0 +1,28 @@
+package mathexpr
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestGeneratorRand(t *testing.T) {
+	newGen := func(numbers *NumberSpec) *Generator {
+		return &Generator{
+			Numbers:   numbers,
+			FuncNames: StandardFuncNames,
+			VarNames:  []string{"x", "y"},
+			Rand:      rand.New(rand.NewSource(1337)),
+		}
+	}
+	for _, numbers := range []*NumberSpec{nil, {Min: 1, Max: 9, AllowNegative: true}} {
+		gen1, gen2 := newGen(numbers), newGen(numbers)
+		for i := 0; i < 100; i++ {
+			expr1 := gen1.Generate(4)
+			// Draws from the global source should not matter.
+			rand.Int63()
+			if expr2 := gen2.Generate(4); expr1.String() != expr2.String() {
+				t.Fatalf("expressions differ: %s and %s", expr1, expr2)
+			}
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package mathexpr
+
+import (
+	"fmt"
+	"hash"
+	"hash/fnv"
+)
+
+// HashNode computes a 64-bit FNV-1a hash of an
+// expression's tree.
+//
+// The hash only depends on the structure of the tree and
+// the contents of its nodes, so it is the same for copies
+// of an expression and across runs of a program.
+// Trees which print the same way may still have different
+// hashes, e.g. RawNode("-1") and a NegOp of RawNode("1").
+func HashNode(n Node) uint64 {
+	h := fnv.New64a()
+	hashNode(h, n)
+	return h.Sum64()
+}
+
+// hashNode writes the nodes of a tree in prefix order.
+// Each node is written as its type, its label, and its
+// number of children, so the encoding is unambiguous.
+func hashNode(h hash.Hash64, n Node) {
+	var typeName, label string
+	switch n := n.(type) {
+	case RawNode:
+		typeName, label = "RawNode", string(n)
+	case *BinaryOp:
+		typeName, label = "BinaryOp", n.Op
+	case *NegOp:
+		typeName = "NegOp"
+	case *FuncOp:
+		typeName, label = "FuncOp", n.Name
+	default:
+		typeName, label = fmt.Sprintf("%T", n), n.String()
+	}
+	children := n.Children()
+	fmt.Fprintf(h, "%s\x00%s\x00%d\x00", typeName, label, len(children))
+	for _, child := range children {
+		hashNode(h, child)
+	}
+}
@@ -0,0 +1,55 @@
+package mathexpr
+
+import "testing"
+
+func TestHashNodeClone(t *testing.T) {
+	gen := &Generator{
+		VarNames:  []string{"x", "y"},
+		FuncNames: StandardFuncNames,
+	}
+	for i := 0; i < 100; i++ {
+		expr := gen.Generate(4)
+		if HashNode(expr) != HashNode(Clone(expr)) {
+			t.Fatalf("clone of %s has a different hash", expr)
+		}
+	}
+}
+
+func TestHashNodeCollisions(t *testing.T) {
+	gen := &Generator{
+		VarNames:  []string{"x", "y"},
+		FuncNames: StandardFuncNames,
+	}
+	trees := map[uint64]string{}
+	for i := 0; i < 10000; i++ {
+		expr := gen.Generate(4)
+		tree := PrettyPrintTree(expr, " ")
+		hash := HashNode(expr)
+		if other, ok := trees[hash]; ok && other != tree {
+			t.Fatalf("hash collision:\n%s\n%s", other, tree)
+		}
+		trees[hash] = tree
+	}
+
+	// These trees print the same way.
+	if HashNode(RawNode("-1")) == HashNode(&NegOp{Node: RawNode("1")}) {
+		t.Error("hash does not distinguish node types")
+	}
+	if HashNode(&FuncOp{Name: "sin", Args: []Node{RawNode("x")}}) ==
+		HashNode(&FuncOp{Name: "sin", Args: []Node{RawNode("x"), RawNode("x")}}) {
+		t.Error("hash does not distinguish argument counts")
+	}
+}
+
+func TestHashNodeStable(t *testing.T) {
+	// The expected value must never change, since hashes
+	// may be stored between runs.
+	expr := &BinaryOp{
+		Op:    AddOp,
+		Left:  &FuncOp{Name: "sin", Args: []Node{RawNode("x")}},
+		Right: &NegOp{Node: RawNode("2")},
+	}
+	if actual := HashNode(expr); actual != 0x9e5a70afae9e5e42 {
+		t.Errorf("unexpected hash: %#x", actual)
+	}
+}
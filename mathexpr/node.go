@@ -33,3 +33,34 @@ type Node interface {
 	// SetChild updates the n-th child.
 	SetChild(n int, c Node)
 }
+
+// Complexity counts the nodes in an expression.
+func Complexity(n Node) int {
+	res := 1
+	for _, child := range n.Children() {
+		res += Complexity(child)
+	}
+	return res
+}
+
+// Clone creates a deep copy of an expression.
+//
+// It panics if the expression contains a node type which
+// is not defined in this package.
+func Clone(n Node) Node {
+	switch n := n.(type) {
+	case RawNode:
+		return n
+	case *NegOp:
+		return &NegOp{Node: Clone(n.Node)}
+	case *BinaryOp:
+		return &BinaryOp{Op: n.Op, Left: Clone(n.Left), Right: Clone(n.Right)}
+	case *FuncOp:
+		args := make([]Node, len(n.Args))
+		for i, x := range n.Args {
+			args[i] = Clone(x)
+		}
+		return &FuncOp{Name: n.Name, Args: args}
+	}
+	panic("cannot clone: " + n.String())
+}
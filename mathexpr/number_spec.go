@@ -0,0 +1,55 @@
+package mathexpr
+
+import (
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// A NumberSpec describes how to generate random numerical
+// constants.
+type NumberSpec struct {
+	// Min and Max specify the inclusive range of magnitudes
+	// for generated numbers.
+	Min int
+	Max int
+
+	// DecimalPlaces is the number of digits which follow
+	// the decimal point.
+	// If this is 0, all numbers are integers.
+	DecimalPlaces int
+
+	// IntegerProb is the probability of generating an
+	// integer even if DecimalPlaces is non-zero.
+	// This can be used to mix integers and decimals.
+	IntegerProb float64
+
+	// AllowNegative indicates that numbers may be negated.
+	// Negative numbers are represented as a NegOp.
+	AllowNegative bool
+}
+
+// Generate generates a random number.
+// The result is a RawNode, or a NegOp if the number is
+// negative.
+func (n *NumberSpec) Generate() Node {
+	return n.GenerateRand(nil)
+}
+
+// GenerateRand is like Generate, but it uses rng as the
+// source of randomness.
+// If rng is nil, the global math/rand source is used.
+func (n *NumberSpec) GenerateRand(rng *rand.Rand) Node {
+	r := sourceOrGlobal(rng)
+	num := float64(n.Min) + r.Float64()*float64(n.Max-n.Min)
+	var res RawNode
+	if n.DecimalPlaces == 0 || r.Float64() < n.IntegerProb {
+		res = RawNode(strconv.Itoa(int(math.Floor(num + 0.5))))
+	} else {
+		res = RawNode(strconv.FormatFloat(num, 'f', n.DecimalPlaces, 64))
+	}
+	if n.AllowNegative && r.Intn(2) == 0 {
+		return &NegOp{Node: res}
+	}
+	return res
+}
@@ -0,0 +1,53 @@
+package mathexpr
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestNumberSpecIntegers(t *testing.T) {
+	spec := &NumberSpec{Min: 3, Max: 7}
+	for i := 0; i < 1000; i++ {
+		node := spec.Generate()
+		raw, ok := node.(RawNode)
+		if !ok {
+			t.Fatalf("unexpected node: %s", node)
+		}
+		num, err := strconv.Atoi(string(raw))
+		if err != nil {
+			t.Fatalf("not an integer: %s", raw)
+		}
+		if num < 3 || num > 7 {
+			t.Fatalf("out of range: %d", num)
+		}
+	}
+}
+
+func TestNumberSpecDecimals(t *testing.T) {
+	spec := &NumberSpec{Min: 0, Max: 10, DecimalPlaces: 2, IntegerProb: 0.5,
+		AllowNegative: true}
+	var numInts, numNeg int
+	for i := 0; i < 1000; i++ {
+		node := spec.Generate()
+		if neg, ok := node.(*NegOp); ok {
+			numNeg++
+			node = neg.Node
+		}
+		str := string(node.(RawNode))
+		if idx := strings.Index(str, "."); idx < 0 {
+			numInts++
+		} else if len(str)-idx != 3 {
+			t.Fatalf("unexpected decimals: %s", str)
+		}
+		if num, _ := strconv.ParseFloat(str, 64); num < 0 || num > 10 {
+			t.Fatalf("out of range: %s", str)
+		}
+	}
+	if numInts < 400 || numInts > 600 {
+		t.Errorf("unexpected integer count: %d", numInts)
+	}
+	if numNeg < 400 || numNeg > 600 {
+		t.Errorf("unexpected negative count: %d", numNeg)
+	}
+}
@@ -0,0 +1,206 @@
+package mathexpr
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// ParseString parses an expression in the format produced
+// by Node.String.
+//
+// Binary operators are left-associative, except for "^",
+// which is right-associative.
+// Negation binds more loosely than "^", so "-x^2" is the
+// negation of "x^2".
+func ParseString(s string) (Node, error) {
+	p := &parser{input: []rune(s)}
+	res, err := p.parseSum()
+	if err == nil {
+		p.skipSpace()
+		if p.pos < len(p.input) {
+			err = fmt.Errorf("unexpected %q at offset %d", p.input[p.pos], p.pos)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse %q: %s", s, err)
+	}
+	return res, nil
+}
+
+// CommutativeEqual checks if two expressions are equal up
+// to the order of the terms in sums and the factors in
+// products.
+//
+// For example, "(x+2)*(x+3)" is commutatively equal to
+// "(3+x)*(x+2)".
+func CommutativeEqual(n1, n2 Node) bool {
+	return commutativeString(n1) == commutativeString(n2)
+}
+
+func commutativeString(n Node) string {
+	switch n := n.(type) {
+	case *BinaryOp:
+		if n.Op == AddOp || n.Op == MultiplyOp {
+			var operands []string
+			var gather func(n Node)
+			gather = func(child Node) {
+				if b, ok := child.(*BinaryOp); ok && b.Op == n.Op {
+					gather(b.Left)
+					gather(b.Right)
+				} else {
+					operands = append(operands, commutativeString(child))
+				}
+			}
+			gather(n)
+			sort.Strings(operands)
+			return n.Op + "(" + strings.Join(operands, ",") + ")"
+		}
+		return n.Op + "(" + commutativeString(n.Left) + "," +
+			commutativeString(n.Right) + ")"
+	case *NegOp:
+		return "-(" + commutativeString(n.Node) + ")"
+	case *FuncOp:
+		args := make([]string, len(n.Args))
+		for i, x := range n.Args {
+			args[i] = commutativeString(x)
+		}
+		return n.Name + "(" + strings.Join(args, ",") + ")"
+	}
+	return n.String()
+}
+
+type parser struct {
+	input []rune
+	pos   int
+}
+
+func (p *parser) parseSum() (Node, error) {
+	res, err := p.parseProduct()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume('+') || p.consume('-') {
+		op := string(p.input[p.pos-1])
+		right, err := p.parseProduct()
+		if err != nil {
+			return nil, err
+		}
+		res = &BinaryOp{Op: op, Left: res, Right: right}
+	}
+	return res, nil
+}
+
+func (p *parser) parseProduct() (Node, error) {
+	res, err := p.parseNeg()
+	if err != nil {
+		return nil, err
+	}
+	for p.consume('*') || p.consume('/') {
+		op := string(p.input[p.pos-1])
+		right, err := p.parseNeg()
+		if err != nil {
+			return nil, err
+		}
+		res = &BinaryOp{Op: op, Left: res, Right: right}
+	}
+	return res, nil
+}
+
+func (p *parser) parseNeg() (Node, error) {
+	if p.consume('-') {
+		n, err := p.parseNeg()
+		if err != nil {
+			return nil, err
+		}
+		return &NegOp{Node: n}, nil
+	}
+	return p.parsePow()
+}
+
+func (p *parser) parsePow() (Node, error) {
+	base, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	if p.consume('^') {
+		exponent, err := p.parseNeg()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryOp{Op: PowOp, Left: base, Right: exponent}, nil
+	}
+	return base, nil
+}
+
+func (p *parser) parseAtom() (Node, error) {
+	p.skipSpace()
+	if p.pos >= len(p.input) {
+		return nil, errors.New("unexpected end of input")
+	}
+	if p.consume('(') {
+		res, err := p.parseSum()
+		if err != nil {
+			return nil, err
+		}
+		if !p.consume(')') {
+			return nil, p.expected(")")
+		}
+		return res, nil
+	}
+	start := p.pos
+	r := p.input[p.pos]
+	if unicode.IsDigit(r) || r == '.' {
+		for p.pos < len(p.input) && (unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '.') {
+			p.pos++
+		}
+		return RawNode(p.input[start:p.pos]), nil
+	} else if unicode.IsLetter(r) || r == '_' {
+		for p.pos < len(p.input) && (unicode.IsLetter(p.input[p.pos]) ||
+			unicode.IsDigit(p.input[p.pos]) || p.input[p.pos] == '_') {
+			p.pos++
+		}
+		name := string(p.input[start:p.pos])
+		if !p.consume('(') {
+			return RawNode(name), nil
+		}
+		res := &FuncOp{Name: name}
+		for {
+			arg, err := p.parseSum()
+			if err != nil {
+				return nil, err
+			}
+			res.Args = append(res.Args, arg)
+			if p.consume(')') {
+				return res, nil
+			} else if !p.consume(',') {
+				return nil, p.expected(", or )")
+			}
+		}
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", r, p.pos)
+}
+
+func (p *parser) consume(r rune) bool {
+	p.skipSpace()
+	if p.pos < len(p.input) && p.input[p.pos] == r {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.input) && unicode.IsSpace(p.input[p.pos]) {
+		p.pos++
+	}
+}
+
+func (p *parser) expected(s string) error {
+	if p.pos >= len(p.input) {
+		return fmt.Errorf("expected %s at end of input", s)
+	}
+	return fmt.Errorf("expected %s at offset %d", s, p.pos)
+}
@@ -0,0 +1,69 @@
+package mathexpr
+
+import "testing"
+
+func TestParseStringRoundTrip(t *testing.T) {
+	gen := &Generator{
+		VarNames:   []string{"x", "y"},
+		ConstNames: StandardConstNames,
+		FuncNames:  StandardFuncNames,
+		Numbers:    &NumberSpec{Max: 20, AllowNegative: true, DecimalPlaces: 1},
+	}
+	for i := 0; i < 1000; i++ {
+		expr := gen.Generate(4)
+		parsed, err := ParseString(expr.String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if parsed.String() != expr.String() {
+			t.Fatalf("expected %s but got %s", expr, parsed)
+		}
+	}
+}
+
+func TestParseString(t *testing.T) {
+	cases := map[string]string{
+		"1+2*3":     "1+2*3",
+		"1 - 2 - 3": "(1-2)-3",
+		"-x^2":      "-x^2",
+		"2^3^4":     "2^(3^4)",
+		"f(x, y+1)": "f(x, y+1)",
+		"((x))":     "x",
+	}
+	for input, expected := range cases {
+		parsed, err := ParseString(input)
+		if err != nil {
+			t.Errorf("%s: %s", input, err)
+		} else if parsed.String() != expected {
+			t.Errorf("%s: expected %s but got %s", input, expected, parsed)
+		}
+	}
+	for _, bad := range []string{"", "1+", "(x", "x)", "f(x", "2 $ 3"} {
+		if _, err := ParseString(bad); err == nil {
+			t.Errorf("%q: expected error", bad)
+		}
+	}
+}
+
+func TestCommutativeEqual(t *testing.T) {
+	cases := []struct {
+		s1, s2 string
+		equal  bool
+	}{
+		{"(x+2)*(x+3)", "(x+3)*(x+2)", true},
+		{"(x+2)*(x+3)", "(3+x)*(2+x)", true},
+		{"(x*y)*z", "x*(z*y)", true},
+		{"x-2", "2-x", false},
+		{"x+2", "x*2", false},
+	}
+	for _, c := range cases {
+		n1, err1 := ParseString(c.s1)
+		n2, err2 := ParseString(c.s2)
+		if err1 != nil || err2 != nil {
+			t.Fatal(err1, err2)
+		}
+		if CommutativeEqual(n1, n2) != c.equal {
+			t.Errorf("%s vs %s: expected %v", c.s1, c.s2, c.equal)
+		}
+	}
+}
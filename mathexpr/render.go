@@ -0,0 +1,145 @@
+package mathexpr
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// RenderOptions controls the symbols used to render an
+// expression.
+//
+// Empty fields use the symbols from Node.String.
+type RenderOptions struct {
+	Multiply string
+	Divide   string
+	Add      string
+	Subtract string
+	Pow      string
+	Negate   string
+
+	// If ImplicitMultiply is set, products are written by
+	// juxtaposition (e.g. "2x" or "x(y+1)") whenever the
+	// right operand starts with a parenthesis, or the left
+	// operand is a number and the right operand starts
+	// with a letter.
+	// Other products, such as "x*y", still use the
+	// Multiply symbol, since "xy" would read as a single
+	// variable.
+	ImplicitMultiply bool
+}
+
+// Render is like n.String(), but it uses the symbols from
+// opts.
+// If opts is nil, the result is equal to n.String().
+func Render(n Node, opts *RenderOptions) string {
+	if opts == nil {
+		opts = &RenderOptions{}
+	}
+	switch n := n.(type) {
+	case *BinaryOp:
+		left := Render(n.Left, opts)
+		right := Render(n.Right, opts)
+		if n.Left.Precedence() <= n.Precedence() {
+			left = "(" + left + ")"
+		}
+		if n.Right.Precedence() <= n.Precedence() {
+			right = "(" + right + ")"
+		}
+		if n.Op == MultiplyOp && opts.ImplicitMultiply && implicitProduct(n.Left, right) {
+			return left + right
+		}
+		return left + opts.symbol(n.Op) + right
+	case *NegOp:
+		inner := Render(n.Node, opts)
+		if n.Node.Precedence() <= NegPrecedence {
+			inner = "(" + inner + ")"
+		}
+		return opts.symbol("neg") + inner
+	case *FuncOp:
+		args := make([]string, len(n.Args))
+		for i, x := range n.Args {
+			args[i] = Render(x, opts)
+		}
+		return n.Name + "(" + strings.Join(args, ", ") + ")"
+	}
+	return n.String()
+}
+
+func (r *RenderOptions) symbol(op string) string {
+	var res string
+	switch op {
+	case MultiplyOp:
+		res = r.Multiply
+	case DivideOp:
+		res = r.Divide
+	case AddOp:
+		res = r.Add
+	case SubtractOp:
+		res = r.Subtract
+	case PowOp:
+		res = r.Pow
+	case "neg":
+		res = r.Negate
+		op = "-"
+	}
+	if res == "" {
+		return op
+	}
+	return res
+}
+
+// implicitProduct checks if the multiplication sign can be
+// omitted between a left operand and a rendered right
+// operand.
+func implicitProduct(left Node, right string) bool {
+	for _, r := range right {
+		if r == '(' {
+			return true
+		}
+		raw, ok := left.(RawNode)
+		if !ok || !unicode.IsLetter(r) {
+			return false
+		}
+		_, err := strconv.ParseFloat(string(raw), 64)
+		return err == nil
+	}
+	return false
+}
+
+// PrettyPrintTree renders an expression's tree with one
+// node per line, indenting each child by one more copy of
+// indent than its parent.
+//
+// For example, "x+y" is rendered with an indent of "  " as
+//
+//	BinaryOp(+)
+//	  RawNode(x)
+//	  RawNode(y)
+func PrettyPrintTree(n Node, indent string) string {
+	var lines []string
+	prettyPrintLines(n, "", indent, &lines)
+	return strings.Join(lines, "\n")
+}
+
+func prettyPrintLines(n Node, prefix, indent string, lines *[]string) {
+	*lines = append(*lines, prefix+prettyPrintLabel(n))
+	for _, child := range n.Children() {
+		prettyPrintLines(child, prefix+indent, indent, lines)
+	}
+}
+
+func prettyPrintLabel(n Node) string {
+	switch n := n.(type) {
+	case *BinaryOp:
+		return "BinaryOp(" + n.Op + ")"
+	case *NegOp:
+		return "NegOp"
+	case *FuncOp:
+		return "FuncOp(" + n.Name + ")"
+	case RawNode:
+		return "RawNode(" + string(n) + ")"
+	}
+	return fmt.Sprintf("%T(%s)", n, n)
+}
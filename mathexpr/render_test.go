@@ -0,0 +1,108 @@
+package mathexpr
+
+import "testing"
+
+func TestRender(t *testing.T) {
+	// 2*x/(y-3)+-z
+	expr := &BinaryOp{
+		Op: AddOp,
+		Left: &BinaryOp{
+			Op:    DivideOp,
+			Left:  &BinaryOp{Op: MultiplyOp, Left: RawNode("2"), Right: RawNode("x")},
+			Right: &BinaryOp{Op: SubtractOp, Left: RawNode("y"), Right: RawNode("3")},
+		},
+		Right: &NegOp{Node: &BinaryOp{
+			Op:    MultiplyOp,
+			Left:  RawNode("z"),
+			Right: RawNode("4"),
+		}},
+	}
+	cases := []struct {
+		opts     *RenderOptions
+		expected string
+	}{
+		{nil, expr.String()},
+		{&RenderOptions{}, expr.String()},
+		{&RenderOptions{Multiply: "·", Divide: "÷"}, "(2·x)÷(y-3)+-(z·4)"},
+		{&RenderOptions{ImplicitMultiply: true}, "(2x)/(y-3)+-(z*4)"},
+		{&RenderOptions{Add: " + ", Subtract: " - ", Negate: "−"}, "(2*x)/(y - 3) + −(z*4)"},
+	}
+	for i, c := range cases {
+		if actual := Render(expr, c.opts); actual != c.expected {
+			t.Errorf("case %d: expected %s but got %s", i, c.expected, actual)
+		}
+	}
+}
+
+func TestRenderImplicitMultiply(t *testing.T) {
+	product := func(left, right Node) Node {
+		return &BinaryOp{Op: MultiplyOp, Left: left, Right: right}
+	}
+	sum := &BinaryOp{Op: AddOp, Left: RawNode("y"), Right: RawNode("1")}
+	cases := []struct {
+		expr     Node
+		expected string
+	}{
+		{product(RawNode("2"), RawNode("x")), "2x"},
+		{product(RawNode("2.5"), &FuncOp{Name: "sin", Args: []Node{RawNode("x")}}), "2.5sin(x)"},
+		{product(RawNode("x"), sum), "x(y+1)"},
+		{product(RawNode("2"), sum), "2(y+1)"},
+		{product(RawNode("x"), RawNode("y")), "x*y"},
+		{product(RawNode("x"), RawNode("2")), "x*2"},
+		{product(RawNode("2"), RawNode("3")), "2*3"},
+		{product(sum, RawNode("x")), "(y+1)*x"},
+		{product(RawNode("x"), &FuncOp{Name: "cos", Args: []Node{RawNode("y")}}), "x*cos(y)"},
+	}
+	opts := &RenderOptions{ImplicitMultiply: true}
+	for _, c := range cases {
+		if actual := Render(c.expr, opts); actual != c.expected {
+			t.Errorf("%s: expected %s but got %s", c.expr, c.expected, actual)
+		}
+	}
+}
+
+func TestRenderMatchesString(t *testing.T) {
+	gen := &Generator{
+		VarNames:  []string{"x", "y"},
+		FuncNames: StandardFuncNames,
+	}
+	for i := 0; i < 100; i++ {
+		expr := gen.Generate(4)
+		if actual := Render(expr, nil); actual != expr.String() {
+			t.Fatalf("expected %s but got %s", expr, actual)
+		}
+	}
+}
+
+func TestPrettyPrintTree(t *testing.T) {
+	// sin(x)*-(y+2)
+	expr := &BinaryOp{
+		Op:   MultiplyOp,
+		Left: &FuncOp{Name: "sin", Args: []Node{RawNode("x")}},
+		Right: &NegOp{Node: &BinaryOp{
+			Op:    AddOp,
+			Left:  RawNode("y"),
+			Right: RawNode("2"),
+		}},
+	}
+	expected := "BinaryOp(*)\n" +
+		"  FuncOp(sin)\n" +
+		"    RawNode(x)\n" +
+		"  NegOp\n" +
+		"    BinaryOp(+)\n" +
+		"      RawNode(y)\n" +
+		"      RawNode(2)"
+	if actual := PrettyPrintTree(expr, "  "); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	expected = "BinaryOp(-)\n\tRawNode(x)\n\tRawNode(1)"
+	expr1 := &BinaryOp{Op: SubtractOp, Left: RawNode("x"), Right: RawNode("1")}
+	if actual := PrettyPrintTree(expr1, "\t"); actual != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	if actual := PrettyPrintTree(RawNode("x"), "  "); actual != "RawNode(x)" {
+		t.Errorf("unexpected leaf output: %q", actual)
+	}
+}
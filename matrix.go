@@ -0,0 +1,126 @@
+package algebrain
+
+import (
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// DefaultMatrixMaxEntry is the default maximum absolute
+// entry used by a MatrixGenerator.
+const DefaultMatrixMaxEntry = 9
+
+// A MatrixGenerator generates Samples with queries like
+// "determinant of [[1,2],[3,4]]", expecting "Result: -2",
+// or "trace of [[5,0],[1,3]]", expecting "Result: 8".
+//
+// Matrices are written row by row, as in formatMatrix.
+type MatrixGenerator struct {
+	// MaxEntry is the maximum absolute value of each
+	// entry.
+	// If this is 0, DefaultMatrixMaxEntry is used.
+	MaxEntry int
+
+	// Allow3x3 adds determinants of 3x3 matrices, which
+	// are a harder version of the task.
+	// Traces always use 2x2 matrices.
+	Allow3x3 bool
+}
+
+// Generate generates a matrix sample.
+func (m *MatrixGenerator) Generate() *Sample {
+	maxEntry := m.MaxEntry
+	if maxEntry == 0 {
+		maxEntry = DefaultMatrixMaxEntry
+	}
+	size := 2
+	trace := rand.Intn(2) == 0
+	if m.Allow3x3 && !trace && rand.Intn(2) == 0 {
+		size = 3
+	}
+	matrix := make([][]int, size)
+	for i := range matrix {
+		matrix[i] = make([]int, size)
+		for j := range matrix[i] {
+			matrix[i][j] = rand.Intn(2*maxEntry+1) - maxEntry
+		}
+	}
+	if trace {
+		return &Sample{
+			Query:    "trace of " + formatMatrix(matrix),
+			Response: "Result: " + strconv.Itoa(matrixTrace(matrix)),
+		}
+	}
+	return &Sample{
+		Query:    "determinant of " + formatMatrix(matrix),
+		Response: "Result: " + strconv.Itoa(matrixDeterminant(matrix)),
+	}
+}
+
+// formatMatrix writes a matrix as a list of rows, like
+// "[[1,2],[3,4]]".
+func formatMatrix(m [][]int) string {
+	rows := make([]string, len(m))
+	for i, row := range m {
+		entries := make([]string, len(row))
+		for j, x := range row {
+			entries[j] = strconv.Itoa(x)
+		}
+		rows[i] = "[" + strings.Join(entries, ",") + "]"
+	}
+	return "[" + strings.Join(rows, ",") + "]"
+}
+
+// parseMatrix parses a square matrix written by
+// formatMatrix.
+func parseMatrix(s string) ([][]int, error) {
+	if !strings.HasPrefix(s, "[[") || !strings.HasSuffix(s, "]]") {
+		return nil, errors.New("parse matrix: missing brackets")
+	}
+	rowStrs := strings.Split(s[2:len(s)-2], "],[")
+	res := make([][]int, len(rowStrs))
+	for i, rowStr := range rowStrs {
+		entryStrs := strings.Split(rowStr, ",")
+		if len(entryStrs) != len(rowStrs) {
+			return nil, errors.New("parse matrix: matrix is not square")
+		}
+		res[i] = make([]int, len(entryStrs))
+		for j, entryStr := range entryStrs {
+			x, err := strconv.Atoi(entryStr)
+			if err != nil {
+				return nil, errors.New("parse matrix: invalid entry: " + entryStr)
+			}
+			res[i][j] = x
+		}
+	}
+	return res, nil
+}
+
+func matrixTrace(m [][]int) int {
+	var res int
+	for i, row := range m {
+		res += row[i]
+	}
+	return res
+}
+
+// matrixDeterminant computes the determinant of a square
+// matrix by cofactor expansion along the first row.
+func matrixDeterminant(m [][]int) int {
+	if len(m) == 1 {
+		return m[0][0]
+	}
+	var res int
+	sign := 1
+	for col, x := range m[0] {
+		minor := make([][]int, 0, len(m)-1)
+		for _, row := range m[1:] {
+			minorRow := append(append([]int{}, row[:col]...), row[col+1:]...)
+			minor = append(minor, minorRow)
+		}
+		res += sign * x * matrixDeterminant(minor)
+		sign = -sign
+	}
+	return res
+}
@@ -0,0 +1,96 @@
+package algebrain
+
+import (
+	"reflect"
+	"regexp"
+	"strconv"
+	"testing"
+)
+
+func TestMatrixFormatting(t *testing.T) {
+	cases := []struct {
+		Matrix [][]int
+		Str    string
+	}{
+		{[][]int{{1, 2}, {3, 4}}, "[[1,2],[3,4]]"},
+		{[][]int{{-5, 0}, {10, -3}}, "[[-5,0],[10,-3]]"},
+		{[][]int{{1, 0, 2}, {-1, 3, 1}, {0, 4, -2}}, "[[1,0,2],[-1,3,1],[0,4,-2]]"},
+		{[][]int{{7}}, "[[7]]"},
+	}
+	for _, c := range cases {
+		if actual := formatMatrix(c.Matrix); actual != c.Str {
+			t.Errorf("expected %q but got %q", c.Str, actual)
+		}
+		parsed, err := parseMatrix(c.Str)
+		if err != nil {
+			t.Errorf("%s: %s", c.Str, err)
+		} else if !reflect.DeepEqual(parsed, c.Matrix) {
+			t.Errorf("%s: parsed %v", c.Str, parsed)
+		}
+	}
+
+	for _, invalid := range []string{"", "[1,2]", "[[1,2],[3]]", "[[1,2],[3,4],[5,6]]",
+		"[[1,x],[3,4]]", "[[]]", "[[1, 2],[3,4]]"} {
+		if _, err := parseMatrix(invalid); err == nil {
+			t.Errorf("expected error for %q", invalid)
+		}
+	}
+}
+
+func TestMatrixDeterminant(t *testing.T) {
+	cases := []struct {
+		Matrix   [][]int
+		Expected int
+	}{
+		{[][]int{{1, 2}, {3, 4}}, -2},
+		{[][]int{{5, 0}, {1, 3}}, 15},
+		{[][]int{{2, 4}, {1, 2}}, 0},
+		{[][]int{{1, 0, 2}, {-1, 3, 1}, {0, 4, -2}}, -18},
+		{[][]int{{2, 0, 0}, {0, 3, 0}, {0, 0, 4}}, 24},
+	}
+	for _, c := range cases {
+		if actual := matrixDeterminant(c.Matrix); actual != c.Expected {
+			t.Errorf("%v: expected %d but got %d", c.Matrix, c.Expected, actual)
+		}
+	}
+	if actual := matrixTrace([][]int{{5, 0}, {1, 3}}); actual != 8 {
+		t.Errorf("expected trace 8 but got %d", actual)
+	}
+}
+
+func TestMatrixGenerator(t *testing.T) {
+	queryExpr := regexp.MustCompile(`^(determinant|trace) of (\[.*\])$`)
+	gen := &MatrixGenerator{MaxEntry: 3, Allow3x3: true}
+	counts := map[string]int{}
+	for i := 0; i < 1000; i++ {
+		sample := gen.Generate()
+		if err := sample.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		match := queryExpr.FindStringSubmatch(sample.Query)
+		if match == nil {
+			t.Fatalf("unexpected query: %q", sample.Query)
+		}
+		matrix, err := parseMatrix(match[2])
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, row := range matrix {
+			for _, x := range row {
+				if x < -3 || x > 3 {
+					t.Fatalf("entry out of range: %q", sample.Query)
+				}
+			}
+		}
+		if match[1] == "trace" && len(matrix) != 2 {
+			t.Fatalf("unexpected trace size: %q", sample.Query)
+		}
+		counts[match[1]+" "+strconv.Itoa(len(matrix))]++
+		if !VerifySample(sample) {
+			t.Fatalf("incorrect response: %q -> %q", sample.Query, sample.Response)
+		}
+	}
+	if len(counts) != 3 {
+		t.Errorf("expected 2x2 and 3x3 determinants and 2x2 traces, got %v", counts)
+	}
+}
@@ -0,0 +1,149 @@
+package algebrain
+
+import (
+	"encoding/json"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// DefaultLatencyBuckets are the default upper bounds, in
+// seconds, of the latency histogram buckets in HTTPMetrics.
+var DefaultLatencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5,
+	5, 10}
+
+// HTTPMetrics collects operational metrics for an
+// HTTPHandler.
+//
+// To avoid a dependency on a metrics library, HTTPMetrics
+// implements expvar.Var, so it can be exported with
+// expvar.Publish and scraped from /debug/vars.
+// Its String method produces a JSON object with these
+// fields:
+//
+//	requests_total          requests per endpoint
+//	request_latency_seconds latency histogram per endpoint,
+//	                        with cumulative "buckets"
+//	                        keyed by upper bound (or "+Inf"),
+//	                        plus "sum" and "count"
+//	in_flight_requests      requests currently being served
+//	decodes_total           queries which were decoded
+//	decode_steps_total      decoder steps over all decodes
+//	decodes_capped_total    decodes which hit the maximum
+//	                        response length
+//
+// Requests for unknown paths are counted under the
+// endpoint "other".
+type HTTPMetrics struct {
+	// LatencyBuckets are the sorted upper bounds of the
+	// latency histogram, in seconds.
+	// If this is nil, DefaultLatencyBuckets is used.
+	// It should not be changed once requests are recorded.
+	LatencyBuckets []float64
+
+	lock          sync.Mutex
+	requests      map[string]int64
+	latencies     map[string]*latencyHistogram
+	inFlight      int64
+	decodes       int64
+	decodeSteps   int64
+	cappedDecodes int64
+}
+
+type latencyHistogram struct {
+	Counts []int64
+	Sum    float64
+	Count  int64
+}
+
+// String encodes the metrics as a JSON object.
+func (h *HTTPMetrics) String() string {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	bounds := h.bounds()
+	latencies := map[string]interface{}{}
+	for endpoint, hist := range h.latencies {
+		buckets := map[string]int64{}
+		var cumulative int64
+		for i, count := range hist.Counts {
+			cumulative += count
+			key := "+Inf"
+			if i < len(bounds) {
+				key = strconv.FormatFloat(bounds[i], 'g', -1, 64)
+			}
+			buckets[key] = cumulative
+		}
+		latencies[endpoint] = map[string]interface{}{
+			"buckets": buckets,
+			"sum":     hist.Sum,
+			"count":   hist.Count,
+		}
+	}
+	requests := map[string]int64{}
+	for endpoint, count := range h.requests {
+		requests[endpoint] = count
+	}
+	data, _ := json.Marshal(map[string]interface{}{
+		"requests_total":          requests,
+		"request_latency_seconds": latencies,
+		"in_flight_requests":      h.inFlight,
+		"decodes_total":           h.decodes,
+		"decode_steps_total":      h.decodeSteps,
+		"decodes_capped_total":    h.cappedDecodes,
+	})
+	return string(data)
+}
+
+// startRequest records the start of a request and returns
+// a function to call when it finishes.
+func (h *HTTPMetrics) startRequest(endpoint string) func() {
+	start := time.Now()
+	h.lock.Lock()
+	h.inFlight++
+	h.lock.Unlock()
+	return func() {
+		seconds := time.Since(start).Seconds()
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		h.inFlight--
+		if h.requests == nil {
+			h.requests = map[string]int64{}
+			h.latencies = map[string]*latencyHistogram{}
+		}
+		h.requests[endpoint]++
+		bounds := h.bounds()
+		hist, ok := h.latencies[endpoint]
+		if !ok {
+			hist = &latencyHistogram{Counts: make([]int64, len(bounds)+1)}
+			h.latencies[endpoint] = hist
+		}
+		bucket := len(bounds)
+		for i, bound := range bounds {
+			if seconds <= bound {
+				bucket = i
+				break
+			}
+		}
+		hist.Counts[bucket]++
+		hist.Sum += seconds
+		hist.Count++
+	}
+}
+
+// recordDecode records the result of decoding a query.
+func (h *HTTPMetrics) recordDecode(res *decodeResult) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.decodes++
+	h.decodeSteps += int64(res.Steps)
+	if res.Truncated {
+		h.cappedDecodes++
+	}
+}
+
+func (h *HTTPMetrics) bounds() []float64 {
+	if h.LatencyBuckets == nil {
+		return DefaultLatencyBuckets
+	}
+	return h.LatencyBuckets
+}
@@ -0,0 +1,71 @@
+package algebrain
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestHTTPMetrics(t *testing.T) {
+	handler, err := NewHTTPHandler(NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler.Metrics = &HTTPMetrics{LatencyBuckets: []float64{0, 1000}}
+	requests := []struct {
+		method string
+		path   string
+		body   string
+	}{
+		{"POST", "/query", `{"query": "evaluate 1+1"}`},
+		{"POST", "/query", `{"query": "evaluate 2+2"}`},
+		{"POST", "/query", `{"query": "evaluate ∑"}`},
+		{"GET", "/healthz", ""},
+		{"GET", "/foo", ""},
+		{"GET", "/bar", ""},
+	}
+	for _, r := range requests {
+		req := httptest.NewRequest(r.method, r.path, strings.NewReader(r.body))
+		handler.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	var metrics struct {
+		Requests  map[string]int64 `json:"requests_total"`
+		Latencies map[string]struct {
+			Buckets map[string]int64 `json:"buckets"`
+			Sum     float64          `json:"sum"`
+			Count   int64            `json:"count"`
+		} `json:"request_latency_seconds"`
+		InFlight    int64 `json:"in_flight_requests"`
+		Decodes     int64 `json:"decodes_total"`
+		DecodeSteps int64 `json:"decode_steps_total"`
+		Capped      int64 `json:"decodes_capped_total"`
+	}
+	if err := json.Unmarshal([]byte(handler.Metrics.String()), &metrics); err != nil {
+		t.Fatal(err)
+	}
+	expectedRequests := map[string]int64{"/query": 3, "/healthz": 1, "other": 2}
+	for endpoint, count := range expectedRequests {
+		if metrics.Requests[endpoint] != count {
+			t.Errorf("endpoint %s: expected %d requests but got %d", endpoint, count,
+				metrics.Requests[endpoint])
+		}
+		hist := metrics.Latencies[endpoint]
+		if hist.Count != count || hist.Buckets["1000"] != count || hist.Buckets["+Inf"] != count {
+			t.Errorf("endpoint %s: unexpected latency histogram %v", endpoint, hist)
+		}
+	}
+	if len(metrics.Requests) != len(expectedRequests) {
+		t.Errorf("unexpected endpoints: %v", metrics.Requests)
+	}
+	if metrics.InFlight != 0 {
+		t.Errorf("expected no requests in flight but got %d", metrics.InFlight)
+	}
+	if metrics.Decodes != 2 || metrics.DecodeSteps < 2 || metrics.Capped > 2 {
+		t.Errorf("unexpected decode metrics: %d decodes, %d steps, %d capped",
+			metrics.Decodes, metrics.DecodeSteps, metrics.Capped)
+	}
+}
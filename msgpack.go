@@ -0,0 +1,382 @@
+package algebrain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+)
+
+// MarshalToMessagePack encodes the network as a
+// MessagePack map with the same structure as the document
+// produced by MarshalToJSON.
+//
+// Parameters are stored as 64-bit floats, so the result is
+// lossless and considerably smaller than the JSON
+// encoding.
+func (n *Network) MarshalToMessagePack() ([]byte, error) {
+	doc, err := n.portableDocument()
+	if err != nil {
+		return nil, essentials.AddCtx("marshal MessagePack", err)
+	}
+	tokenizer := map[string]interface{}{"type": doc.Tokenizer.Type}
+	if doc.Tokenizer.Words != nil {
+		tokenizer["words"] = doc.Tokenizer.Words
+	}
+	var preprocessor interface{}
+	if p := doc.Preprocessor; p != nil {
+		synonyms := map[string]interface{}{}
+		for phrase, replacement := range p.Synonyms {
+			synonyms[phrase] = replacement
+		}
+		preprocessor = map[string]interface{}{
+			"full_width":     p.FullWidth,
+			"lowercase":      p.Lowercase,
+			"collapse_space": p.CollapseSpace,
+			"synonyms":       synonyms,
+		}
+	}
+	params := map[string]interface{}{}
+	for name, values := range doc.Parameters {
+		params[name] = values
+	}
+	var buf bytes.Buffer
+	writeMessagePack(&buf, map[string]interface{}{
+		"architecture": map[string]interface{}{
+			"query_size":   doc.Architecture.QuerySize,
+			"encoded_size": doc.Architecture.EncodedSize,
+			"vocab_size":   doc.Architecture.VocabSize,
+		},
+		"tokenizer":               tokenizer,
+		"reverse_input":           doc.ReverseInput,
+		"preprocessor":            preprocessor,
+		"parameters":              params,
+		"calibration_temperature": doc.CalibrationTemperature,
+	})
+	return buf.Bytes(), nil
+}
+
+// UnmarshalNetworkFromMessagePack decodes a network
+// encoded with MarshalToMessagePack, creating its
+// parameters with c.
+func UnmarshalNetworkFromMessagePack(c anyvec.Creator, data []byte) (*Network, error) {
+	doc, err := messagePackDocument(data)
+	if err != nil {
+		return nil, essentials.AddCtx("unmarshal MessagePack", err)
+	}
+	res, err := networkFromPortable(c, doc)
+	if err != nil {
+		return nil, essentials.AddCtx("unmarshal MessagePack", err)
+	}
+	return res, nil
+}
+
+func messagePackDocument(data []byte) (*jsonNetwork, error) {
+	r := bytes.NewReader(data)
+	obj, err := readMessagePack(r)
+	if err != nil {
+		return nil, err
+	} else if r.Len() != 0 {
+		return nil, errors.New("trailing data")
+	}
+	root, ok := obj.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("document is not a map")
+	}
+
+	doc := &jsonNetwork{Parameters: map[string][]float64{}}
+	arch, ok := root["architecture"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("missing architecture")
+	}
+	for key, dest := range map[string]*int{
+		"query_size":   &doc.Architecture.QuerySize,
+		"encoded_size": &doc.Architecture.EncodedSize,
+		"vocab_size":   &doc.Architecture.VocabSize,
+	} {
+		x, ok := arch[key].(int64)
+		if !ok {
+			return nil, fmt.Errorf("invalid architecture field: %s", key)
+		}
+		*dest = int(x)
+	}
+
+	tokenizer, ok := root["tokenizer"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("missing tokenizer")
+	}
+	if doc.Tokenizer.Type, ok = tokenizer["type"].(string); !ok {
+		return nil, errors.New("invalid tokenizer type")
+	}
+	if words, ok := tokenizer["words"].([]interface{}); ok {
+		for _, w := range words {
+			word, ok := w.(string)
+			if !ok {
+				return nil, errors.New("invalid tokenizer word")
+			}
+			doc.Tokenizer.Words = append(doc.Tokenizer.Words, word)
+		}
+	}
+
+	if doc.ReverseInput, ok = root["reverse_input"].(bool); !ok {
+		return nil, errors.New("invalid reverse_input")
+	}
+	if p, ok := root["preprocessor"].(map[string]interface{}); ok {
+		doc.Preprocessor = &Preprocessor{}
+		lower, ok1 := p["lowercase"].(bool)
+		collapse, ok2 := p["collapse_space"].(bool)
+		synonyms, ok3 := p["synonyms"].(map[string]interface{})
+		if !ok1 || !ok2 || !ok3 {
+			return nil, errors.New("invalid preprocessor")
+		}
+		doc.Preprocessor.Lowercase = lower
+		doc.Preprocessor.CollapseSpace = collapse
+		if f, ok := p["full_width"]; ok {
+			if doc.Preprocessor.FullWidth, ok = f.(bool); !ok {
+				return nil, errors.New("invalid preprocessor")
+			}
+		}
+		for phrase, r := range synonyms {
+			replacement, ok := r.(string)
+			if !ok {
+				return nil, errors.New("invalid preprocessor synonym")
+			}
+			if doc.Preprocessor.Synonyms == nil {
+				doc.Preprocessor.Synonyms = map[string]string{}
+			}
+			doc.Preprocessor.Synonyms[phrase] = replacement
+		}
+	} else if root["preprocessor"] != nil {
+		return nil, errors.New("invalid preprocessor")
+	}
+
+	if t, ok := root["calibration_temperature"]; ok {
+		if doc.CalibrationTemperature, ok = t.(float64); !ok {
+			return nil, errors.New("invalid calibration_temperature")
+		}
+	}
+
+	params, ok := root["parameters"].(map[string]interface{})
+	if !ok {
+		return nil, errors.New("missing parameters")
+	}
+	for name, p := range params {
+		list, ok := p.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("invalid parameter: %s", name)
+		}
+		values := make([]float64, len(list))
+		for i, x := range list {
+			if values[i], ok = x.(float64); !ok {
+				return nil, fmt.Errorf("invalid parameter: %s", name)
+			}
+		}
+		doc.Parameters[name] = values
+	}
+	return doc, nil
+}
+
+// writeMessagePack encodes a value, which may be a nil,
+// bool, int, float64, string, []string, []float64, or a
+// map[string]interface{} of such values.
+//
+// Map keys are written in sorted order so that the output
+// is deterministic.
+func writeMessagePack(buf *bytes.Buffer, obj interface{}) {
+	switch obj := obj.(type) {
+	case nil:
+		buf.WriteByte(0xc0)
+	case bool:
+		if obj {
+			buf.WriteByte(0xc3)
+		} else {
+			buf.WriteByte(0xc2)
+		}
+	case int:
+		if obj >= 0 && obj < 0x80 {
+			buf.WriteByte(byte(obj))
+		} else {
+			buf.WriteByte(0xd3)
+			binary.Write(buf, binary.BigEndian, int64(obj))
+		}
+	case float64:
+		buf.WriteByte(0xcb)
+		binary.Write(buf, binary.BigEndian, math.Float64bits(obj))
+	case string:
+		writeMessagePackHeader(buf, len(obj), 0xa0, 0x20, 0xd9, 0xda, 0xdb)
+		buf.WriteString(obj)
+	case []string:
+		writeMessagePackHeader(buf, len(obj), 0x90, 0x10, 0, 0xdc, 0xdd)
+		for _, x := range obj {
+			writeMessagePack(buf, x)
+		}
+	case []float64:
+		writeMessagePackHeader(buf, len(obj), 0x90, 0x10, 0, 0xdc, 0xdd)
+		for _, x := range obj {
+			writeMessagePack(buf, x)
+		}
+	case map[string]interface{}:
+		writeMessagePackHeader(buf, len(obj), 0x80, 0x10, 0, 0xde, 0xdf)
+		keys := make([]string, 0, len(obj))
+		for key := range obj {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			writeMessagePack(buf, key)
+			writeMessagePack(buf, obj[key])
+		}
+	default:
+		panic(fmt.Sprintf("unsupported MessagePack type: %T", obj))
+	}
+}
+
+// writeMessagePackHeader writes a length header, using the
+// fixed-size prefix for lengths below fixLimit and the
+// smallest of the 8, 16, and 32-bit prefixes otherwise.
+// A zero prefix8 means there is no 8-bit form.
+func writeMessagePackHeader(buf *bytes.Buffer, length int, fixPrefix byte, fixLimit int,
+	prefix8, prefix16, prefix32 byte) {
+	if length < fixLimit {
+		buf.WriteByte(fixPrefix | byte(length))
+	} else if prefix8 != 0 && length < 0x100 {
+		buf.WriteByte(prefix8)
+		buf.WriteByte(byte(length))
+	} else if length < 0x10000 {
+		buf.WriteByte(prefix16)
+		binary.Write(buf, binary.BigEndian, uint16(length))
+	} else {
+		buf.WriteByte(prefix32)
+		binary.Write(buf, binary.BigEndian, uint32(length))
+	}
+}
+
+// readMessagePack decodes a value written by
+// writeMessagePack.
+//
+// Integers are decoded as int64, arrays as []interface{},
+// and maps as map[string]interface{}.
+func readMessagePack(r *bytes.Reader) (interface{}, error) {
+	prefix, err := r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case prefix < 0x80:
+		return int64(prefix), nil
+	case prefix >= 0xe0:
+		return int64(int8(prefix)), nil
+	case prefix&0xf0 == 0x80:
+		return readMessagePackMap(r, int(prefix&0xf))
+	case prefix&0xf0 == 0x90:
+		return readMessagePackArray(r, int(prefix&0xf))
+	case prefix&0xe0 == 0xa0:
+		return readMessagePackString(r, int(prefix&0x1f))
+	}
+	switch prefix {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcb:
+		var bits uint64
+		if err := binary.Read(r, binary.BigEndian, &bits); err != nil {
+			return nil, err
+		}
+		return math.Float64frombits(bits), nil
+	case 0xd3:
+		var x int64
+		if err := binary.Read(r, binary.BigEndian, &x); err != nil {
+			return nil, err
+		}
+		return x, nil
+	case 0xd9, 0xda, 0xdb:
+		length, err := readMessagePackLength(r, prefix-0xd9)
+		if err != nil {
+			return nil, err
+		}
+		return readMessagePackString(r, length)
+	case 0xdc, 0xdd:
+		length, err := readMessagePackLength(r, prefix-0xdc+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMessagePackArray(r, length)
+	case 0xde, 0xdf:
+		length, err := readMessagePackLength(r, prefix-0xde+1)
+		if err != nil {
+			return nil, err
+		}
+		return readMessagePackMap(r, length)
+	}
+	return nil, fmt.Errorf("unsupported MessagePack prefix: 0x%02x", prefix)
+}
+
+// readMessagePackLength reads a length with 1<<sizeLog
+// bytes.
+func readMessagePackLength(r *bytes.Reader, sizeLog byte) (int, error) {
+	switch sizeLog {
+	case 0:
+		x, err := r.ReadByte()
+		return int(x), err
+	case 1:
+		var x uint16
+		err := binary.Read(r, binary.BigEndian, &x)
+		return int(x), err
+	default:
+		var x uint32
+		err := binary.Read(r, binary.BigEndian, &x)
+		return int(x), err
+	}
+}
+
+func readMessagePackString(r *bytes.Reader, length int) (string, error) {
+	if length > r.Len() {
+		return "", errors.New("unexpected end of MessagePack data")
+	}
+	data := make([]byte, length)
+	r.Read(data)
+	return string(data), nil
+}
+
+func readMessagePackArray(r *bytes.Reader, length int) ([]interface{}, error) {
+	if length > r.Len() {
+		return nil, errors.New("unexpected end of MessagePack data")
+	}
+	res := make([]interface{}, length)
+	for i := range res {
+		var err error
+		if res[i], err = readMessagePack(r); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
+
+func readMessagePackMap(r *bytes.Reader, length int) (map[string]interface{}, error) {
+	if 2*length > r.Len() {
+		return nil, errors.New("unexpected end of MessagePack data")
+	}
+	res := make(map[string]interface{}, length)
+	for i := 0; i < length; i++ {
+		key, err := readMessagePack(r)
+		if err != nil {
+			return nil, err
+		}
+		keyStr, ok := key.(string)
+		if !ok {
+			return nil, errors.New("non-string MessagePack map key")
+		}
+		if res[keyStr], err = readMessagePack(r); err != nil {
+			return nil, err
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,62 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestNetworkMessagePack(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+	net.ReverseInput = true
+	net.Preprocessor = &Preprocessor{
+		FullWidth:     true,
+		CollapseSpace: true,
+		Synonyms:      map[string]string{"what is": "evaluate"},
+	}
+	net.CalibrationTemperature = 1.5
+	data, err := net.MarshalToMessagePack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	jsonData, err := net.MarshalToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) >= len(jsonData) {
+		t.Errorf("MessagePack size %d is not less than JSON size %d", len(data),
+			len(jsonData))
+	}
+
+	loaded, err := UnmarshalNetworkFromMessagePack(anyvec64.CurrentCreator(), data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.ReverseInput || !loaded.Preprocessor.Equal(net.Preprocessor) ||
+		loaded.CalibrationTemperature != net.CalibrationTemperature {
+		t.Error("configuration was not preserved")
+	}
+	if loaded.Tokenizer.VocabSize() != net.Tokenizer.VocabSize() {
+		t.Error("tokenizer was not preserved")
+	}
+	if !NetworksEqual(net, loaded, 1e-15) {
+		t.Error("parameters were not preserved")
+	}
+
+	net.Preprocessor = nil
+	data, err = net.MarshalToMessagePack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded, err := UnmarshalNetworkFromMessagePack(anyvec64.CurrentCreator(),
+		data); err != nil {
+		t.Fatal(err)
+	} else if loaded.Preprocessor != nil {
+		t.Error("expected nil preprocessor")
+	}
+
+	if _, err := UnmarshalNetworkFromMessagePack(anyvec64.CurrentCreator(),
+		data[:len(data)/2]); err == nil {
+		t.Error("expected error for truncated document")
+	}
+}
@@ -1,6 +1,14 @@
 package algebrain
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"reflect"
+	"time"
+
 	"github.com/unixpickle/anydiff"
 	"github.com/unixpickle/anydiff/anyseq"
 	"github.com/unixpickle/anynet"
@@ -15,13 +23,18 @@ const (
 	CharCount  = 0x80
 	Terminator = 0
 
-	querySize     = 0x80
-	encodedSize   = 0x40
-	decoderInSize = CharCount + encodedSize
+	querySize   = 0x80
+	encodedSize = 0x40
 
 	maxResponseLen = 0x400
+
+	inputLengthBuckets = 0x400
 )
 
+// DefaultConfidenceThreshold is the default threshold for
+// logging low-confidence responses.
+const DefaultConfidenceThreshold = 0.5
+
 func init() {
 	var n Network
 	serializer.RegisterTypedDeserializer(n.SerializerType(), DeserializeNetwork)
@@ -33,28 +46,151 @@ type Network struct {
 	Encoder *anyrnn.Bidir
 	Align   *attention.SoftAlign
 	Output  anynet.Net
+
+	// Tokenizer determines the input and output vocabulary
+	// of the network.
+	Tokenizer Tokenizer
+
+	// ReverseInput indicates that queries are fed to the
+	// encoder in reverse order.
+	// Responses are never reversed.
+	ReverseInput bool
+
+	// Preprocessor, if non-nil, normalizes every query
+	// before it is tokenized, both in batches and in calls
+	// to Query.
+	// Storing it with the network ensures that training
+	// and inference preprocess queries identically.
+	Preprocessor *Preprocessor
+
+	// InputLengthHistogram counts decoded queries by their
+	// length in runes, before preprocessing.
+	// The last bucket also counts longer queries.
+	//
+	// Buckets are updated atomically, so the histogram
+	// should only be read with atomic loads while the
+	// network is in use.
+	InputLengthHistogram [inputLengthBuckets]int64
+
+	// MaxConcurrency is the maximum number of queries that
+	// QueryAll runs at once.
+	// If this is 0, runtime.GOMAXPROCS(0) is used.
+	MaxConcurrency int
+
+	// BatchCompactInterval is the number of decoder steps
+	// between removals of finished queries from the batch
+	// in QueryBatch.
+	// Removing them avoids computing their outputs, but it
+	// copies the decoder state, so a longer interval can be
+	// faster for large batches.
+	// If this is 0, finished queries are removed after
+	// every step.
+	BatchCompactInterval int
+
+	// CalibrationTemperature divides the output log
+	// probabilities when QueryScored computes confidences.
+	// It can be fit with CalibrateTemperature.
+	// If this is 0, a temperature of 1 is used.
+	//
+	// The temperature does not change the decoded
+	// responses.
+	CalibrationTemperature float64
+
+	// Logger, if non-nil, receives a warning whenever
+	// Query or QueryContext produces a response with a
+	// confidence (see QueryWithConfidence) below the
+	// ConfidenceThreshold.
+	Logger *log.Logger
+
+	// ConfidenceThreshold is the confidence below which
+	// responses are logged.
+	// If this is 0, DefaultConfidenceThreshold is used.
+	ConfidenceThreshold float64
+
+	// InputEncoder, if non-nil, converts query tokens into
+	// encoder inputs in place of one-hot vectors.
+	// It must match the input size of the Encoder, so it
+	// should only be set via NewNetworkInputEncoder.
+	InputEncoder InputEncoder
 }
 
 // DeserializeNetwork deserializes a Network.
 func DeserializeNetwork(d []byte) (*Network, error) {
-	var res Network
-	err := serializer.DeserializeAny(d, &res.Encoder, &res.Align, &res.Output)
+	objs, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, essentials.AddCtx("deserialize Network", err)
+	}
+	res, err := networkFromFields(objs)
 	if err != nil {
 		return nil, essentials.AddCtx("deserialize Network", err)
 	}
+	return res, nil
+}
+
+// networkFromFields creates a Network from its decoded
+// fields, as written by Serialize.
+func networkFromFields(objs []serializer.Serializer) (*Network, error) {
+	res := Network{Tokenizer: &CharTokenizer{}}
+	dests := []interface{}{&res.Encoder, &res.Align, &res.Output, &res.Tokenizer,
+		&res.ReverseInput, &res.Preprocessor}
+
+	// Networks saved by older versions lack some of the
+	// trailing fields, which keep their default values.
+	// The optional fields after the Preprocessor are
+	// identified by their types.
+	if len(objs) < 3 || len(objs) > len(dests)+2 {
+		return nil, fmt.Errorf("unexpected field count: %d", len(objs))
+	}
+	dests = dests[:essentials.MinInt(len(objs), len(dests))]
+	for _, obj := range objs[len(dests):] {
+		if _, ok := obj.(serializer.Float64); ok {
+			dests = append(dests, &res.CalibrationTemperature)
+		} else {
+			dests = append(dests, &res.InputEncoder)
+		}
+	}
+
+	for i, obj := range objs {
+		val := reflect.ValueOf(obj)
+		dest := reflect.ValueOf(dests[i]).Elem()
+		if val.Type().AssignableTo(dest.Type()) {
+			dest.Set(val)
+		} else if val.Type().ConvertibleTo(dest.Type()) {
+			dest.Set(val.Convert(dest.Type()))
+		} else {
+			return nil, fmt.Errorf("field %d: expecting %s but decoded %T", i, dest.Type(), obj)
+		}
+	}
 	return &res, nil
 }
 
-// NewNetwork creates a randomly-initialized Network.
-func NewNetwork(c anyvec.Creator) *Network {
+// NewNetwork creates a randomly-initialized Network which
+// uses the given Tokenizer.
+func NewNetwork(c anyvec.Creator, t Tokenizer) *Network {
+	return NewNetworkInputEncoder(c, t, nil)
+}
+
+// NewNetworkInputEncoder is like NewNetwork, but the
+// queries are fed to the encoder using e.
+//
+// The encoder's input size is e.InputSize(), rather than
+// the vocabulary size used for one-hot inputs.
+// If e is nil, one-hot inputs are used.
+func NewNetworkInputEncoder(c anyvec.Creator, t Tokenizer, e InputEncoder) *Network {
+	vocabSize := t.VocabSize()
+	inputSize := vocabSize
+	if e != nil {
+		inputSize = e.InputSize(vocabSize)
+	}
+	decoderInSize := vocabSize + encodedSize
 	inScaler := c.MakeNumeric(16)
 	encoder := &anyrnn.Bidir{
 		Forward: anyrnn.Stack{
-			anyrnn.NewLSTM(c, CharCount, 0x100).ScaleInWeights(inScaler),
+			anyrnn.NewLSTM(c, inputSize, 0x100).ScaleInWeights(inScaler),
 			anyrnn.NewLSTM(c, 0x100, encodedSize),
 		},
 		Backward: anyrnn.Stack{
-			anyrnn.NewLSTM(c, CharCount, 0x100).ScaleInWeights(inScaler),
+			anyrnn.NewLSTM(c, inputSize, 0x100).ScaleInWeights(inScaler),
 			anyrnn.NewLSTM(c, 0x100, encodedSize),
 		},
 		Mixer: &anynet.AddMixer{
@@ -69,7 +205,7 @@ func NewNetwork(c anyvec.Creator) *Network {
 	}
 	inComb := &anynet.AddMixer{
 		In1: anynet.NewFC(c, encodedSize, decoderInSize),
-		In2: anynet.NewFC(c, CharCount, decoderInSize),
+		In2: anynet.NewFC(c, vocabSize, decoderInSize),
 		Out: anynet.Tanh,
 	}
 	inComb.In2.(*anynet.FC).Weights.Vector.Scale(inScaler)
@@ -94,9 +230,11 @@ func NewNetwork(c anyvec.Creator) *Network {
 			InitQuery:  anydiff.NewVar(c.MakeVector(querySize)),
 		},
 		Output: anynet.Net{
-			anynet.NewFC(c, querySize, CharCount),
+			anynet.NewFC(c, querySize, vocabSize),
 			anynet.LogSoftmax,
 		},
+		Tokenizer:    t,
+		InputEncoder: e,
 	}
 }
 
@@ -109,6 +247,104 @@ func (n *Network) Parameters() []*anydiff.Var {
 	return res
 }
 
+// NamedParameters returns the parameters of the network,
+// keyed by the component they belong to and their index
+// within that component, e.g. "Encoder.Forward.0" or
+// "Output.2".
+func (n *Network) NamedParameters() map[string]*anydiff.Var {
+	res := map[string]*anydiff.Var{}
+	components := map[string]interface{}{
+		"Encoder.Forward":  n.Encoder.Forward,
+		"Encoder.Backward": n.Encoder.Backward,
+		"Encoder.Mixer":    n.Encoder.Mixer,
+		"Align":            n.Align,
+		"Output":           n.Output,
+	}
+	for name, c := range components {
+		if p, ok := c.(anynet.Parameterizer); ok {
+			for i, param := range p.Parameters() {
+				res[fmt.Sprintf("%s.%d", name, i)] = param
+			}
+		}
+	}
+	return res
+}
+
+// ParameterGroups returns the parameters of the network,
+// grouped by the kind of layer they come from.
+// This makes it possible to use a different learning rate
+// for each kind of layer.
+//
+// The groups are:
+//
+//	"lstm_weights"  LSTM gate weights and peepholes
+//	"lstm_biases"   LSTM gate biases
+//	"lstm_init"     LSTM initial states
+//	"encoder_mixer" layers combining the encoder outputs
+//	"attention"     non-recurrent parts of the attention
+//	"output_dense"  the output layer
+//
+// Every parameter belongs to exactly one group.
+func (n *Network) ParameterGroups() map[string][]*anydiff.Var {
+	lstmGroups := map[*anydiff.Var]string{}
+	for _, b := range []anyrnn.Block{n.Encoder.Forward, n.Encoder.Backward, n.Align.Decoder} {
+		for _, lstm := range lstmLayers(b) {
+			for _, gate := range []*anyrnn.LSTMGate{lstm.InValue, lstm.In, lstm.Remember,
+				lstm.Output} {
+				for _, p := range gate.Parameters() {
+					lstmGroups[p] = "lstm_weights"
+				}
+				lstmGroups[gate.Biases] = "lstm_biases"
+			}
+			lstmGroups[lstm.InitLastOut] = "lstm_init"
+			lstmGroups[lstm.InitInternal] = "lstm_init"
+		}
+	}
+	res := map[string][]*anydiff.Var{}
+	components := []struct {
+		Group string
+		Param anynet.Parameterizer
+	}{
+		{"encoder_mixer", n.Encoder},
+		{"attention", n.Align},
+		{"output_dense", n.Output},
+	}
+	for _, c := range components {
+		for _, p := range c.Param.Parameters() {
+			group := c.Group
+			if lstmGroup, ok := lstmGroups[p]; ok {
+				group = lstmGroup
+			}
+			res[group] = append(res[group], p)
+		}
+	}
+	return res
+}
+
+// ParameterCount returns the total number of scalar
+// parameters in the network.
+func (n *Network) ParameterCount() int {
+	var res int
+	for _, p := range n.Parameters() {
+		res += p.Vector.Len()
+	}
+	return res
+}
+
+// EstimateMemoryUsage estimates the number of bytes used
+// by the parameters of the network, plus the size of the
+// LSTM states needed to run one sequence.
+//
+// The estimate assumes 8-byte (float64) numerics.
+func (n *Network) EstimateMemoryUsage() int64 {
+	numParams := int64(n.ParameterCount())
+	var stateSize int64
+	for _, b := range []anyrnn.Block{n.Encoder.Forward, n.Encoder.Backward, n.Align.Decoder} {
+		stateSize += int64(lstmStateSize(b))
+	}
+	return (numParams + 2*stateSize) * 8
+}
+
 // SerializerType returns the unique ID used to serialize
 // a Network with the serializer package.
 func (n *Network) SerializerType() string {
@@ -117,13 +353,130 @@ func (n *Network) SerializerType() string {
 
 // Serialize attempts to serialize the Network.
 func (n *Network) Serialize() ([]byte, error) {
-	return serializer.SerializeAny(n.Encoder, n.Align, n.Output)
+	return serializer.SerializeAny(n.serializedFields()...)
+}
+
+// serializedFields gets the fields written by Serialize,
+// in order.
+func (n *Network) serializedFields() []interface{} {
+	preprocessor := n.Preprocessor
+	if preprocessor == nil {
+		preprocessor = &Preprocessor{}
+	}
+	fields := []interface{}{n.Encoder, n.Align, n.Output, n.Tokenizer, n.ReverseInput,
+		preprocessor}
+	if n.InputEncoder != nil {
+		fields = append(fields, n.InputEncoder)
+	}
+	if n.CalibrationTemperature != 0 {
+		fields = append(fields, n.CalibrationTemperature)
+	}
+	return fields
+}
+
+// CheckPreprocessor returns an error if p does not match
+// the network's Preprocessor.
+//
+// This can be used to ensure that an explicitly configured
+// Preprocessor agrees with the one a network was trained
+// with.
+func (n *Network) CheckPreprocessor(p *Preprocessor) error {
+	if !n.Preprocessor.Equal(p) {
+		return errors.New("check preprocessor: preprocessor does not match network")
+	}
+	return nil
 }
 
 // Query runs a query against this Network.
+//
+// It panics if the query cannot be tokenized.
 func (n *Network) Query(q string) string {
-	sample := Sample{Query: q}
-	inSeq := anyseq.ConstSeqList(n.creator(), [][]anyvec.Vector{sample.InputSequence()})
+	res, err := n.QueryContext(context.Background(), q)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// QueryContext is like Query, but it returns an error if
+// the query cannot be tokenized, or if ctx is done before
+// the response has been decoded.
+func (n *Network) QueryContext(ctx context.Context, q string) (string, error) {
+	res, err := n.decode(ctx, q, argMaxFloats)
+	if err != nil {
+		return "", err
+	}
+	if n.Logger != nil {
+		threshold := n.ConfidenceThreshold
+		if threshold == 0 {
+			threshold = DefaultConfidenceThreshold
+		}
+		if confidence := res.Confidence(); confidence < threshold {
+			n.Logger.Printf("low confidence %.4f for query %q: %q", confidence, q,
+				res.Response)
+		}
+	}
+	return res.Response, nil
+}
+
+// QueryWithConfidence is like Query, but it also returns
+// the network's confidence in the response, which is in
+// (0, 1].
+//
+// The confidence is the geometric mean of the
+// probabilities of the decoded tokens, including the
+// terminator, so it does not depend on the length of the
+// response.
+// Unlike QueryScored, it is not calibrated.
+//
+// It panics if the query cannot be tokenized.
+func (n *Network) QueryWithConfidence(q string) (string, float64) {
+	res, err := n.decode(context.Background(), q, argMaxFloats)
+	if err != nil {
+		panic(err)
+	}
+	return res.Response, res.Confidence()
+}
+
+// decodeResult describes the output of the decoder.
+type decodeResult struct {
+	Response string
+
+	// LogProb is the total log probability of the chosen
+	// tokens, including the terminator (if one was chosen).
+	LogProb float64
+
+	// Steps is the number of decoder timesteps.
+	Steps int
+
+	// Truncated is set if the response reached the maximum
+	// length without a terminator.
+	Truncated bool
+}
+
+// Confidence computes the geometric mean probability of
+// the chosen tokens.
+func (d *decodeResult) Confidence() float64 {
+	return math.Exp(d.LogProb / float64(d.Steps))
+}
+
+// decode runs the decoder on a query, using choose to
+// select each output token from the network's output
+// log probabilities.
+//
+// The slice passed to choose is reused for every step, so
+// choose must copy it if it needs the values afterwards.
+func (n *Network) decode(ctx context.Context, q string,
+	choose func(logProbs []float64) int) (*decodeResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	n.recordInputLength(q)
+	inVecs, err := n.inputSequence(&Sample{Query: q})
+	if err != nil {
+		return nil, err
+	}
+	inSeq := anyseq.ConstSeqList(n.creator(), [][]anyvec.Vector{inVecs})
 	enc := n.Encoder.Apply(inSeq)
 	b := anyrnn.Stack{
 		n.Align.Block(enc),
@@ -131,23 +484,171 @@ func (n *Network) Query(q string) string {
 	}
 	state := b.Start(1)
 
-	var lastChar rune
-	var res string
+	vocabSize := n.Tokenizer.VocabSize()
+	lastToken := Terminator
+	var tokens []int
+	var logProbs []float64
+	res := &decodeResult{}
 
 	for {
-		result := b.Step(state, oneHotVector(lastChar))
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		result := b.Step(state, oneHotVector(n.creator(), lastToken, vocabSize))
 		state = result.State()
-		nextIdx := anyvec.MaxIndex(result.Output())
-		lastChar = rune(nextIdx)
-		if lastChar == 0 || len(res) >= maxResponseLen {
+		logProbs = appendVectorFloats(logProbs[:0], result.Output())
+		lastToken = choose(logProbs)
+		res.Steps++
+		res.LogProb += logProbs[lastToken]
+		if lastToken == Terminator {
+			break
+		} else if len(tokens) >= maxResponseLen {
+			res.Truncated = true
 			break
 		}
-		res += string(lastChar)
+		tokens = append(tokens, lastToken)
+	}
+
+	res.Response = n.Tokenizer.Decode(tokens)
+	return res, nil
+}
+
+// applyTeacherForced applies the network to a batch of
+// encoder inputs, feeding the given decoder inputs rather
+// than the network's own predictions.
+// The result contains the output log probabilities.
+func (n *Network) applyTeacherForced(encIn, decIn anyseq.Seq) anyseq.Seq {
+	enc := n.Encoder.Apply(encIn)
+	return anyseq.Pool(enc, func(enc anyseq.Seq) anyseq.Seq {
+		block := n.Align.Block(enc)
+		return anyseq.Map(anyrnn.Map(decIn, block), n.Output.Apply)
+	})
+}
+
+// QueryTimeout is like QueryContext with a timeout.
+// The second return value is false if the query failed or
+// did not finish before the timeout.
+func (n *Network) QueryTimeout(q string, timeout time.Duration) (string, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	res, err := n.QueryContext(ctx, q)
+	return res, err == nil
+}
+
+func (n *Network) inputSequence(s *Sample) ([]anyvec.Vector, error) {
+	if n.Preprocessor != nil {
+		s = &Sample{Query: n.Preprocessor.Apply(s.Query), Response: s.Response}
+	}
+	if n.InputEncoder != nil {
+		return s.EncodedInputVectors(n.creator(), n.Tokenizer, n.InputEncoder,
+			n.ReverseInput)
 	}
+	if n.ReverseInput {
+		return s.ReversedInputVectors(n.creator(), n.Tokenizer)
+	}
+	return s.InputVectors(n.creator(), n.Tokenizer)
+}
 
+func lstmStateSize(b anyrnn.Block) int {
+	var res int
+	for _, lstm := range lstmLayers(b) {
+		res += lstm.InitInternal.Vector.Len()
+	}
 	return res
 }
 
+// lstmLayers finds the LSTMs in a (possibly stacked)
+// block.
+func lstmLayers(b anyrnn.Block) []*anyrnn.LSTM {
+	switch b := b.(type) {
+	case anyrnn.Stack:
+		var res []*anyrnn.LSTM
+		for _, x := range b {
+			res = append(res, lstmLayers(x)...)
+		}
+		return res
+	case *anyrnn.LSTM:
+		return []*anyrnn.LSTM{b}
+	}
+	return nil
+}
+
 func (n *Network) creator() anyvec.Creator {
 	return n.Parameters()[0].Vector.Creator()
 }
+
+// argMax finds the index of the largest component of v.
+// Unlike anyvec.MaxIndex, ties are always broken in favor
+// of the lowest index, regardless of the backend.
+func argMax(v anyvec.Vector) int {
+	switch data := v.Data().(type) {
+	case []float32:
+		var idx int
+		for i, x := range data {
+			if x > data[idx] {
+				idx = i
+			}
+		}
+		return idx
+	case []float64:
+		return argMaxFloats(data)
+	default:
+		panic(fmt.Sprintf("unsupported numeric type: %T", data))
+	}
+}
+
+// vectorFloats gets the entries of v as float64s.
+func vectorFloats(v anyvec.Vector) []float64 {
+	switch data := v.Data().(type) {
+	case []float32:
+		res := make([]float64, len(data))
+		for i, x := range data {
+			res[i] = float64(x)
+		}
+		return res
+	case []float64:
+		return data
+	default:
+		panic(fmt.Sprintf("unsupported numeric type: %T", data))
+	}
+}
+
+// appendVectorFloats appends the entries of v to buf.
+//
+// Unlike vectorFloats, it does not allocate a float64
+// slice when buf has enough capacity, although v.Data()
+// still copies the vector.
+func appendVectorFloats(buf []float64, v anyvec.Vector) []float64 {
+	switch data := v.Data().(type) {
+	case []float32:
+		for _, x := range data {
+			buf = append(buf, float64(x))
+		}
+		return buf
+	case []float64:
+		return append(buf, data...)
+	default:
+		panic(fmt.Sprintf("unsupported numeric type: %T", data))
+	}
+}
+
+func vectorEntry(v anyvec.Vector, idx int) float64 {
+	switch data := v.Data().(type) {
+	case []float32:
+		return float64(data[idx])
+	case []float64:
+		return data[idx]
+	default:
+		panic(fmt.Sprintf("unsupported numeric type: %T", data))
+	}
+}
+
+func argMaxFloats(data []float64) int {
+	var idx int
+	for i, x := range data {
+		if x > data[idx] {
+			idx = i
+		}
+	}
+	return idx
+}
@@ -0,0 +1,271 @@
+package algebrain
+
+import (
+	"bytes"
+	"log"
+	"math"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anynet/anyrnn"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/anyvec/anyvec64"
+	"github.com/unixpickle/serializer"
+)
+
+func TestArgMaxTies(t *testing.T) {
+	vec32 := anyvec32.MakeVectorData([]float32{-1, 3, 2, 3, 3})
+	if idx := argMax(vec32); idx != 1 {
+		t.Errorf("expected 1 but got %d", idx)
+	}
+	vec64 := anyvec64.MakeVectorData([]float64{5, 5, 5})
+	if idx := argMax(vec64); idx != 0 {
+		t.Errorf("expected 0 but got %d", idx)
+	}
+}
+
+func TestAppendVectorFloats(t *testing.T) {
+	vec32 := anyvec32.MakeVectorData([]float32{-1, 3, 2.5})
+	vec64 := anyvec64.MakeVectorData([]float64{1, 2})
+	for _, c := range []struct {
+		Vec      anyvec.Vector
+		Expected []float64
+	}{
+		{vec32, []float64{-1, 3, 2.5}},
+		{vec64, []float64{1, 2}},
+	} {
+		buf := make([]float64, 0, 3)
+		allocs := testing.AllocsPerRun(100, func() {
+			if res := appendVectorFloats(buf[:0], c.Vec); &res[0] != &buf[:1][0] {
+				t.Fatal("buffer was not reused")
+			}
+		})
+		// Data() copies the vector and boxes the copy in an
+		// interface{}, but the buffer itself is reused.
+		if allocs > 2 {
+			t.Errorf("expected at most 2 allocations but got %f", allocs)
+		}
+		if res := appendVectorFloats(buf[:0], c.Vec); !reflect.DeepEqual(res, c.Expected) {
+			t.Errorf("unexpected floats: %v", res)
+		}
+	}
+	prefix := []float64{7}
+	if res := appendVectorFloats(prefix, vec64); !reflect.DeepEqual(res, []float64{7, 1, 2}) {
+		t.Errorf("unexpected floats: %v", res)
+	}
+}
+
+func TestNetworkQueryReference(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+
+	// A straightforward greedy decoder, which allocates
+	// new outputs at every step.
+	reference := func(q string) string {
+		inVecs, err := net.inputSequence(&Sample{Query: q})
+		if err != nil {
+			t.Fatal(err)
+		}
+		enc := net.Encoder.Apply(anyseq.ConstSeqList(net.creator(),
+			[][]anyvec.Vector{inVecs}))
+		b := anyrnn.Stack{net.Align.Block(enc), &anyrnn.LayerBlock{Layer: net.Output}}
+		state := b.Start(1)
+		var response string
+		lastToken := Terminator
+		for len(response) < maxResponseLen {
+			res := b.Step(state, oneHotVector(net.creator(), lastToken, CharCount))
+			state = res.State()
+			lastToken = argMax(res.Output())
+			if lastToken == Terminator {
+				break
+			}
+			response += string(rune(lastToken))
+		}
+		return response
+	}
+
+	for _, q := range []string{"evaluate 1+1", "shift x by 2 in x^2"} {
+		if actual, expected := net.Query(q), reference(q); actual != expected {
+			t.Errorf("query %q: expected %q but got %q", q, expected, actual)
+		}
+	}
+}
+
+func TestNetworkSerialize(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+	net.ReverseInput = true
+	net.Preprocessor = &Preprocessor{
+		Lowercase: true,
+		Synonyms:  map[string]string{"what is": "evaluate"},
+	}
+	data, err := serializer.SerializeAny(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded *Network
+	if err := serializer.DeserializeAny(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	tok, ok := loaded.Tokenizer.(*WordTokenizer)
+	if !ok {
+		t.Fatalf("unexpected tokenizer type: %T", loaded.Tokenizer)
+	}
+	if !reflect.DeepEqual(tok.Words, StandardWords) {
+		t.Errorf("expected words %v but got %v", StandardWords, tok.Words)
+	}
+	if !loaded.ReverseInput {
+		t.Error("ReverseInput was not preserved")
+	}
+	if err := loaded.CheckPreprocessor(net.Preprocessor); err != nil {
+		t.Error(err)
+	}
+	if err := loaded.CheckPreprocessor(&Preprocessor{Lowercase: true}); err == nil {
+		t.Error("expected preprocessor mismatch")
+	}
+}
+
+func TestNetworkSaveLoad(t *testing.T) {
+	queries := []string{"evaluate 1+1", "scale x by 2 in x^2", "shift y by 3 in y"}
+	for _, reverse := range []bool{false, true} {
+		net := NewNetwork(anyvec32.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+		net.ReverseInput = reverse
+		net.Preprocessor = &Preprocessor{CollapseSpace: true}
+		path := filepath.Join(t.TempDir(), "net")
+		if err := serializer.SaveAny(path, net); err != nil {
+			t.Fatal(err)
+		}
+		var loaded *Network
+		if err := serializer.LoadAny(path, &loaded); err != nil {
+			t.Fatal(err)
+		}
+		if !NetworksEqual(net, loaded, 0) {
+			t.Error("parameters were not preserved")
+		}
+		for _, q := range queries {
+			if expected, actual := net.Query(q), loaded.Query(q); actual != expected {
+				t.Errorf("query %q: expected %q but got %q", q, expected, actual)
+			}
+		}
+	}
+}
+
+func TestNetworkEstimateMemoryUsage(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	var numParams int64
+	for _, p := range net.Parameters() {
+		numParams += int64(p.Vector.Len())
+	}
+	usage := net.EstimateMemoryUsage()
+	if usage < numParams*8 {
+		t.Errorf("usage %d is less than parameter size %d", usage, numParams*8)
+	}
+	if usage2 := net.EstimateMemoryUsage(); usage2 != usage {
+		t.Errorf("estimate changed from %d to %d", usage, usage2)
+	}
+}
+
+func TestNetworkParameterGroups(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	groups := net.ParameterGroups()
+	for _, name := range []string{"lstm_weights", "lstm_biases", "lstm_init", "output_dense"} {
+		if len(groups[name]) == 0 {
+			t.Errorf("group %s should not be empty", name)
+		}
+	}
+	lstm := net.Align.Decoder.(anyrnn.Stack)[0].(*anyrnn.LSTM)
+	for _, p := range []*anydiff.Var{lstm.InitLastOut, lstm.InitInternal} {
+		var found bool
+		for _, x := range groups["lstm_init"] {
+			found = found || x == p
+		}
+		if !found {
+			t.Error("missing initial state in lstm_init")
+		}
+	}
+	seen := map[*anydiff.Var]bool{}
+	var count int
+	for _, params := range groups {
+		for _, p := range params {
+			seen[p] = true
+			count++
+		}
+	}
+	params := net.Parameters()
+	if count != len(params) {
+		t.Errorf("expected %d parameters but got %d", len(params), count)
+	}
+	for i, p := range params {
+		if !seen[p] {
+			t.Errorf("parameter %d is missing from the groups", i)
+		}
+	}
+}
+
+func TestNetworkQueryTimeout(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	if _, ok := net.QueryTimeout("evaluate 1+1", time.Nanosecond); ok {
+		t.Error("query should have timed out")
+	}
+
+	// Force the network to avoid terminating immediately.
+	outLayer := net.Output[0].(*anynet.FC)
+	biases := make([]float64, CharCount)
+	biases['1'] = 1000
+	outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+
+	res, ok := net.QueryTimeout("evaluate 1+1", time.Hour)
+	if !ok {
+		t.Error("query should not have timed out")
+	} else if res == "" {
+		t.Error("unexpected empty response")
+	}
+}
+
+func TestDecodeResultConfidence(t *testing.T) {
+	res := &decodeResult{Response: "x", LogProb: 0, Steps: 2}
+	if actual := res.Confidence(); actual != 1 {
+		t.Errorf("expected confidence 1 but got %f", actual)
+	}
+	res = &decodeResult{Response: "xy", LogProb: 3 * math.Log(0.5), Steps: 3}
+	if actual := res.Confidence(); math.Abs(actual-0.5) > 1e-8 {
+		t.Errorf("expected confidence 0.5 but got %f", actual)
+	}
+}
+
+func TestNetworkQueryWithConfidence(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+
+	// Make the output distribution uniform, so that the
+	// terminator is chosen with probability 1/CharCount.
+	outLayer := net.Output[0].(*anynet.FC)
+	outLayer.Weights.Vector.Scale(0.0)
+	outLayer.Biases.Vector.Scale(0.0)
+
+	response, confidence := net.QueryWithConfidence("evaluate 1+1")
+	if response != "" {
+		t.Errorf("unexpected response: %q", response)
+	}
+	if math.Abs(confidence-1.0/CharCount) > 1e-8 {
+		t.Errorf("expected confidence %f but got %f", 1.0/CharCount, confidence)
+	}
+
+	var buf bytes.Buffer
+	net.Logger = log.New(&buf, "", 0)
+	net.Query("evaluate 1+1")
+	if !strings.Contains(buf.String(), "low confidence") {
+		t.Errorf("expected low-confidence warning but got %q", buf.String())
+	}
+
+	buf.Reset()
+	net.ConfidenceThreshold = 1.0 / (2 * CharCount)
+	net.Query("evaluate 1+1")
+	if buf.Len() != 0 {
+		t.Errorf("unexpected log output: %q", buf.String())
+	}
+}
@@ -0,0 +1,37 @@
+package algebrain
+
+import "math/rand"
+
+// A NoisyGenerator wraps a Generator and corrupts the
+// characters of each query, simulating typos or OCR
+// errors.
+// Responses are unchanged.
+//
+// Each character is independently replaced, with
+// probability FlipProb, by an adjacent ASCII character
+// (one code point higher or lower).
+// Characters stay within [1, CharCount-1], so they never
+// become the Terminator.
+// Queries should only contain characters in this range.
+type NoisyGenerator struct {
+	Generator Generator
+	FlipProb  float64
+}
+
+// Generate generates a noisy sample.
+func (n *NoisyGenerator) Generate() *Sample {
+	sample := *n.Generator.Generate()
+	query := []byte(sample.Query)
+	for i, ch := range query {
+		if rand.Float64() >= n.FlipProb {
+			continue
+		}
+		if ch <= 1 || (ch < CharCount-1 && rand.Intn(2) == 0) {
+			query[i] = ch + 1
+		} else {
+			query[i] = ch - 1
+		}
+	}
+	sample.Query = string(query)
+	return &sample
+}
@@ -0,0 +1,55 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNoisyGenerator(t *testing.T) {
+	query := "\x01evaluate 1+1\x7f"
+	inner := &constGenerator{Sample: Sample{Query: query, Response: "Result: 2"}}
+	const flipProb = 0.2
+	gen := &NoisyGenerator{Generator: inner, FlipProb: flipProb}
+
+	const numSamples = 5000
+	counts := make([]float64, numSamples)
+	for i := range counts {
+		sample := gen.Generate()
+		if sample.Response != "Result: 2" {
+			t.Fatalf("response changed to %q", sample.Response)
+		}
+		if len(sample.Query) != len(query) {
+			t.Fatalf("query length changed: %q", sample.Query)
+		}
+		for j := 0; j < len(query); j++ {
+			ch := sample.Query[j]
+			if ch < 1 || ch >= CharCount {
+				t.Fatalf("character out of range: %d", ch)
+			}
+			if ch != query[j] {
+				if ch != query[j]+1 && ch != query[j]-1 {
+					t.Fatalf("character %d changed from %d to %d", j, query[j], ch)
+				}
+				counts[i]++
+			}
+		}
+	}
+
+	// The flip counts should be Binomial(len(query), p).
+	var mean, variance float64
+	for _, c := range counts {
+		mean += c / numSamples
+	}
+	for _, c := range counts {
+		variance += (c - mean) * (c - mean) / (numSamples - 1)
+	}
+	n := float64(len(query))
+	expectedMean := n * flipProb
+	expectedVariance := n * flipProb * (1 - flipProb)
+	if math.Abs(mean-expectedMean) > 0.1 {
+		t.Errorf("expected mean %f but got %f", expectedMean, mean)
+	}
+	if math.Abs(variance-expectedVariance) > 0.25 {
+		t.Errorf("expected variance %f but got %f", expectedVariance, variance)
+	}
+}
@@ -0,0 +1,35 @@
+package algebrain
+
+import (
+	"github.com/unixpickle/essentials"
+)
+
+// OnlineUpdate applies a single SGD step to the network,
+// using the teacher-forced cost of one sample.
+// The gradient is scaled by the learning rate lr.
+//
+// This is meant for correcting individual mistakes, e.g.
+// in an interactive session.
+// Repeated updates on a single sample (or a handful of
+// samples) will overfit to it and can degrade the network
+// on other queries, so lr should be small, and the network
+// should be re-evaluated after a series of updates.
+//
+// It returns an error if the sample cannot be tokenized,
+// or a *DivergenceError if the cost or gradient is not
+// finite.
+// In either case, the network is not modified.
+func OnlineUpdate(n *Network, s *Sample, lr float64) error {
+	trainer := &Trainer{Network: n}
+	batch, err := trainer.Fetch(SampleList{s})
+	if err != nil {
+		return essentials.AddCtx("online update", err)
+	}
+	grad := trainer.Gradient(batch)
+	if trainer.divergence != nil {
+		return trainer.divergence
+	}
+	grad.Scale(n.creator().MakeNumeric(-lr))
+	grad.AddToVars()
+	return nil
+}
@@ -0,0 +1,34 @@
+package algebrain
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestOnlineUpdate(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	sample := &Sample{Query: "evaluate 1+1", Response: "Result: 2"}
+	samples := []*Sample{sample}
+
+	before := Perplexity(net, samples)
+	if err := OnlineUpdate(net, sample, 0.01); err != nil {
+		t.Fatal(err)
+	}
+	after := Perplexity(net, samples)
+	if !(after < before) {
+		t.Errorf("perplexity went from %f to %f", before, after)
+	}
+
+	diverged := &Sample{Query: sample.Query, Response: sample.Response, Weight: math.Inf(1)}
+	if err := OnlineUpdate(net, diverged, 0.01); !errors.Is(err, ErrTrainingDiverged) {
+		t.Errorf("expected divergence but got %v", err)
+	} else if p := Perplexity(net, samples); p != after {
+		t.Errorf("diverged update changed perplexity from %f to %f", after, p)
+	}
+	if err := OnlineUpdate(net, &Sample{Query: "é", Response: "1"}, 0.01); err == nil {
+		t.Error("expected error for untokenizable sample")
+	}
+}
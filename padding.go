@@ -0,0 +1,117 @@
+package algebrain
+
+import (
+	"fmt"
+
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anyvec"
+)
+
+// A PadPolicy determines how PadSamples deals with samples
+// that exceed the maximum lengths.
+type PadPolicy int
+
+const (
+	// PadError causes PadSamples to fail on long samples.
+	PadError PadPolicy = iota
+
+	// PadTruncate causes PadSamples to truncate long
+	// queries and responses.
+	PadTruncate
+)
+
+// PadSamples encodes samples as rectangular token arrays.
+//
+// Each row of inputs contains maxQueryLen tokens, and is
+// padded with Terminator tokens.
+// Each row of targets contains maxRespLen+1 tokens: the
+// response, a Terminator, and then padding.
+// The mask indicates which targets are not padding.
+//
+// Tokens are stored in their natural order, even if the
+// network uses ReverseInput.
+func (n *Network) PadSamples(samples []*Sample, maxQueryLen, maxRespLen int,
+	policy PadPolicy) (inputs, targets [][]int, mask [][]bool, err error) {
+	for i, sample := range samples {
+		query, err := n.Tokenizer.Encode(n.Preprocessor.Apply(sample.Query))
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("pad sample %d: %s", i, err)
+		}
+		response, err := n.Tokenizer.Encode(sample.Response)
+		if err != nil {
+			return nil, nil, nil, fmt.Errorf("pad sample %d: %s", i, err)
+		}
+		if len(query) > maxQueryLen || len(response) > maxRespLen {
+			if policy != PadTruncate {
+				return nil, nil, nil, fmt.Errorf("pad sample %d: sample too long", i)
+			}
+			if len(query) > maxQueryLen {
+				query = query[:maxQueryLen]
+			}
+			if len(response) > maxRespLen {
+				response = response[:maxRespLen]
+			}
+		}
+		input := make([]int, maxQueryLen)
+		copy(input, query)
+		target := make([]int, maxRespLen+1)
+		copy(target, response)
+		targetMask := make([]bool, maxRespLen+1)
+		for j := 0; j <= len(response); j++ {
+			targetMask[j] = true
+		}
+		inputs = append(inputs, input)
+		targets = append(targets, target)
+		mask = append(mask, targetMask)
+	}
+	return
+}
+
+// PaddedBatch creates a *Batch from the results of
+// PadSamples.
+//
+// Padding is removed before the sequences are built, so
+// padded steps contribute nothing to the cost of the
+// batch.
+func (n *Network) PaddedBatch(inputs, targets [][]int, mask [][]bool) *Batch {
+	vocabSize := n.Tokenizer.VocabSize()
+	var encIn, decIn, decOut [][]anyvec.Vector
+	for i, input := range inputs {
+		var query []int
+		for _, x := range input {
+			if x == Terminator {
+				break
+			}
+			query = append(query, x)
+		}
+		if n.ReverseInput {
+			query = reversedTokens(query)
+		}
+		var target []int
+		for j, x := range targets[i] {
+			if mask[i][j] {
+				target = append(target, x)
+			}
+		}
+		var targetIn []int
+		if len(target) > 0 {
+			targetIn = append([]int{Terminator}, target[:len(target)-1]...)
+		}
+		encIn = append(encIn, n.encodeQuery(query))
+		decIn = append(decIn, oneHotSequence(n.creator(), targetIn, vocabSize))
+		decOut = append(decOut, oneHotSequence(n.creator(), target, vocabSize))
+	}
+	return &Batch{
+		EncIn:  anyseq.ConstSeqList(n.creator(), encIn),
+		DecIn:  anyseq.ConstSeqList(n.creator(), decIn),
+		DecOut: anyseq.ConstSeqList(n.creator(), decOut),
+	}
+}
+
+func reversedTokens(tokens []int) []int {
+	res := make([]int, len(tokens))
+	for i, x := range tokens {
+		res[len(res)-(i+1)] = x
+	}
+	return res
+}
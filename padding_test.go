@@ -0,0 +1,59 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestPaddedCost(t *testing.T) {
+	samples := []*Sample{
+		{Query: "scale x by 2 in x", Response: "x*2"},
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "shift x by 3 in x^2", Response: "(x-3)^2"},
+	}
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	net.ReverseInput = true
+	trainer := &Trainer{Network: net}
+
+	ragged, err := net.MakeBatch(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inputs, targets, mask, err := net.PadSamples(samples, 30, 20, PadError)
+	if err != nil {
+		t.Fatal(err)
+	}
+	padded := net.PaddedBatch(inputs, targets, mask)
+
+	expected := anyvec.Sum(trainer.TotalCost(ragged).Output()).(float64)
+	actual := anyvec.Sum(trainer.TotalCost(padded).Output()).(float64)
+	if math.Abs(actual-expected) > 1e-8 {
+		t.Errorf("expected cost %f but got %f", expected, actual)
+	}
+}
+
+func TestPadSamplesPolicy(t *testing.T) {
+	samples := []*Sample{{Query: "evaluate 12+13", Response: "Result: 25"}}
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	if _, _, _, err := net.PadSamples(samples, 5, 5, PadError); err == nil {
+		t.Error("expected error for long sample")
+	}
+	inputs, targets, mask, err := net.PadSamples(samples, 5, 5, PadTruncate)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if net.Tokenizer.Decode(inputs[0]) != "evalu" {
+		t.Errorf("unexpected inputs: %v", inputs[0])
+	}
+	if net.Tokenizer.Decode(targets[0][:5]) != "Resul" || targets[0][5] != Terminator {
+		t.Errorf("unexpected targets: %v", targets[0])
+	}
+	for i, x := range mask[0] {
+		if !x {
+			t.Errorf("target %d should not be masked", i)
+		}
+	}
+}
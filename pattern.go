@@ -0,0 +1,119 @@
+package algebrain
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// Default settings for a PatternGenerator.
+const (
+	DefaultPatternLength   = 4
+	DefaultPatternMaxStart = 10
+	DefaultPatternMaxStep  = 5
+	DefaultPatternMaxRatio = 3
+)
+
+// A PatternGenerator generates Samples with queries like
+// "continue the pattern 2, 4, 6, ?", expecting
+// "Result: 8".
+//
+// The missing term is either the last term or one of the
+// terms in the middle, e.g. "continue the pattern 3, ?,
+// 12, 24".
+// The first term is never missing.
+type PatternGenerator struct {
+	// Geometric selects geometric sequences, where each
+	// term is a multiple of the previous one.
+	// Otherwise, sequences are arithmetic.
+	Geometric bool
+
+	// Length is the number of terms, including the missing
+	// one.
+	// It must be at least 3.
+	// If this is 0, DefaultPatternLength is used.
+	Length int
+
+	// MaxStart is the maximum absolute value of the first
+	// term.
+	// If this is 0, DefaultPatternMaxStart is used.
+	MaxStart int
+
+	// MaxStep is the maximum absolute difference between
+	// terms of an arithmetic sequence.
+	// If this is 0, DefaultPatternMaxStep is used.
+	MaxStep int
+
+	// MaxRatio is the maximum absolute ratio between terms
+	// of a geometric sequence.
+	// It must be at least 2.
+	// If this is 0, DefaultPatternMaxRatio is used.
+	MaxRatio int
+}
+
+// Generate generates a pattern completion sample.
+func (p *PatternGenerator) Generate() *Sample {
+	length := p.Length
+	if length == 0 {
+		length = DefaultPatternLength
+	}
+	if length < 3 {
+		panic("pattern length must be at least 3")
+	}
+	maxStart := p.MaxStart
+	if maxStart == 0 {
+		maxStart = DefaultPatternMaxStart
+	}
+
+	terms := make([]int, length)
+	if p.Geometric {
+		maxRatio := p.MaxRatio
+		if maxRatio == 0 {
+			maxRatio = DefaultPatternMaxRatio
+		}
+		if maxRatio < 2 {
+			panic("pattern ratio must be at least 2")
+		}
+		terms[0] = rand.Intn(maxStart) + 1
+		if rand.Intn(2) == 0 {
+			terms[0] = -terms[0]
+		}
+		ratio := rand.Intn(maxRatio-1) + 2
+		if rand.Intn(2) == 0 {
+			ratio = -ratio
+		}
+		for i := 1; i < length; i++ {
+			terms[i] = terms[i-1] * ratio
+		}
+	} else {
+		maxStep := p.MaxStep
+		if maxStep == 0 {
+			maxStep = DefaultPatternMaxStep
+		}
+		terms[0] = rand.Intn(2*maxStart+1) - maxStart
+		step := rand.Intn(maxStep) + 1
+		if rand.Intn(2) == 0 {
+			step = -step
+		}
+		for i := 1; i < length; i++ {
+			terms[i] = terms[i-1] + step
+		}
+	}
+
+	missing := length - 1
+	if rand.Intn(2) == 0 {
+		missing = rand.Intn(length-2) + 1
+	}
+	parts := make([]string, length)
+	for i, term := range terms {
+		if i == missing {
+			parts[i] = "?"
+		} else {
+			parts[i] = strconv.Itoa(term)
+		}
+	}
+	return &Sample{
+		Query:    "continue the pattern " + strings.Join(parts, ", "),
+		Response: "Result: " + strconv.Itoa(terms[missing]),
+	}
+}
@@ -0,0 +1,57 @@
+package algebrain
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestPatternGenerator(t *testing.T) {
+	for _, geometric := range []bool{false, true} {
+		gen := &PatternGenerator{Geometric: geometric, Length: 5}
+		var missingEnd, missingMiddle bool
+		for i := 0; i < 1000; i++ {
+			sample := gen.Generate()
+			if !strings.HasPrefix(sample.Query, "continue the pattern ") ||
+				!strings.HasPrefix(sample.Response, "Result: ") {
+				t.Fatalf("unexpected sample: %s -> %s", sample.Query, sample.Response)
+			}
+			parts := strings.Split(strings.TrimPrefix(sample.Query, "continue the pattern "),
+				", ")
+			if len(parts) != 5 {
+				t.Fatalf("unexpected length: %s", sample.Query)
+			}
+			answer, err := strconv.Atoi(strings.TrimPrefix(sample.Response, "Result: "))
+			if err != nil {
+				t.Fatal(err)
+			}
+			terms := make([]int, len(parts))
+			for j, part := range parts {
+				if part == "?" {
+					if j == 0 {
+						t.Fatalf("first term missing: %s", sample.Query)
+					}
+					missingEnd = missingEnd || j == len(parts)-1
+					missingMiddle = missingMiddle || j < len(parts)-1
+					terms[j] = answer
+				} else if terms[j], err = strconv.Atoi(part); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for j := 2; j < len(terms); j++ {
+				var ok bool
+				if geometric {
+					ok = terms[j]*terms[j-2] == terms[j-1]*terms[j-1] && terms[j] != terms[j-1]
+				} else {
+					ok = terms[j]-terms[j-1] == terms[j-1]-terms[j-2] && terms[j] != terms[j-1]
+				}
+				if !ok {
+					t.Fatalf("not a valid pattern: %s -> %s", sample.Query, sample.Response)
+				}
+			}
+		}
+		if !missingEnd || !missingMiddle {
+			t.Errorf("geometric=%v: missing terms not varied", geometric)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package algebrain
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"strconv"
+)
+
+// Default bounds for a PercentChangeGenerator.
+const (
+	DefaultPercentChangeMaxBase    = 1000
+	DefaultPercentChangeMaxPercent = 100
+)
+
+// A PercentChangeGenerator generates Samples with queries
+// like "increase 80 by 25%", expecting "Result: 100", or
+// "decrease 200 by 10%", expecting "Result: 180".
+type PercentChangeGenerator struct {
+	// If Decrease is set, queries decrease the base value.
+	// Otherwise, they increase it.
+	Decrease bool
+
+	// MaxBase is the maximum base value.
+	// If this is 0, DefaultPercentChangeMaxBase is used.
+	MaxBase int
+
+	// MaxPercent is the maximum percentage.
+	// If this is 0, DefaultPercentChangeMaxPercent is used.
+	// Decreases never exceed 100%.
+	MaxPercent int
+
+	// Precision is the number of decimal places in the
+	// result, which is rounded half away from zero.
+	Precision int
+}
+
+// Generate generates a percentage change sample.
+func (p *PercentChangeGenerator) Generate() *Sample {
+	maxBase := p.MaxBase
+	if maxBase == 0 {
+		maxBase = DefaultPercentChangeMaxBase
+	}
+	maxPercent := p.MaxPercent
+	if maxPercent == 0 {
+		maxPercent = DefaultPercentChangeMaxPercent
+	}
+	if p.Decrease && maxPercent > 100 {
+		maxPercent = 100
+	}
+	base := rand.Intn(maxBase + 1)
+	percent := rand.Intn(maxPercent + 1)
+
+	command := "increase"
+	change := base * percent
+	if p.Decrease {
+		command = "decrease"
+		change = -change
+	}
+
+	// Work in hundredths so that halves are exact, then
+	// round them away from zero (unlike FormatFloat, which
+	// rounds them to even).
+	hundredths := float64(base*100 + change)
+	scale := math.Pow10(p.Precision)
+	rounded := math.Round(hundredths*scale/100) / scale
+	result := strconv.FormatFloat(rounded, 'f', p.Precision, 64)
+	return &Sample{
+		Query:    fmt.Sprintf("%s %d by %d%%", command, base, percent),
+		Response: "Result: " + result,
+	}
+}
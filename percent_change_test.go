@@ -0,0 +1,61 @@
+package algebrain
+
+import "testing"
+
+func TestPercentChangeGenerator(t *testing.T) {
+	cases := []struct {
+		Gen      *PercentChangeGenerator
+		Expected map[string]string
+	}{
+		{
+			Gen: &PercentChangeGenerator{MaxBase: 10, MaxPercent: 40},
+			Expected: map[string]string{
+				"increase 10 by 25%": "Result: 13",
+				"increase 10 by 15%": "Result: 12",
+				"increase 10 by 5%":  "Result: 11",
+				"increase 8 by 40%":  "Result: 11",
+				"increase 0 by 30%":  "Result: 0",
+			},
+		},
+		{
+			Gen: &PercentChangeGenerator{Decrease: true, MaxBase: 10, MaxPercent: 40},
+			Expected: map[string]string{
+				"decrease 10 by 25%": "Result: 8",
+				"decrease 10 by 35%": "Result: 7",
+				"decrease 4 by 10%":  "Result: 4",
+			},
+		},
+		{
+			Gen: &PercentChangeGenerator{MaxBase: 5, MaxPercent: 10, Precision: 1},
+			Expected: map[string]string{
+				"increase 5 by 1%": "Result: 5.1",
+				"increase 5 by 3%": "Result: 5.2",
+				"increase 2 by 5%": "Result: 2.1",
+			},
+		},
+		{
+			Gen: &PercentChangeGenerator{Decrease: true, MaxBase: 5, MaxPercent: 10,
+				Precision: 2},
+			Expected: map[string]string{
+				"decrease 5 by 1%": "Result: 4.95",
+				"decrease 3 by 5%": "Result: 2.85",
+			},
+		},
+	}
+	for i, c := range cases {
+		seen := map[string]bool{}
+		for j := 0; j < 20000 && len(seen) < len(c.Expected); j++ {
+			sample := c.Gen.Generate()
+			if expected, ok := c.Expected[sample.Query]; ok {
+				seen[sample.Query] = true
+				if sample.Response != expected {
+					t.Errorf("case %d: %s: expected %q but got %q", i, sample.Query,
+						expected, sample.Response)
+				}
+			}
+		}
+		if len(seen) < len(c.Expected) {
+			t.Errorf("case %d: only saw %d of %d queries", i, len(seen), len(c.Expected))
+		}
+	}
+}
@@ -0,0 +1,207 @@
+package algebrain
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+// preprocessorFullWidthVersion marks the serialized format
+// that includes the FullWidth flag.
+const preprocessorFullWidthVersion serializer.Int = 1
+
+func init() {
+	var p Preprocessor
+	serializer.RegisterTypedDeserializer(p.SerializerType(), DeserializePreprocessor)
+}
+
+// A Preprocessor normalizes queries before they are fed to
+// a Network.
+//
+// Each stage is optional, and the stages are applied in
+// the order of the fields.
+type Preprocessor struct {
+	// FullWidth replaces full-width digits, operators, and
+	// parentheses with their ASCII equivalents, as in
+	// NormalizeUnicodeQuery, so that "１＋２" becomes "1+2".
+	FullWidth bool `json:"full_width"`
+
+	// Lowercase converts queries to lowercase, so that
+	// "Evaluate 2+2" becomes "evaluate 2+2".
+	Lowercase bool `json:"lowercase"`
+
+	// CollapseSpace trims leading and trailing whitespace
+	// and replaces inner runs of whitespace with a single
+	// space.
+	CollapseSpace bool `json:"collapse_space"`
+
+	// Synonyms maps phrases to their replacements, e.g.
+	// "what is" to "evaluate".
+	// Phrases are only replaced when they are not part of
+	// a larger word, and longer phrases take priority.
+	Synonyms map[string]string `json:"synonyms,omitempty"`
+}
+
+// DeserializePreprocessor deserializes a Preprocessor.
+func DeserializePreprocessor(d []byte) (*Preprocessor, error) {
+	objs, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, essentials.AddCtx("deserialize Preprocessor", err)
+	}
+	// Preprocessors which use FullWidth are prefixed with a
+	// format version, since the original format only had
+	// two flags.
+	// Other Preprocessors use the original format, so that
+	// older versions can still load them.
+	var version serializer.Int
+	if len(objs) > 0 {
+		if v, ok := objs[0].(serializer.Int); ok {
+			if v != preprocessorFullWidthVersion {
+				return nil, fmt.Errorf("deserialize Preprocessor: unknown version: %d", v)
+			}
+			version = v
+			objs = objs[1:]
+		}
+	}
+	numFlags := 2
+	if version == preprocessorFullWidthVersion {
+		numFlags = 3
+	}
+	if len(objs) < numFlags || (len(objs)-numFlags)%2 != 0 {
+		return nil, fmt.Errorf("deserialize Preprocessor: unexpected field count: %d",
+			len(objs))
+	}
+	flags := make([]bool, numFlags)
+	for i := range flags {
+		flag, ok := objs[i].(serializer.Bool)
+		if !ok {
+			return nil, errors.New("deserialize Preprocessor: invalid flag type")
+		}
+		flags[i] = bool(flag)
+	}
+	res := &Preprocessor{Lowercase: flags[0], CollapseSpace: flags[1]}
+	if numFlags == 3 {
+		res.FullWidth = flags[2]
+	}
+	for i := numFlags; i < len(objs); i += 2 {
+		phrase, ok1 := objs[i].(serializer.String)
+		replacement, ok2 := objs[i+1].(serializer.String)
+		if !ok1 || !ok2 {
+			return nil, errors.New("deserialize Preprocessor: invalid synonym type")
+		}
+		if res.Synonyms == nil {
+			res.Synonyms = map[string]string{}
+		}
+		res.Synonyms[string(phrase)] = string(replacement)
+	}
+	return res, nil
+}
+
+// Apply preprocesses a query.
+//
+// A nil Preprocessor returns the query unchanged.
+func (p *Preprocessor) Apply(query string) string {
+	if p == nil {
+		return query
+	}
+	if p.FullWidth {
+		query = NormalizeUnicodeQuery(query)
+	}
+	if p.Lowercase {
+		query = strings.ToLower(query)
+	}
+	if p.CollapseSpace {
+		query = strings.Join(strings.Fields(query), " ")
+	}
+	if len(p.Synonyms) > 0 {
+		query = p.replaceSynonyms(query)
+	}
+	return query
+}
+
+// Equal checks if two Preprocessors always produce the
+// same results.
+// A nil Preprocessor is equivalent to an empty one.
+func (p *Preprocessor) Equal(p1 *Preprocessor) bool {
+	if p == nil {
+		p = &Preprocessor{}
+	}
+	if p1 == nil {
+		p1 = &Preprocessor{}
+	}
+	if len(p.Synonyms) == 0 && len(p1.Synonyms) == 0 {
+		return p.FullWidth == p1.FullWidth && p.Lowercase == p1.Lowercase &&
+			p.CollapseSpace == p1.CollapseSpace
+	}
+	return reflect.DeepEqual(p, p1)
+}
+
+// SerializerType returns the unique ID used to serialize
+// a Preprocessor with the serializer package.
+func (p *Preprocessor) SerializerType() string {
+	return "github.com/unixpickle/algebrain.Preprocessor"
+}
+
+// Serialize serializes the Preprocessor.
+func (p *Preprocessor) Serialize() ([]byte, error) {
+	var objs []serializer.Serializer
+	if p.FullWidth {
+		objs = append(objs, preprocessorFullWidthVersion)
+	}
+	objs = append(objs, serializer.Bool(p.Lowercase), serializer.Bool(p.CollapseSpace))
+	if p.FullWidth {
+		objs = append(objs, serializer.Bool(true))
+	}
+	for _, phrase := range p.sortedPhrases() {
+		objs = append(objs, serializer.String(phrase),
+			serializer.String(p.Synonyms[phrase]))
+	}
+	return serializer.SerializeSlice(objs)
+}
+
+func (p *Preprocessor) replaceSynonyms(query string) string {
+	phrases := p.sortedPhrases()
+	var res strings.Builder
+	for i := 0; i < len(query); i++ {
+		var matched bool
+		for _, phrase := range phrases {
+			if phrase == "" || !strings.HasPrefix(query[i:], phrase) {
+				continue
+			}
+			end := i + len(phrase)
+			if (i > 0 && isWordRune(rune(query[i-1]))) ||
+				(end < len(query) && isWordRune(rune(query[end]))) {
+				continue
+			}
+			res.WriteString(p.Synonyms[phrase])
+			i = end - 1
+			matched = true
+			break
+		}
+		if !matched {
+			res.WriteByte(query[i])
+		}
+	}
+	return res.String()
+}
+
+// sortedPhrases returns the synonym phrases, longest
+// first, breaking ties alphabetically.
+func (p *Preprocessor) sortedPhrases() []string {
+	var res []string
+	for phrase := range p.Synonyms {
+		res = append(res, phrase)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if len(res[i]) != len(res[j]) {
+			return len(res[i]) > len(res[j])
+		}
+		return res[i] < res[j]
+	})
+	return res
+}
@@ -0,0 +1,162 @@
+package algebrain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/serializer"
+)
+
+func TestPreprocessorStages(t *testing.T) {
+	query := "  What is   2+2 "
+	cases := []struct {
+		p        *Preprocessor
+		expected string
+	}{
+		{nil, query},
+		{&Preprocessor{}, query},
+		{&Preprocessor{Lowercase: true}, "  what is   2+2 "},
+		{&Preprocessor{CollapseSpace: true}, "What is 2+2"},
+		{
+			&Preprocessor{Synonyms: map[string]string{"What is": "evaluate"}},
+			"  evaluate   2+2 ",
+		},
+		{
+			&Preprocessor{
+				Lowercase:     true,
+				CollapseSpace: true,
+				Synonyms:      map[string]string{"what is": "evaluate", "what": "X"},
+			},
+			"evaluate 2+2",
+		},
+	}
+	for i, c := range cases {
+		if actual := c.p.Apply(query); actual != c.expected {
+			t.Errorf("case %d: expected %q but got %q", i, c.expected, actual)
+		}
+	}
+}
+
+func TestPreprocessorSynonymBoundaries(t *testing.T) {
+	p := &Preprocessor{Synonyms: map[string]string{"in": "IN", "compute": "evaluate"}}
+	actual := p.Apply("compute sin(x) in x")
+	expected := "evaluate sin(x) IN x"
+	if actual != expected {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+}
+
+func TestPreprocessorSerialize(t *testing.T) {
+	p := &Preprocessor{
+		FullWidth:     true,
+		CollapseSpace: true,
+		Synonyms:      map[string]string{"what is": "evaluate", "compute": "evaluate"},
+	}
+	data, err := serializer.SerializeAny(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var loaded *Preprocessor
+	if err := serializer.DeserializeAny(data, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !loaded.Equal(p) {
+		t.Errorf("expected %v but got %v", p, loaded)
+	}
+	if loaded.Equal(&Preprocessor{CollapseSpace: true}) {
+		t.Error("synonyms should affect equality")
+	}
+	if (&Preprocessor{FullWidth: true}).Equal(&Preprocessor{}) {
+		t.Error("FullWidth should affect equality")
+	}
+	if !(&Preprocessor{}).Equal(nil) {
+		t.Error("empty preprocessor should equal nil")
+	}
+}
+
+func TestPreprocessorSerializeFormat(t *testing.T) {
+	// Without FullWidth, the original two-flag format is
+	// used, so older versions can load the Preprocessor.
+	legacy, err := serializer.SerializeSlice([]serializer.Serializer{
+		serializer.Bool(true), serializer.Bool(false),
+		serializer.String("what is"), serializer.String("evaluate"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := &Preprocessor{Lowercase: true, Synonyms: map[string]string{"what is": "evaluate"}}
+	data, err := p.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != string(legacy) {
+		t.Error("unexpected encoding without FullWidth")
+	}
+
+	// With FullWidth, a version marker precedes the flags,
+	// regardless of the number of synonyms.
+	for _, synonyms := range []map[string]string{nil, {"what is": "evaluate"}} {
+		p := &Preprocessor{FullWidth: true, CollapseSpace: true, Synonyms: synonyms}
+		data, err := p.Serialize()
+		if err != nil {
+			t.Fatal(err)
+		}
+		objs, err := serializer.DeserializeSlice(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := objs[0].(serializer.Int); !ok {
+			t.Errorf("expected version marker but got %T", objs[0])
+		}
+		loaded, err := DeserializePreprocessor(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !loaded.Equal(p) {
+			t.Errorf("expected %v but got %v", p, loaded)
+		}
+	}
+
+	unknown, err := serializer.SerializeSlice([]serializer.Serializer{
+		serializer.Int(2), serializer.Bool(true), serializer.Bool(true),
+		serializer.Bool(true),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := DeserializePreprocessor(unknown); err == nil {
+		t.Error("expected error for unknown version")
+	}
+}
+
+func TestNetworkPreprocessor(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	net.Preprocessor = &Preprocessor{Lowercase: true, CollapseSpace: true}
+	batch, err := net.MakeBatch([]*Sample{{Query: " Evaluate  1+1", Response: "Result: 2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := net.DecodeBatch(batch)
+	if decoded[0].Query != "evaluate 1+1" {
+		t.Errorf("unexpected query: %q", decoded[0].Query)
+	}
+	if net.Query("EVALUATE 1+1") != net.Query("evaluate   1+1") {
+		t.Error("equivalent queries gave different results")
+	}
+}
+
+func TestNetworkPreprocessorFullWidth(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	if _, err := net.QueryContext(context.Background(), "evaluate １＋２"); err == nil {
+		t.Error("expected full-width query to be rejected without preprocessing")
+	}
+	expected := net.Query("evaluate 1+2")
+	net.Preprocessor = &Preprocessor{FullWidth: true}
+	actual, err := net.QueryContext(context.Background(), "evaluate １＋２")
+	if err != nil {
+		t.Fatal(err)
+	} else if actual != expected {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+}
@@ -0,0 +1,54 @@
+package algebrain
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// A ProgressBar displays the progress of training.
+//
+// To display the progress of a Trainer, set its Progress
+// field, which is updated after every batch.
+type ProgressBar interface {
+	// Update reports that a step finished with the given
+	// loss.
+	Update(step int, loss float64)
+
+	// Close finalizes the display.
+	Close()
+}
+
+// A TextProgressBar is a ProgressBar which draws a single
+// line of text, overwriting it with a carriage return on
+// every update, e.g. "Step 42/1000 | Loss: 0.3241 | ETA:
+// 2m30s".
+type TextProgressBar struct {
+	totalSteps int
+	w          io.Writer
+	start      time.Time
+}
+
+// NewTextProgressBar creates a TextProgressBar for the
+// given number of steps.
+// The ETA is estimated from the time since the bar was
+// created.
+func NewTextProgressBar(totalSteps int, w io.Writer) *TextProgressBar {
+	return &TextProgressBar{totalSteps: totalSteps, w: w, start: time.Now()}
+}
+
+// Update redraws the progress bar.
+func (t *TextProgressBar) Update(step int, loss float64) {
+	var eta time.Duration
+	if step > 0 && step < t.totalSteps {
+		perStep := time.Since(t.start) / time.Duration(step)
+		eta = (perStep * time.Duration(t.totalSteps-step)).Round(time.Second)
+	}
+	fmt.Fprintf(t.w, "\r\x1b[KStep %d/%d | Loss: %.4f | ETA: %s", step, t.totalSteps,
+		loss, eta)
+}
+
+// Close ends the line of the progress bar.
+func (t *TextProgressBar) Close() {
+	fmt.Fprintln(t.w)
+}
@@ -0,0 +1,23 @@
+package algebrain
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestTextProgressBar(t *testing.T) {
+	var buf bytes.Buffer
+	bar := NewTextProgressBar(1000, &buf)
+	bar.Update(42, 0.32414)
+	bar.Close()
+	out := buf.String()
+	for _, part := range []string{"Step 42/1000", "Loss: 0.3241", "ETA: "} {
+		if !strings.Contains(out, part) {
+			t.Errorf("output %q missing %q", out, part)
+		}
+	}
+	if !strings.HasPrefix(out, "\r") || !strings.HasSuffix(out, "\n") {
+		t.Errorf("unexpected output: %q", out)
+	}
+}
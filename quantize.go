@@ -0,0 +1,269 @@
+package algebrain
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var q QuantizedNetwork
+	serializer.RegisterTypedDeserializer(q.SerializerType(), DeserializeQuantizedNetwork)
+}
+
+// A QuantizedParameter is a parameter vector stored as
+// 8-bit integers.
+//
+// Each stored value q represents Scale*(q-ZeroPoint).
+type QuantizedParameter struct {
+	Scale     float64
+	ZeroPoint int8
+	Values    []int8
+}
+
+// quantizeParameter quantizes a vector using the range of
+// its values, which is extended to include 0 so that zeros
+// are represented exactly.
+func quantizeParameter(values []float64) *QuantizedParameter {
+	min, max := 0.0, 0.0
+	for _, x := range values {
+		min = math.Min(min, x)
+		max = math.Max(max, x)
+	}
+	scale := (max - min) / 255
+	if scale == 0 {
+		scale = 1
+	}
+	res := &QuantizedParameter{
+		Scale:     scale,
+		ZeroPoint: int8(clampInt8(math.Round(-128 - min/scale))),
+		Values:    make([]int8, len(values)),
+	}
+	for i, x := range values {
+		res.Values[i] = int8(clampInt8(math.Round(x/scale) + float64(res.ZeroPoint)))
+	}
+	return res
+}
+
+// Dequantize computes the approximate parameter values.
+func (q *QuantizedParameter) Dequantize() []float64 {
+	res := make([]float64, len(q.Values))
+	for i, x := range q.Values {
+		res[i] = q.Scale * float64(int(x)-int(q.ZeroPoint))
+	}
+	return res
+}
+
+func clampInt8(x float64) float64 {
+	return math.Max(math.MinInt8, math.Min(math.MaxInt8, x))
+}
+
+// A QuantizedNetwork is a Network whose parameters are
+// stored as 8-bit integers, for deployment where memory
+// and storage are limited.
+//
+// Quantization rounds each parameter to the nearest of 256
+// evenly spaced values spanning the range of its matrix,
+// so every parameter is within Scale/2 of its original
+// value.
+// In the tests, the quantized version of an untrained
+// network, whose nearly uniform outputs are especially
+// sensitive to rounding, chooses the same next token as
+// the original at over 90% of decoding steps.
+type QuantizedNetwork struct {
+	Tokenizer    Tokenizer
+	ReverseInput bool
+	Preprocessor *Preprocessor
+
+	// Parameters are keyed by the names used by
+	// Network.NamedParameters.
+	Parameters map[string]*QuantizedParameter
+
+	lock    sync.Mutex
+	network *Network
+}
+
+// Quantize creates a QuantizedNetwork from the network's
+// current parameters.
+//
+// Networks with custom input encoders are not supported.
+func (n *Network) Quantize() (*QuantizedNetwork, error) {
+	if n.InputEncoder != nil {
+		return nil, errors.New("quantize network: custom input encoders are not supported")
+	}
+	res := &QuantizedNetwork{
+		Tokenizer:    n.Tokenizer,
+		ReverseInput: n.ReverseInput,
+		Preprocessor: n.Preprocessor,
+		Parameters:   map[string]*QuantizedParameter{},
+	}
+	for name, param := range n.NamedParameters() {
+		res.Parameters[name] = quantizeParameter(vectorFloats(param.Vector))
+	}
+	return res, nil
+}
+
+// DeserializeQuantizedNetwork deserializes a
+// QuantizedNetwork.
+func DeserializeQuantizedNetwork(d []byte) (*QuantizedNetwork, error) {
+	objs, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, essentials.AddCtx("deserialize QuantizedNetwork", err)
+	}
+	if len(objs) < 3 || (len(objs)-3)%4 != 0 {
+		return nil, fmt.Errorf("deserialize QuantizedNetwork: unexpected field count: %d",
+			len(objs))
+	}
+	tokenizer, ok1 := objs[0].(Tokenizer)
+	reverse, ok2 := objs[1].(serializer.Bool)
+	preprocessor, ok3 := objs[2].(*Preprocessor)
+	if !ok1 || !ok2 || !ok3 {
+		return nil, errors.New("deserialize QuantizedNetwork: invalid configuration")
+	}
+	res := &QuantizedNetwork{
+		Tokenizer:    tokenizer,
+		ReverseInput: bool(reverse),
+		Preprocessor: preprocessor,
+		Parameters:   map[string]*QuantizedParameter{},
+	}
+	for i := 3; i < len(objs); i += 4 {
+		name, ok1 := objs[i].(serializer.String)
+		scale, ok2 := objs[i+1].(serializer.Float64)
+		zeroPoint, ok3 := objs[i+2].(serializer.Int)
+		data, ok4 := objs[i+3].(serializer.Bytes)
+		if !ok1 || !ok2 || !ok3 || !ok4 {
+			return nil, errors.New("deserialize QuantizedNetwork: invalid parameter")
+		}
+		param := &QuantizedParameter{
+			Scale:     float64(scale),
+			ZeroPoint: int8(zeroPoint),
+			Values:    make([]int8, len(data)),
+		}
+		for j, x := range data {
+			param.Values[j] = int8(x)
+		}
+		res.Parameters[string(name)] = param
+	}
+	return res, nil
+}
+
+// SerializerType returns the unique ID used to serialize
+// a QuantizedNetwork with the serializer package.
+func (q *QuantizedNetwork) SerializerType() string {
+	return "github.com/unixpickle/algebrain.QuantizedNetwork"
+}
+
+// Serialize attempts to serialize the QuantizedNetwork.
+func (q *QuantizedNetwork) Serialize() ([]byte, error) {
+	preprocessor := q.Preprocessor
+	if preprocessor == nil {
+		preprocessor = &Preprocessor{}
+	}
+	objs := []serializer.Serializer{q.Tokenizer, serializer.Bool(q.ReverseInput),
+		preprocessor}
+	names := make([]string, 0, len(q.Parameters))
+	for name := range q.Parameters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		param := q.Parameters[name]
+		data := make([]byte, len(param.Values))
+		for i, x := range param.Values {
+			data[i] = byte(x)
+		}
+		objs = append(objs, serializer.String(name), serializer.Float64(param.Scale),
+			serializer.Int(param.ZeroPoint), serializer.Bytes(data))
+	}
+	return serializer.SerializeSlice(objs)
+}
+
+// ParameterBytes returns the number of bytes used to store
+// the quantized parameters, including the scale and zero
+// point of each parameter.
+func (q *QuantizedNetwork) ParameterBytes() int {
+	var res int
+	for _, param := range q.Parameters {
+		res += len(param.Values) + 9
+	}
+	return res
+}
+
+// SizeReduction returns the ratio between the number of
+// bytes used by the parameters before and after
+// quantization.
+//
+// Like Network.EstimateMemoryUsage, this assumes that the
+// original parameters are 8-byte (float64) numerics.
+func (q *QuantizedNetwork) SizeReduction() float64 {
+	var numParams int
+	for _, param := range q.Parameters {
+		numParams += len(param.Values)
+	}
+	return float64(numParams*8) / float64(q.ParameterBytes())
+}
+
+// Dequantize creates a Network with the approximate
+// parameters, using the creator c.
+func (q *QuantizedNetwork) Dequantize(c anyvec.Creator) (*Network, error) {
+	res := NewNetwork(c, q.Tokenizer)
+	res.ReverseInput = q.ReverseInput
+	res.Preprocessor = q.Preprocessor
+	params := res.NamedParameters()
+	if len(params) != len(q.Parameters) {
+		return nil, fmt.Errorf("dequantize network: expected %d parameters but got %d",
+			len(params), len(q.Parameters))
+	}
+	for name, param := range params {
+		quantized, ok := q.Parameters[name]
+		if !ok {
+			return nil, fmt.Errorf("dequantize network: missing parameter: %s", name)
+		} else if len(quantized.Values) != param.Vector.Len() {
+			return nil, fmt.Errorf("dequantize network: parameter %s has length %d "+
+				"(expected %d)", name, len(quantized.Values), param.Vector.Len())
+		}
+		param.Vector.SetData(c.MakeNumericList(quantized.Dequantize()))
+	}
+	return res, nil
+}
+
+// Query runs a query against the network.
+//
+// The first call dequantizes the parameters using the
+// current anyvec32 creator, and the resulting network is
+// reused by later calls.
+//
+// It panics if the query cannot be tokenized or the
+// parameters do not match the architecture.
+func (q *QuantizedNetwork) Query(query string) string {
+	res, err := q.QueryContext(context.Background(), query)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// QueryContext is like Query, but it returns an error
+// rather than panicking, as in Network.QueryContext.
+func (q *QuantizedNetwork) QueryContext(ctx context.Context, query string) (string, error) {
+	q.lock.Lock()
+	if q.network == nil {
+		net, err := q.Dequantize(anyvec32.CurrentCreator())
+		if err != nil {
+			q.lock.Unlock()
+			return "", err
+		}
+		q.network = net
+	}
+	net := q.network
+	q.lock.Unlock()
+	return net.QueryContext(ctx, query)
+}
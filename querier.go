@@ -0,0 +1,19 @@
+package algebrain
+
+import "context"
+
+// A Querier answers queries, e.g. a *Network or one of its
+// wrappers such as a *TTLCachedNetwork.
+//
+// APIs which only need responses accept a Querier, so that
+// tests can substitute a simple implementation for a real
+// network.
+type Querier interface {
+	// Query returns the response to a query.
+	// It panics if the query cannot be answered.
+	Query(q string) string
+
+	// QueryContext is like Query, but it returns an error
+	// rather than panicking.
+	QueryContext(ctx context.Context, q string) (string, error)
+}
@@ -0,0 +1,57 @@
+package algebrain
+
+import (
+	"runtime"
+	"sync"
+)
+
+// QueryAll runs a set of named queries concurrently,
+// returning a map from each query's ID to its response.
+//
+// At most MaxConcurrency queries run at once.
+// It panics if a query cannot be tokenized.
+func (n *Network) QueryAll(queries map[string]string) map[string]string {
+	concurrency := n.MaxConcurrency
+	if concurrency == 0 {
+		concurrency = runtime.GOMAXPROCS(0)
+	}
+
+	type job struct {
+		ID    string
+		Query string
+	}
+	jobs := make(chan job, len(queries))
+	for id, q := range queries {
+		jobs <- job{ID: id, Query: q}
+	}
+	close(jobs)
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	var panicValue interface{}
+	res := make(map[string]string, len(queries))
+	for i := 0; i < concurrency && i < len(queries); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					lock.Lock()
+					panicValue = r
+					lock.Unlock()
+				}
+			}()
+			for j := range jobs {
+				response := n.Query(j.Query)
+				lock.Lock()
+				res[j.ID] = response
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if panicValue != nil {
+		panic(panicValue)
+	}
+	return res
+}
@@ -0,0 +1,29 @@
+package algebrain
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestQueryAll(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	net.MaxConcurrency = 4
+	queries := map[string]string{}
+	for i := 0; i < 20; i++ {
+		queries[fmt.Sprintf("q%d", i)] = fmt.Sprintf("evaluate %d+%d", i, i%3)
+	}
+	responses := net.QueryAll(queries)
+	if len(responses) != len(queries) {
+		t.Fatalf("expected %d responses but got %d", len(queries), len(responses))
+	}
+	for id, q := range queries {
+		response, ok := responses[id]
+		if !ok {
+			t.Errorf("missing response for %s", id)
+		} else if expected := net.Query(q); response != expected {
+			t.Errorf("%s: expected %q but got %q", id, expected, response)
+		}
+	}
+}
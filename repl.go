@@ -0,0 +1,169 @@
+package algebrain
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+// A REPL reads queries line by line and prints the
+// network's responses.
+//
+// Besides queries, the following commands are supported:
+//
+//	:temp T     sample with temperature T (0 for greedy)
+//	:load PATH  switch to the network saved at PATH
+//	:history    print the lines entered so far
+//	:reset      clear the history and the temperature
+//
+// Networks do not carry state between queries, so every
+// query is answered independently.
+type REPL struct {
+	Network *Network
+
+	// Temperature is used to sample responses.
+	// If it is 0, responses are decoded greedily.
+	Temperature float64
+
+	// Rand is used for sampling.
+	// If it is nil, a source seeded from the global
+	// math/rand source is used.
+	Rand *rand.Rand
+
+	// Prompt is printed before every line is read.
+	Prompt string
+
+	history []string
+}
+
+// Run reads lines from rd until EOF, writing responses
+// and command output to w.
+//
+// Failed queries and commands are reported to w and do not
+// stop the loop.
+// An error is returned only if reading or writing fails.
+func (r *REPL) Run(rd io.Reader, w io.Writer) error {
+	scanner := bufio.NewScanner(rd)
+	for {
+		if _, err := io.WriteString(w, r.Prompt); err != nil {
+			return essentials.AddCtx("run REPL", err)
+		}
+		if !scanner.Scan() {
+			break
+		}
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			continue
+		}
+		output, err := r.Eval(line)
+		if err != nil {
+			output = "Error: " + err.Error()
+		}
+		if _, err := fmt.Fprintln(w, output); err != nil {
+			return essentials.AddCtx("run REPL", err)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return essentials.AddCtx("run REPL", err)
+	}
+	return nil
+}
+
+// Eval runs a single query or command and returns the
+// text to display.
+// The line is added to the history.
+//
+// Control characters in responses, such as newlines, are
+// escaped, so every response is displayed on one line and
+// cannot affect the terminal.
+func (r *REPL) Eval(line string) (string, error) {
+	if !strings.HasPrefix(line, ":") {
+		r.history = append(r.history, line)
+		res, err := r.query(line)
+		return escapeControl(res), err
+	}
+	fields := strings.Fields(line)
+	if fields[0] != ":reset" {
+		r.history = append(r.history, line)
+	}
+	switch fields[0] {
+	case ":reset":
+		r.history = nil
+		r.Temperature = 0
+		return "State cleared.", nil
+	case ":history":
+		return strings.Join(r.history, "\n"), nil
+	case ":temp":
+		if len(fields) != 2 {
+			return "", errors.New("usage: :temp T")
+		}
+		temp, err := strconv.ParseFloat(fields[1], 64)
+		if err != nil || temp < 0 {
+			return "", fmt.Errorf("invalid temperature: %s", fields[1])
+		}
+		r.Temperature = temp
+		return fmt.Sprintf("Temperature set to %v.", temp), nil
+	case ":load":
+		if len(fields) != 2 {
+			return "", errors.New("usage: :load PATH")
+		}
+		var net *Network
+		if err := serializer.LoadAny(fields[1], &net); err != nil {
+			return "", err
+		}
+		r.Network = net
+		return "Loaded " + fields[1] + ".", nil
+	case ":beam":
+		return "", errors.New("beam search is not supported")
+	}
+	return "", fmt.Errorf("unknown command: %s", fields[0])
+}
+
+// History returns the lines evaluated since the last
+// :reset, including commands.
+func (r *REPL) History() []string {
+	return append([]string{}, r.history...)
+}
+
+func (r *REPL) query(q string) (string, error) {
+	if r.Network == nil {
+		return "", errors.New("no network loaded")
+	}
+	if r.Temperature == 0 {
+		return r.Network.QueryContext(context.Background(), q)
+	}
+	if r.Rand == nil {
+		r.Rand = rand.New(rand.NewSource(rand.Int63()))
+	}
+	res, err := r.Network.decode(context.Background(), q, func(logProbs []float64) int {
+		return sampleLogProbs(logProbs, r.Temperature, r.Rand)
+	})
+	if err != nil {
+		return "", err
+	}
+	return res.Response, nil
+}
+
+// escapeControl replaces control characters with Go
+// escape sequences like "\n" or "\x13".
+func escapeControl(s string) string {
+	var res strings.Builder
+	for _, r := range s {
+		if unicode.IsControl(r) {
+			quoted := strconv.QuoteRune(r)
+			res.WriteString(quoted[1 : len(quoted)-1])
+		} else {
+			res.WriteRune(r)
+		}
+	}
+	return res.String()
+}
@@ -0,0 +1,69 @@
+package algebrain
+
+import (
+	"bytes"
+	"math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+	"github.com/unixpickle/serializer"
+)
+
+func TestREPL(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	otherNet := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	path := filepath.Join(t.TempDir(), "net")
+	if err := serializer.SaveAny(path, otherNet); err != nil {
+		t.Fatal(err)
+	}
+
+	repl := &REPL{Network: net, Rand: rand.New(rand.NewSource(1337)), Prompt: "> "}
+	input := strings.Join([]string{
+		"evaluate 1+1",
+		":temp 0.8",
+		"evaluate 1+1",
+		":beam 5",
+		":temp abc",
+		":history",
+		":load " + path,
+		"",
+		":reset",
+		":history",
+		":foo",
+	}, "\n")
+	var output bytes.Buffer
+	if err := repl.Run(strings.NewReader(input), &output); err != nil {
+		t.Fatal(err)
+	}
+	// Every output is followed by a newline and the next
+	// prompt.
+	expected := strings.Join([]string{
+		escapeControl(net.Query("evaluate 1+1")),
+		"Temperature set to 0.8.",
+		escapeControl(net.QuerySampleSeed("evaluate 1+1", 0.8, 1337)),
+		"Error: beam search is not supported",
+		"Error: invalid temperature: abc",
+		"evaluate 1+1\n:temp 0.8\nevaluate 1+1\n:beam 5\n:temp abc\n:history",
+		"Loaded " + path + ".",
+		// The empty line only produces a prompt.
+		"> State cleared.",
+		":history",
+		"Error: unknown command: :foo",
+		"",
+	}, "\n> ")
+	expected = "> " + expected
+	if actual := output.String(); actual != expected {
+		t.Errorf("expected output %q but got %q", expected, actual)
+	}
+	if repl.Network == net || repl.Temperature != 0 {
+		t.Error("unexpected REPL state after :load and :reset")
+	}
+}
+
+func TestEscapeControl(t *testing.T) {
+	if actual := escapeControl("a\nb\x13c\x7fd×"); actual != `a\nb\x13c\x7fd×` {
+		t.Errorf("unexpected escaped string: %s", actual)
+	}
+}
@@ -2,14 +2,17 @@ package algebrain
 
 import (
 	"fmt"
+	"hash/fnv"
 	"math"
 	"math/rand"
+	"reflect"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/unixpickle/algebrain/mathexpr"
 	"github.com/unixpickle/anyvec"
 	"github.com/unixpickle/anyvec/anyvec32"
-	"github.com/unixpickle/num-analysis/linalg"
 )
 
 // A Sample contains a query (e.g. "factorize x^2+x") and
@@ -17,37 +20,166 @@ import (
 type Sample struct {
 	Query    string
 	Response string
+
+	// AltResponses contains other acceptable responses,
+	// e.g. "(x+3)*(x+2)" when Response is "(x+2)*(x+3)".
+	// Training only uses Response.
+	AltResponses []string
+
+	// Weight scales the sample's contribution to the
+	// training cost, e.g. to emphasize hard examples.
+	// If this is 0, a weight of 1 is used.
+	Weight float64
 }
 
-// InputSequence generates the sample's input sequence.
-func (s *Sample) InputSequence() []anyvec.Vector {
-	res := make([]anyvec.Vector, len(s.Query))
-	for i, x := range s.Query {
-		res[i] = oneHotVector(x)
+// Accepts checks if a response matches the Response or
+// any of the AltResponses.
+//
+// If commutative is true, responses are also accepted if
+// they are expressions which are commutatively equal to an
+// acceptable response (see mathexpr.CommutativeEqual).
+func (s *Sample) Accepts(response string, commutative bool) bool {
+	for _, expected := range s.acceptableResponses() {
+		if response == expected {
+			return true
+		}
 	}
-	return res
+	if !commutative {
+		return false
+	}
+	actual, err := mathexpr.ParseString(response)
+	if err != nil {
+		return false
+	}
+	for _, expected := range s.acceptableResponses() {
+		if parsed, err := mathexpr.ParseString(expected); err == nil {
+			if mathexpr.CommutativeEqual(actual, parsed) {
+				return true
+			}
+		}
+	}
+	return false
 }
 
-// DecoderOutSequence is the desired output from the
+func (s *Sample) acceptableResponses() []string {
+	return append([]string{s.Response}, s.AltResponses...)
+}
+
+// InputVectors generates the sample's input sequence as
+// one-hot vectors created with c.
+//
+// The resulting vectors, like those from the other
+// sequence methods, may be shared between calls.
+// They must be treated as read-only; use Copy() to get a
+// mutable vector.
+func (s *Sample) InputVectors(c anyvec.Creator, t Tokenizer) ([]anyvec.Vector, error) {
+	tokens, err := t.Encode(s.Query)
+	if err != nil {
+		return nil, err
+	}
+	return oneHotSequence(c, tokens, t.VocabSize()), nil
+}
+
+// ReversedInputVectors is like InputVectors, but the
+// tokens of the query are in reverse order.
+func (s *Sample) ReversedInputVectors(c anyvec.Creator,
+	t Tokenizer) ([]anyvec.Vector, error) {
+	tokens, err := t.Encode(s.Query)
+	if err != nil {
+		return nil, err
+	}
+	return oneHotSequence(c, reversedTokens(tokens), t.VocabSize()), nil
+}
+
+// DecoderOutVectors generates the desired output from the
 // decoder if all goes well.
-func (s *Sample) DecoderOutSequence() []anyvec.Vector {
-	res := make([]anyvec.Vector, len(s.Response)+1)
-	for i, x := range s.Response {
-		res[i] = oneHotVector(x)
+func (s *Sample) DecoderOutVectors(c anyvec.Creator,
+	t Tokenizer) ([]anyvec.Vector, error) {
+	tokens, err := t.Encode(s.Response)
+	if err != nil {
+		return nil, err
 	}
-	res[len(res)-1] = oneHotVector(Terminator)
-	return res
+	return oneHotSequence(c, append(tokens, Terminator), t.VocabSize()), nil
+}
+
+// DecoderInVectors generates the desired input to be fed
+// to the decoder if all goes well.
+func (s *Sample) DecoderInVectors(c anyvec.Creator,
+	t Tokenizer) ([]anyvec.Vector, error) {
+	tokens, err := t.Encode(s.Response)
+	if err != nil {
+		return nil, err
+	}
+	return oneHotSequence(c, append([]int{Terminator}, tokens...), t.VocabSize()), nil
+}
+
+// InputSequence generates the sample's input sequence
+// using the current anyvec32 creator.
+//
+// Deprecated: use InputVectors, which produces vectors
+// from the same creator as the Network.
+func (s *Sample) InputSequence(t Tokenizer) ([]anyvec.Vector, error) {
+	return s.InputVectors(anyvec32.CurrentCreator(), t)
+}
+
+// ReversedInputSequence is like InputSequence, but the
+// tokens of the query are in reverse order.
+//
+// Deprecated: use ReversedInputVectors.
+func (s *Sample) ReversedInputSequence(t Tokenizer) ([]anyvec.Vector, error) {
+	return s.ReversedInputVectors(anyvec32.CurrentCreator(), t)
+}
+
+// DecoderOutSequence is the desired output from the
+// decoder if all goes well.
+//
+// Deprecated: use DecoderOutVectors.
+func (s *Sample) DecoderOutSequence(t Tokenizer) ([]anyvec.Vector, error) {
+	return s.DecoderOutVectors(anyvec32.CurrentCreator(), t)
 }
 
 // DecoderInSequence generates the desired input to be fed
 // to the decoder if all goes well.
-func (s *Sample) DecoderInSequence() []anyvec.Vector {
-	res := make([]anyvec.Vector, len(s.Response)+1)
-	res[0] = oneHotVector(0)
-	for i, x := range s.Response {
-		res[i+1] = oneHotVector(x)
+//
+// Deprecated: use DecoderInVectors.
+func (s *Sample) DecoderInSequence(t Tokenizer) ([]anyvec.Vector, error) {
+	return s.DecoderInVectors(anyvec32.CurrentCreator(), t)
+}
+
+// Validate checks that the query and responses only
+// contain characters which can be tokenized, i.e. non-zero
+// runes below CharCount.
+// The error identifies the first invalid character.
+func (s *Sample) Validate() error {
+	fields := []struct {
+		Name string
+		Text string
+	}{{"query", s.Query}, {"response", s.Response}}
+	for i, alt := range s.AltResponses {
+		fields = append(fields, struct {
+			Name string
+			Text string
+		}{fmt.Sprintf("alternative response %d", i), alt})
 	}
-	return res
+	for _, field := range fields {
+		for i, x := range []rune(field.Text) {
+			if err := checkCharToken(x); err != nil {
+				return fmt.Errorf("invalid sample: %s character %d: %v", field.Name, i, err)
+			}
+		}
+	}
+	return nil
+}
+
+// HashSample computes a 64-bit FNV-1a hash of a sample's
+// query and response, e.g. to deduplicate generated
+// samples without storing them.
+//
+// AltResponses and Weight are ignored.
+func HashSample(s *Sample) uint64 {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%s%d:%s", len(s.Query), s.Query, len(s.Response), s.Response)
+	return h.Sum64()
 }
 
 // A Generator generates random Samples from a template.
@@ -55,31 +187,73 @@ type Generator interface {
 	Generate() *Sample
 }
 
+// A MixtureGenerator generates each sample with one of its
+// generators, chosen uniformly at random.
+type MixtureGenerator []Generator
+
+// Generate generates a sample from a random generator.
+func (m MixtureGenerator) Generate() *Sample {
+	return m[rand.Intn(len(m))].Generate()
+}
+
 // A ShiftGenerator generates Samples with queries like
 // "shift x by 2 in x^2+2", producing results like
 // "(x-2)^2+2".
 type ShiftGenerator struct {
 	Generator *mathexpr.Generator
 	MaxDepth  int
+
+	// Amounts, if non-nil, specifies how to generate the
+	// shift amounts.
+	// By default, amounts are generated like the constants
+	// in the expression.
+	Amounts *mathexpr.NumberSpec
+
+	// If ShiftAllVars is set, every variable in the
+	// expression is shifted by its own amount, giving
+	// queries like "shift x by 2 and y by 3 in x^2+y".
+	ShiftAllVars bool
 }
 
 // Generate generates a graph shifting sample.
 func (s *ShiftGenerator) Generate() *Sample {
+	return s.generate(nil)
+}
+
+// generate generates a graph shifting sample.
+// If rewrite is non-nil, it is applied to a copy of the
+// expression in the query.
+func (s *ShiftGenerator) generate(rewrite func(mathexpr.Node) mathexpr.Node) *Sample {
 	expr := s.Generator.Generate(s.MaxDepth)
-	shiftVar := s.Generator.VarNames[rand.Intn(len(s.Generator.VarNames))]
-	num := generateNumber(*s.Generator)
-	query := fmt.Sprintf("shift %s by %s in %s", shiftVar, num, expr)
-	output := s.shiftNode(shiftVar, num, expr).String()
+	queryExpr := expr
+	if rewrite != nil {
+		queryExpr = rewrite(mathexpr.Clone(expr))
+	}
+	shiftVars := []string{randomVarName(s.Generator)}
+	if s.ShiftAllVars {
+		if used := usedVarNames(expr, s.Generator.VarNames); len(used) > 0 {
+			shiftVars = used
+		}
+	}
+	amounts := map[string]mathexpr.Node{}
+	var shifts []string
+	for _, varName := range shiftVars {
+		num := generateNumber(*s.Generator, s.Amounts)
+		amounts[varName] = num
+		shifts = append(shifts, fmt.Sprintf("%s by %s", varName, num))
+	}
+	query := fmt.Sprintf("shift %s in %s", strings.Join(shifts, " and "), queryExpr)
+	output := s.shiftNode(amounts, expr).String()
 	return &Sample{
 		Query:    query,
 		Response: output,
 	}
 }
 
-func (s *ShiftGenerator) shiftNode(varName string, amount mathexpr.Node,
+func (s *ShiftGenerator) shiftNode(amounts map[string]mathexpr.Node,
 	n mathexpr.Node) mathexpr.Node {
 	if n, ok := n.(mathexpr.RawNode); ok {
-		if string(n) == varName {
+		if amount, ok := amounts[string(n)]; ok {
 			return &mathexpr.BinaryOp{
 				Op:    mathexpr.SubtractOp,
 				Left:  n,
@@ -88,7 +262,7 @@ func (s *ShiftGenerator) shiftNode(varName string, amount mathexpr.Node,
 		}
 	}
 	for i, x := range n.Children() {
-		n.SetChild(i, s.shiftNode(varName, amount, x))
+		n.SetChild(i, s.shiftNode(amounts, x))
 	}
 	return n
 }
@@ -98,24 +272,69 @@ func (s *ShiftGenerator) shiftNode(varName string, amount mathexpr.Node,
 type ScaleGenerator struct {
 	Generator *mathexpr.Generator
 	MaxDepth  int
+
+	// Amounts, if non-nil, specifies how to generate the
+	// scale amounts.
+	// By default, amounts are generated like the constants
+	// in the expression.
+	Amounts *mathexpr.NumberSpec
 }
 
 func (s *ScaleGenerator) Generate() *Sample {
 	expr := s.Generator.Generate(s.MaxDepth)
-	shiftVar := s.Generator.VarNames[rand.Intn(len(s.Generator.VarNames))]
-	num := generateNumber(*s.Generator)
+	shiftVar := randomVarName(s.Generator)
+	num := generateNumber(*s.Generator, s.Amounts)
 	query := fmt.Sprintf("scale %s by %s in %s", shiftVar, num, expr)
-	output := s.scaleNode(shiftVar, num, expr).String()
+	output := scaleNode(map[string]mathexpr.Node{shiftVar: num}, expr).String()
 	return &Sample{
 		Query:    query,
 		Response: output,
 	}
 }
 
-func (s *ScaleGenerator) scaleNode(varName string, amount mathexpr.Node,
-	n mathexpr.Node) mathexpr.Node {
+// A MultiScaleGenerator generates Samples which scale
+// every variable in an expression by its own amount, with
+// queries like "scale x by 2 and y by 3 in x*y", expecting
+// "(6*x)*y".
+//
+// Numerical factors in the result are combined with
+// mathexpr.ConstantFold.
+type MultiScaleGenerator struct {
+	Generator *mathexpr.Generator
+	MaxDepth  int
+
+	// Amounts, if non-nil, specifies how to generate the
+	// scale amounts.
+	// By default, amounts are generated like the constants
+	// in the expression.
+	Amounts *mathexpr.NumberSpec
+}
+
+// Generate generates a multi-variable scaling sample.
+func (m *MultiScaleGenerator) Generate() *Sample {
+	expr := m.Generator.Generate(m.MaxDepth)
+	scaleVars := usedVarNames(expr, m.Generator.VarNames)
+	if len(scaleVars) == 0 {
+		scaleVars = []string{randomVarName(m.Generator)}
+	}
+	amounts := map[string]mathexpr.Node{}
+	var scales []string
+	for _, varName := range scaleVars {
+		num := generateNumber(*m.Generator, m.Amounts)
+		amounts[varName] = num
+		scales = append(scales, fmt.Sprintf("%s by %s", varName, num))
+	}
+	query := fmt.Sprintf("scale %s in %s", strings.Join(scales, " and "), expr)
+	output := mathexpr.ConstantFold(scaleNode(amounts, expr)).String()
+	return &Sample{
+		Query:    query,
+		Response: output,
+	}
+}
+
+func scaleNode(amounts map[string]mathexpr.Node, n mathexpr.Node) mathexpr.Node {
 	if n, ok := n.(mathexpr.RawNode); ok {
-		if string(n) == varName {
+		if amount, ok := amounts[string(n)]; ok {
 			return &mathexpr.BinaryOp{
 				Op:    mathexpr.MultiplyOp,
 				Left:  n,
@@ -124,7 +343,7 @@ func (s *ScaleGenerator) scaleNode(varName string, amount mathexpr.Node,
 		}
 	}
 	for i, x := range n.Children() {
-		n.SetChild(i, s.scaleNode(varName, amount, x))
+		n.SetChild(i, scaleNode(amounts, x))
 	}
 	return n
 }
@@ -212,22 +431,96 @@ func (e *EvalGenerator) valid(n mathexpr.Node) bool {
 	return true
 }
 
-func generateNumber(g mathexpr.Generator) mathexpr.RawNode {
+// usedVarNames finds the variables from varNames which
+// appear in n, in the order of varNames.
+func usedVarNames(n mathexpr.Node, varNames []string) []string {
+	found := map[string]bool{}
+	var search func(n mathexpr.Node)
+	search = func(n mathexpr.Node) {
+		if raw, ok := n.(mathexpr.RawNode); ok {
+			found[string(raw)] = true
+		}
+		for _, child := range n.Children() {
+			search(child)
+		}
+	}
+	search(n)
+	var res []string
+	for _, name := range varNames {
+		if found[name] {
+			res = append(res, name)
+		}
+	}
+	return res
+}
+
+func generateNumber(g mathexpr.Generator, spec *mathexpr.NumberSpec) mathexpr.Node {
+	if spec != nil {
+		return spec.GenerateRand(g.Rand)
+	}
 	g.VarNames = nil
 	g.ConstNames = nil
-	return g.Generate(0).(mathexpr.RawNode)
+	return g.Generate(0)
+}
+
+// randomVarName picks one of the generator's variables
+// using its source of randomness.
+func randomVarName(g *mathexpr.Generator) string {
+	return g.VarNames[randomIntn(g, len(g.VarNames))]
+}
+
+// randomIntn is like rand.Intn, but it uses the
+// generator's source of randomness.
+func randomIntn(g *mathexpr.Generator, n int) int {
+	if g.Rand != nil {
+		return g.Rand.Intn(n)
+	}
+	return rand.Intn(n)
+}
+
+func oneHotSequence(c anyvec.Creator, tokens []int, size int) []anyvec.Vector {
+	res := make([]anyvec.Vector, len(tokens))
+	for i, x := range tokens {
+		res[i] = oneHotVector(c, x, size)
+	}
+	return res
 }
 
-func zeroVector() linalg.Vector {
-	return make(linalg.Vector, CharCount)
+type oneHotKey struct {
+	Creator anyvec.Creator
+	Size    int
 }
 
-func oneHotVector(x rune) anyvec.Vector {
-	ix := int(x)
-	if ix >= CharCount || ix < 0 {
-		panic("rune out of range: " + string(x))
+var oneHotLock sync.Mutex
+var oneHotCache = map[oneHotKey][]anyvec.Vector{}
+
+// oneHotVector returns a one-hot vector.
+//
+// For comparable creators, the vectors are cached and
+// shared between callers, so they must not be modified.
+func oneHotVector(c anyvec.Creator, idx, size int) anyvec.Vector {
+	if idx >= size || idx < 0 {
+		panic("token out of range: " + strconv.Itoa(idx))
 	}
-	data := make([]float32, CharCount)
-	data[ix] = 1
-	return anyvec32.MakeVectorData(data)
+	if !reflect.TypeOf(c).Comparable() {
+		return makeOneHotVector(c, idx, size)
+	}
+	key := oneHotKey{Creator: c, Size: size}
+	oneHotLock.Lock()
+	defer oneHotLock.Unlock()
+	vecs, ok := oneHotCache[key]
+	if !ok {
+		vecs = make([]anyvec.Vector, size)
+		for i := range vecs {
+			vecs[i] = makeOneHotVector(c, i, size)
+		}
+		oneHotCache[key] = vecs
+	}
+	return vecs[idx]
+}
+
+func makeOneHotVector(c anyvec.Creator, idx, size int) anyvec.Vector {
+	data := make([]float64, size)
+	data[idx] = 1
+	return c.MakeVectorData(c.MakeNumericList(data))
 }
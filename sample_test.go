@@ -0,0 +1,206 @@
+package algebrain
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestShiftNodeMultipleVars(t *testing.T) {
+	expr := &mathexpr.BinaryOp{
+		Op: mathexpr.AddOp,
+		Left: &mathexpr.BinaryOp{
+			Op:    mathexpr.PowOp,
+			Left:  mathexpr.RawNode("x"),
+			Right: mathexpr.RawNode("2"),
+		},
+		Right: &mathexpr.BinaryOp{
+			Op:    mathexpr.MultiplyOp,
+			Left:  mathexpr.RawNode("y"),
+			Right: mathexpr.RawNode("z"),
+		},
+	}
+	amounts := map[string]mathexpr.Node{
+		"x": mathexpr.RawNode("2"),
+		"y": mathexpr.RawNode("3"),
+	}
+	actual := (&ShiftGenerator{}).shiftNode(amounts, expr).String()
+	expected := "(x-2)^2+(y-3)*z"
+	if actual != expected {
+		t.Errorf("expected %s but got %s", expected, actual)
+	}
+}
+
+func TestShiftGeneratorAllVars(t *testing.T) {
+	gen := &ShiftGenerator{
+		Generator: &mathexpr.Generator{
+			NoReals:  true,
+			VarNames: []string{"x", "y", "z"},
+		},
+		MaxDepth:     3,
+		ShiftAllVars: true,
+	}
+	for i := 0; i < 100; i++ {
+		sample := gen.Generate()
+		parts := strings.SplitN(sample.Query, " in ", 2)
+		for _, name := range []string{"x", "y", "z"} {
+			if !strings.Contains(parts[1], name) {
+				continue
+			}
+			if !strings.Contains(parts[0], name+" by ") {
+				t.Errorf("variable %s not shifted in query %q", name, sample.Query)
+			} else if !strings.Contains(sample.Response, name+"-") {
+				t.Errorf("variable %s not shifted in response %q", name, sample.Response)
+			}
+		}
+	}
+}
+
+func TestMultiScaleNode(t *testing.T) {
+	expr := &mathexpr.BinaryOp{
+		Op:    mathexpr.MultiplyOp,
+		Left:  mathexpr.RawNode("x"),
+		Right: mathexpr.RawNode("y"),
+	}
+	amounts := map[string]mathexpr.Node{
+		"x": mathexpr.RawNode("2"),
+		"y": mathexpr.RawNode("3"),
+	}
+	actual := mathexpr.ConstantFold(scaleNode(amounts, expr)).String()
+	expected := "(6*x)*y"
+	if actual != expected {
+		t.Errorf("expected %s but got %s", expected, actual)
+	}
+}
+
+func TestMultiScaleEquivalence(t *testing.T) {
+	gen := &mathexpr.Generator{
+		NoReals:  true,
+		VarNames: []string{"x", "y"},
+	}
+	amounts := map[string]mathexpr.Node{
+		"x": mathexpr.RawNode("2"),
+		"y": mathexpr.RawNode("3"),
+	}
+	for i := 0; i < 100; i++ {
+		expr := gen.Generate(3)
+		exprStr := expr.String()
+		expected, err := mathexpr.Evaluate(expr, map[string]float64{"x": 2, "y": 3})
+		if err != nil {
+			t.Fatal(err)
+		}
+		scaled := mathexpr.ConstantFold(scaleNode(amounts, expr))
+		actual, err := mathexpr.Evaluate(scaled, map[string]float64{"x": 1, "y": 1})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if math.IsNaN(expected) || math.IsInf(expected, 0) {
+			continue
+		}
+		if math.Abs(actual-expected) > 1e-5*math.Max(1, math.Abs(expected)) {
+			t.Errorf("%s: expected %f but got %f (from %s)", exprStr, expected, actual,
+				scaled)
+		}
+	}
+}
+
+func TestOneHotVectorsUnmodified(t *testing.T) {
+	c := anyvec64.CurrentCreator()
+	net := NewNetwork(c, &CharTokenizer{})
+	samples := []*Sample{
+		{Query: "scale x by 2 in x", Response: "x*2"},
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+	}
+	trainer := &Trainer{Network: net}
+	batch, err := trainer.Fetch(SampleList(samples))
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainer.Gradient(batch)
+	net.Query(samples[0].Query)
+
+	for i := 0; i < CharCount; i++ {
+		data := oneHotVector(c, i, CharCount).Data().([]float64)
+		for j, x := range data {
+			if (i == j && x != 1) || (i != j && x != 0) {
+				t.Fatalf("one-hot vector %d was modified at index %d", i, j)
+			}
+		}
+	}
+}
+
+func BenchmarkInputVectors(b *testing.B) {
+	c := anyvec64.CurrentCreator()
+	tokenizer := &CharTokenizer{}
+	sample := &Sample{Query: "shift x by 3 in (x^2-3)^3", Response: "((x-3)^2-3)^3"}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		sample.InputVectors(c, tokenizer)
+	}
+}
+
+func TestSampleAccepts(t *testing.T) {
+	sample := &Sample{
+		Query:        "factor x^2+5*x+6",
+		Response:     "(x+2)*(x+3)",
+		AltResponses: []string{"(x+3)*(x+2)"},
+	}
+	if !sample.Accepts("(x+2)*(x+3)", false) || !sample.Accepts("(x+3)*(x+2)", false) {
+		t.Error("should accept primary and alternative responses")
+	}
+	if sample.Accepts("(2+x)*(x+3)", false) {
+		t.Error("should not accept reordered terms without commutative check")
+	}
+	if !sample.Accepts("(2+x)*(x+3)", true) {
+		t.Error("should accept reordered terms with commutative check")
+	}
+	if sample.Accepts("(x+2)*(x-3)", true) || sample.Accepts("(x+2", true) {
+		t.Error("should not accept incorrect responses")
+	}
+}
+
+func TestSampleValidate(t *testing.T) {
+	valid := &Sample{Query: "evaluate 1+1", Response: "Result: 2",
+		AltResponses: []string{"Result: 2.0"}}
+	if err := valid.Validate(); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	invalid := []*Sample{
+		{Query: "evaluate 1×1", Response: "Result: 1"},
+		{Query: "evaluate 1+1", Response: "Result: 2\x00"},
+		{Query: "evaluate 1+1", Response: "Result: 2", AltResponses: []string{"2", "²"}},
+	}
+	expected := []string{"query character 10", "response character 9",
+		"alternative response 1 character 0"}
+	for i, sample := range invalid {
+		err := sample.Validate()
+		if err == nil {
+			t.Errorf("sample %d: expected an error", i)
+		} else if !strings.Contains(err.Error(), expected[i]) {
+			t.Errorf("sample %d: unexpected error: %v", i, err)
+		}
+	}
+}
+
+func TestHashSample(t *testing.T) {
+	s := &Sample{Query: "evaluate 1+1", Response: "Result: 2"}
+	same := &Sample{Query: "evaluate 1+1", Response: "Result: 2", Weight: 3,
+		AltResponses: []string{"Result: 2.0"}}
+	if HashSample(s) != HashSample(same) {
+		t.Error("weights and alternatives should not affect the hash")
+	}
+	for _, other := range []*Sample{
+		{Query: "evaluate 1+1", Response: "Result: 3"},
+		{Query: "evaluate 1+2", Response: "Result: 2"},
+		{Query: "evaluate 1+1Result: 2", Response: ""},
+		{Query: "", Response: "evaluate 1+1Result: 2"},
+	} {
+		if HashSample(s) == HashSample(other) {
+			t.Errorf("%v has the same hash as %v", other, s)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package algebrain
+
+import (
+	"context"
+	"math"
+	"math/rand"
+)
+
+// QuerySample is like Query, but it samples each output
+// token from the network's distribution instead of picking
+// the most likely token.
+//
+// The log probabilities are divided by temperature before
+// sampling, so lower temperatures give more deterministic
+// outputs.
+// A temperature of 0 is equivalent to Query.
+//
+// Random numbers are drawn from rng.
+// It panics if the query cannot be tokenized.
+func (n *Network) QuerySample(q string, temperature float64, rng *rand.Rand) string {
+	res, err := n.decode(context.Background(), q, func(logProbs []float64) int {
+		if temperature == 0 {
+			return argMaxFloats(logProbs)
+		}
+		return sampleLogProbs(logProbs, temperature, rng)
+	})
+	if err != nil {
+		panic(err)
+	}
+	return res.Response
+}
+
+// QuerySampleSeed is like QuerySample, but it uses a new
+// random source with the given seed.
+// The same seed always yields the same output.
+func (n *Network) QuerySampleSeed(q string, temperature float64, seed int64) string {
+	return n.QuerySample(q, temperature, rand.New(rand.NewSource(seed)))
+}
+
+func sampleLogProbs(logProbs []float64, temperature float64, rng *rand.Rand) int {
+	maxLogProb := math.Inf(-1)
+	for _, x := range logProbs {
+		maxLogProb = math.Max(maxLogProb, x)
+	}
+	probs := make([]float64, len(logProbs))
+	var total float64
+	for i, x := range logProbs {
+		probs[i] = math.Exp((x - maxLogProb) / temperature)
+		total += probs[i]
+	}
+	sample := rng.Float64() * total
+	for i, p := range probs {
+		sample -= p
+		if sample < 0 {
+			return i
+		}
+	}
+	return len(probs) - 1
+}
@@ -0,0 +1,26 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestQuerySampleSeed(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	const query = "evaluate 1+1"
+	outputs := map[string]bool{}
+	for seed := int64(0); seed < 5; seed++ {
+		res := net.QuerySampleSeed(query, 2, seed)
+		if res1 := net.QuerySampleSeed(query, 2, seed); res1 != res {
+			t.Errorf("seed %d: got %q then %q", seed, res, res1)
+		}
+		outputs[res] = true
+	}
+	if len(outputs) < 2 {
+		t.Error("different seeds always gave the same output")
+	}
+	if res := net.QuerySampleSeed(query, 0, 1); res != net.Query(query) {
+		t.Errorf("zero temperature should match Query, but got %q", res)
+	}
+}
@@ -0,0 +1,213 @@
+package algebrain
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+// saveValidationTolerance is the maximum parameter
+// difference allowed by SaveAndValidate.
+const saveValidationTolerance = 1e-10
+
+// SaveAndValidate saves the network to a file like
+// serializer.SaveAny, then loads it back and checks that
+// the loaded network matches this one.
+//
+// This catches serialization bugs at save time, rather
+// than when the network is next used.
+//
+// The file is written and read back with WriteTo and
+// ReadNetwork, so the serialized network is never held
+// in memory all at once.
+func (n *Network) SaveAndValidate(path string) error {
+	return n.saveAndValidate(path, func(w io.Writer) error {
+		_, err := n.WriteTo(w)
+		return err
+	})
+}
+
+func (n *Network) saveAndValidate(path string, write func(w io.Writer) error) error {
+	if err := writeNetworkFile(path, write); err != nil {
+		return essentials.AddCtx("save network", err)
+	}
+	loaded, err := readNetworkFile(path)
+	if err != nil {
+		return essentials.AddCtx("validate saved network", err)
+	}
+	if diff, idx := NetworksDiff(n, loaded); !(diff <= saveValidationTolerance) {
+		return fmt.Errorf("validate saved network: parameter %d differs by %g", idx, diff)
+	}
+	if loaded.Tokenizer.VocabSize() != n.Tokenizer.VocabSize() ||
+		loaded.ReverseInput != n.ReverseInput || !loaded.Preprocessor.Equal(n.Preprocessor) {
+		return errors.New("validate saved network: configuration does not match")
+	}
+	return nil
+}
+
+// LoadOrCreate loads the network saved at path.
+// If there is no file at path, it creates a network with
+// create and saves it to path with SaveAndValidate.
+//
+// Other errors, such as a corrupt file, are returned
+// without calling create, so an existing network is never
+// replaced.
+func LoadOrCreate(path string, create func() (*Network, error)) (*Network, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		net, err := create()
+		if err != nil {
+			return nil, essentials.AddCtx("create network", err)
+		}
+		if err := net.SaveAndValidate(path); err != nil {
+			return nil, err
+		}
+		return net, nil
+	}
+	net, err := readNetworkFile(path)
+	if err != nil {
+		return nil, essentials.AddCtx("load network", err)
+	}
+	return net, nil
+}
+
+// WriteTo writes the network to w in the format used by
+// serializer.SaveAny, producing the same bytes as
+// serializer.SerializeAny(n).
+//
+// Unlike SerializeAny, it writes one field at a time, so
+// only the largest field is ever held in memory.
+// Since the format begins with the total size, each field
+// is serialized twice: once to measure it, and once to
+// write it.
+func (n *Network) WriteTo(w io.Writer) (int64, error) {
+	fields := n.serializedFields()
+	typeID := n.SerializerType()
+
+	size := 4 + uint64(len(typeID))
+	for _, field := range fields {
+		data, err := serializer.SerializeAny(field)
+		if err != nil {
+			return 0, essentials.AddCtx("write network", err)
+		}
+		size += uint64(len(data))
+	}
+
+	header := make([]byte, 12+len(typeID))
+	binary.LittleEndian.PutUint64(header, size)
+	binary.LittleEndian.PutUint32(header[8:], uint32(len(typeID)))
+	copy(header[12:], typeID)
+	written, err := w.Write(header)
+	total := int64(written)
+	if err != nil {
+		return total, essentials.AddCtx("write network", err)
+	}
+
+	// SerializeAny of a single object gives its size and
+	// typed data, which is exactly one field's entry in
+	// the serialized network.
+	for _, field := range fields {
+		data, err := serializer.SerializeAny(field)
+		if err != nil {
+			return total, essentials.AddCtx("write network", err)
+		}
+		written, err := w.Write(data)
+		total += int64(written)
+		if err != nil {
+			return total, essentials.AddCtx("write network", err)
+		}
+	}
+	return total, nil
+}
+
+// ReadNetwork reads a network written by WriteTo or by
+// serializer.SaveAny.
+//
+// Fields are decoded as they are read, so only the
+// largest serialized field is ever held in memory.
+func ReadNetwork(r io.Reader) (*Network, error) {
+	net, err := readNetwork(r)
+	if err != nil {
+		return nil, essentials.AddCtx("read network", err)
+	}
+	return net, nil
+}
+
+func readNetwork(r io.Reader) (*Network, error) {
+	var size uint64
+	var typeSize uint32
+	if err := binary.Read(r, binary.LittleEndian, &size); err != nil {
+		return nil, err
+	}
+	if err := binary.Read(r, binary.LittleEndian, &typeSize); err != nil {
+		return nil, err
+	}
+	if uint64(typeSize)+4 > size {
+		return nil, serializer.ErrBufferUnderflow
+	}
+	typeID := make([]byte, typeSize)
+	if _, err := io.ReadFull(r, typeID); err != nil {
+		return nil, err
+	}
+	if string(typeID) != (&Network{}).SerializerType() {
+		return nil, fmt.Errorf("unexpected type ID: %s", typeID)
+	}
+
+	remaining := size - 4 - uint64(typeSize)
+	var objs []serializer.Serializer
+	for remaining >= 8 {
+		var fieldSize uint64
+		if err := binary.Read(r, binary.LittleEndian, &fieldSize); err != nil {
+			return nil, err
+		}
+		remaining -= 8
+		if fieldSize > remaining {
+			return nil, serializer.ErrBufferUnderflow
+		}
+		data := make([]byte, fieldSize)
+		if _, err := io.ReadFull(r, data); err != nil {
+			return nil, err
+		}
+		remaining -= fieldSize
+		obj, err := serializer.DeserializeWithType(data)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, obj)
+	}
+	if remaining != 0 {
+		return nil, serializer.ErrResidualData
+	}
+	return networkFromFields(objs)
+}
+
+func writeNetworkFile(path string, write func(w io.Writer) error) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	if err := write(w); err != nil {
+		f.Close()
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func readNetworkFile(path string) (*Network, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadNetwork(bufio.NewReader(f))
+}
@@ -0,0 +1,208 @@
+package algebrain
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/unixpickle/anynet/anyrnn"
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/serializer"
+)
+
+func TestSaveAndValidate(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	net.ReverseInput = true
+	path := filepath.Join(t.TempDir(), "net")
+	if err := net.SaveAndValidate(path); err != nil {
+		t.Fatal(err)
+	}
+	var loaded *Network
+	if err := serializer.LoadAny(path, &loaded); err != nil {
+		t.Fatal(err)
+	}
+	if !NetworksEqual(net, loaded, 0) {
+		t.Error("saved network does not match")
+	}
+}
+
+func TestSaveAndValidateCorrupt(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+
+	// Input weights are random, unlike initial states.
+	lstm := net.Encoder.Forward.(anyrnn.Stack)[0].(*anyrnn.LSTM)
+	weights := lstm.InValue.InputWeights.Vector.Data().([]float32)[:4]
+	for _, w := range weights {
+		if w == 0 {
+			t.Fatal("expected non-zero weights")
+		}
+	}
+
+	corruptSerialize := func() ([]byte, error) {
+		data, err := serializer.SerializeAny(net)
+		if err != nil {
+			return nil, err
+		}
+		// Flip the sign of a non-zero weight.
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			encoded := make([]byte, 4*len(weights))
+			for i, w := range weights {
+				order.PutUint32(encoded[4*i:], math.Float32bits(w))
+			}
+			if idx := bytes.Index(data, encoded); idx >= 0 {
+				if order == binary.LittleEndian {
+					data[idx+3] ^= 0x80
+				} else {
+					data[idx] ^= 0x80
+				}
+				return data, nil
+			}
+		}
+		t.Fatal("weights not found in serialized data")
+		return nil, nil
+	}
+	// A plain save of the corrupted data goes unnoticed.
+	data, _ := corruptSerialize()
+	plainPath := filepath.Join(t.TempDir(), "plain")
+	if err := os.WriteFile(plainPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	var loaded *Network
+	if err := serializer.LoadAny(plainPath, &loaded); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "net")
+	writeCorrupt := func(w io.Writer) error {
+		data, err := corruptSerialize()
+		if err != nil {
+			return err
+		}
+		_, err = w.Write(data)
+		return err
+	}
+	if err := net.saveAndValidate(path, writeCorrupt); err == nil {
+		t.Error("expected validation error")
+	}
+}
+
+func TestNetworkWriteTo(t *testing.T) {
+	plain := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	configured := NewNetwork(anyvec32.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+	configured.ReverseInput = true
+	configured.Preprocessor = &Preprocessor{CollapseSpace: true}
+	configured.CalibrationTemperature = 1.5
+	for i, net := range []*Network{plain, configured} {
+		expected, err := serializer.SerializeAny(net)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var buf bytes.Buffer
+		n, err := net.WriteTo(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n != int64(buf.Len()) {
+			t.Errorf("network %d: wrote %d bytes but reported %d", i, buf.Len(), n)
+		}
+		if !bytes.Equal(buf.Bytes(), expected) {
+			t.Errorf("network %d: streamed data differs from SerializeAny", i)
+			continue
+		}
+
+		loaded, err := ReadNetwork(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var expectedLoaded *Network
+		if err := serializer.DeserializeAny(expected, &expectedLoaded); err != nil {
+			t.Fatal(err)
+		}
+		if !NetworksEqual(loaded, expectedLoaded, 0) || !NetworksEqual(loaded, net, 0) {
+			t.Errorf("network %d: loaded parameters do not match", i)
+		}
+		if loaded.ReverseInput != net.ReverseInput ||
+			!loaded.Preprocessor.Equal(expectedLoaded.Preprocessor) ||
+			loaded.CalibrationTemperature != net.CalibrationTemperature ||
+			loaded.Tokenizer.VocabSize() != net.Tokenizer.VocabSize() {
+			t.Errorf("network %d: loaded configuration does not match", i)
+		}
+	}
+}
+
+func TestReadNetworkCorrupt(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	data, err := serializer.SerializeAny(net)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{0, 7, 20, len(data) / 2, len(data) - 1} {
+		if _, err := ReadNetwork(bytes.NewReader(data[:size])); err == nil {
+			t.Errorf("expected error for %d of %d bytes", size, len(data))
+		}
+	}
+	other, err := serializer.SerializeAny(serializer.String("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ReadNetwork(bytes.NewReader(other)); err == nil {
+		t.Error("expected error for another type")
+	}
+}
+
+func TestLoadOrCreate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "net")
+	var numCreated int
+	create := func() (*Network, error) {
+		numCreated++
+		return NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{}), nil
+	}
+
+	created, err := LoadOrCreate(path, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numCreated != 1 {
+		t.Fatalf("expected 1 creation but got %d", numCreated)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal("network was not saved:", err)
+	}
+
+	loaded, err := LoadOrCreate(path, create)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if numCreated != 1 {
+		t.Error("network was created again")
+	}
+	if !NetworksEqual(created, loaded, 0) {
+		t.Error("loaded network does not match")
+	}
+
+	if err := os.WriteFile(path, []byte("corrupt"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadOrCreate(path, create); err == nil {
+		t.Error("expected error for corrupt file")
+	}
+	if numCreated != 1 {
+		t.Error("network was created for a corrupt file")
+	}
+	if data, _ := os.ReadFile(path); string(data) != "corrupt" {
+		t.Error("corrupt file was overwritten")
+	}
+
+	createErr := errors.New("create failed")
+	_, err = LoadOrCreate(filepath.Join(t.TempDir(), "net"), func() (*Network, error) {
+		return nil, createErr
+	})
+	if err == nil {
+		t.Error("expected error from create")
+	}
+}
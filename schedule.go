@@ -0,0 +1,41 @@
+package algebrain
+
+import "math"
+
+// DefaultLearningRate is the learning rate used by a
+// Trainer without a Schedule.
+const DefaultLearningRate = 0.001
+
+// A LearningRateSchedule determines the learning rate at
+// each training step.
+type LearningRateSchedule interface {
+	// Rate returns the learning rate for a step, starting
+	// at 0.
+	Rate(step int) float64
+}
+
+// A ConstantSchedule uses the same learning rate for
+// every step.
+type ConstantSchedule float64
+
+// Rate returns the constant rate.
+func (c ConstantSchedule) Rate(step int) float64 {
+	return float64(c)
+}
+
+// A CosineRestartSchedule anneals the learning rate from
+// MaxRate to MinRate along a half cosine over each cycle,
+// then restarts at MaxRate (SGDR).
+type CosineRestartSchedule struct {
+	MinRate float64
+	MaxRate float64
+
+	// CycleLength is the number of steps in each cycle.
+	CycleLength int
+}
+
+// Rate returns the annealed rate for the step.
+func (c *CosineRestartSchedule) Rate(step int) float64 {
+	progress := float64(step%c.CycleLength) / float64(c.CycleLength)
+	return c.MinRate + (c.MaxRate-c.MinRate)*(1+math.Cos(math.Pi*progress))/2
+}
@@ -0,0 +1,62 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anynet/anysgd"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestCosineRestartSchedule(t *testing.T) {
+	schedule := &CosineRestartSchedule{MinRate: 0.001, MaxRate: 0.01, CycleLength: 100}
+	cases := []struct {
+		Step     int
+		Expected float64
+	}{
+		{0, 0.01},
+		{50, 0.0055},
+		{100, 0.01},
+		{150, 0.0055},
+		{200, 0.01},
+	}
+	for _, c := range cases {
+		if actual := schedule.Rate(c.Step); math.Abs(actual-c.Expected) > 1e-12 {
+			t.Errorf("step %d: expected %f but got %f", c.Step, c.Expected, actual)
+		}
+	}
+
+	// The rate approaches MinRate at the end of each
+	// cycle, right before it restarts.
+	for _, step := range []int{99, 199} {
+		if actual := schedule.Rate(step); actual > 0.001+1e-5 || actual < 0.001 {
+			t.Errorf("step %d: expected rate near 0.001 but got %f", step, actual)
+		}
+		if schedule.Rate(step+1) <= schedule.Rate(step) {
+			t.Errorf("step %d: rate did not restart", step+1)
+		}
+	}
+}
+
+func TestTrainerRate(t *testing.T) {
+	var _ anysgd.Rater = &Trainer{}
+
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	trainer := &Trainer{Network: net}
+	if actual := trainer.Rate(0); actual != DefaultLearningRate {
+		t.Errorf("expected default rate %f but got %f", DefaultLearningRate, actual)
+	}
+
+	trainer.Schedule = &CosineRestartSchedule{MinRate: 0, MaxRate: 1, CycleLength: 2}
+	batch, err := trainer.Fetch(SampleList{{Query: "evaluate 1+1", Response: "Result: 2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for step, expected := range []float64{1, 0.5, 1} {
+		trainer.Gradient(batch)
+		// The epoch is ignored in favor of the step count.
+		if actual := trainer.Rate(float64(step) + 0.5); math.Abs(actual-expected) > 1e-12 {
+			t.Errorf("step %d: expected rate %f but got %f", step, expected, actual)
+		}
+	}
+}
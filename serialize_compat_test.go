@@ -0,0 +1,195 @@
+package algebrain
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+	"github.com/unixpickle/serializer"
+)
+
+// TestSerializationBackwardCompatibility checks that every
+// released layout of the serialized Network can still be
+// deserialized.
+//
+// Each version appended a field to the previous one:
+//
+//	v1: Encoder, Align, Output
+//	v2: + Tokenizer
+//	v3: + ReverseInput
+//	v4: + Preprocessor
+//	v5: + InputEncoder
+//	v6: + CalibrationTemperature
+//
+// In v5 and v6, the InputEncoder is only present if it is
+// set, so networks without one still use the v4 fields.
+func TestSerializationBackwardCompatibility(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &WordTokenizer{Words: StandardWords})
+	net.ReverseInput = true
+	net.Preprocessor = &Preprocessor{Lowercase: true}
+	charNet := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	encNet := NewNetworkInputEncoder(anyvec32.CurrentCreator(), &CharTokenizer{},
+		serializableEncoder{})
+	encNet.ReverseInput = true
+	encNet.Preprocessor = &Preprocessor{Lowercase: true}
+	encNet.CalibrationTemperature = 1.5
+
+	versions := []struct {
+		Name        string
+		Fields      []interface{}
+		Net         *Network
+		Tokenizer   Tokenizer
+		Reverse     bool
+		Encoder     InputEncoder
+		Temperature float64
+	}{
+		{
+			Name:      "v1",
+			Fields:    []interface{}{charNet.Encoder, charNet.Align, charNet.Output},
+			Net:       charNet,
+			Tokenizer: &CharTokenizer{},
+		},
+		{
+			Name: "v2",
+			Fields: []interface{}{net.Encoder, net.Align, net.Output,
+				net.Tokenizer},
+			Net:       net,
+			Tokenizer: net.Tokenizer,
+		},
+		{
+			Name: "v3",
+			Fields: []interface{}{net.Encoder, net.Align, net.Output,
+				net.Tokenizer, net.ReverseInput},
+			Net:       net,
+			Tokenizer: net.Tokenizer,
+			Reverse:   true,
+		},
+		{
+			Name: "v4",
+			Fields: []interface{}{net.Encoder, net.Align, net.Output,
+				net.Tokenizer, net.ReverseInput, net.Preprocessor},
+			Net:       net,
+			Tokenizer: net.Tokenizer,
+			Reverse:   true,
+		},
+		{
+			Name: "v5",
+			Fields: []interface{}{encNet.Encoder, encNet.Align, encNet.Output,
+				encNet.Tokenizer, encNet.ReverseInput, encNet.Preprocessor,
+				encNet.InputEncoder},
+			Net:       encNet,
+			Tokenizer: encNet.Tokenizer,
+			Reverse:   true,
+			Encoder:   encNet.InputEncoder,
+		},
+		{
+			Name: "v6",
+			Fields: []interface{}{encNet.Encoder, encNet.Align, encNet.Output,
+				encNet.Tokenizer, encNet.ReverseInput, encNet.Preprocessor,
+				encNet.InputEncoder, encNet.CalibrationTemperature},
+			Net:         encNet,
+			Tokenizer:   encNet.Tokenizer,
+			Reverse:     true,
+			Encoder:     encNet.InputEncoder,
+			Temperature: encNet.CalibrationTemperature,
+		},
+	}
+	for _, v := range versions {
+		data, err := serializer.SerializeAny(v.Fields...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded, err := DeserializeNetwork(data)
+		if err != nil {
+			t.Errorf("%s: %s", v.Name, err)
+			continue
+		}
+		if loaded.ParameterCount() != v.Net.ParameterCount() {
+			t.Errorf("%s: expected %d parameters but got %d", v.Name,
+				v.Net.ParameterCount(), loaded.ParameterCount())
+		}
+		if loaded.Tokenizer.VocabSize() != v.Tokenizer.VocabSize() {
+			t.Errorf("%s: unexpected vocabulary size %d", v.Name, loaded.Tokenizer.VocabSize())
+		}
+		if loaded.ReverseInput != v.Reverse {
+			t.Errorf("%s: expected ReverseInput=%v", v.Name, v.Reverse)
+		}
+		if loaded.InputEncoder != v.Encoder {
+			t.Errorf("%s: expected InputEncoder %v but got %v", v.Name, v.Encoder,
+				loaded.InputEncoder)
+		}
+		if loaded.CalibrationTemperature != v.Temperature {
+			t.Errorf("%s: expected CalibrationTemperature %f but got %f", v.Name,
+				v.Temperature, loaded.CalibrationTemperature)
+		}
+		if !NetworksEqual(loaded, v.Net, 0) {
+			t.Errorf("%s: parameters were not preserved", v.Name)
+		}
+	}
+
+	// The current format must be the latest version.
+	data, err := encNet.Serialize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	fields, err := serializer.DeserializeSlice(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fields) != len(versions[len(versions)-1].Fields) {
+		t.Errorf("current format has %d fields; add a version to this test", len(fields))
+	}
+}
+
+// serializableEncoder is a binaryEncoder which can be
+// saved with a Network.
+type serializableEncoder struct {
+	binaryEncoder
+}
+
+func init() {
+	var e serializableEncoder
+	serializer.RegisterTypedDeserializer(e.SerializerType(),
+		func(d []byte) (serializableEncoder, error) {
+			return serializableEncoder{}, nil
+		})
+}
+
+func (s serializableEncoder) SerializerType() string {
+	return "github.com/unixpickle/algebrain.serializableEncoder"
+}
+
+func (s serializableEncoder) Serialize() ([]byte, error) {
+	return []byte{}, nil
+}
+
+// TestSerializationFixtures checks that objects serialized
+// by an earlier version, and stored in testdata/, can still
+// be deserialized.
+func TestSerializationFixtures(t *testing.T) {
+	fixtures := map[string]interface{}{
+		"char_tokenizer.bin": &CharTokenizer{},
+		"word_tokenizer.bin": &WordTokenizer{Words: StandardWords},
+		"preprocessor.bin": &Preprocessor{
+			Lowercase:     true,
+			CollapseSpace: true,
+			Synonyms:      map[string]string{"what is": "evaluate", "compute": "evaluate"},
+		},
+	}
+	for name, expected := range fixtures {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded := reflect.New(reflect.TypeOf(expected))
+		if err := serializer.DeserializeAny(data, loaded.Interface()); err != nil {
+			t.Errorf("%s: %s", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(loaded.Elem().Interface(), expected) {
+			t.Errorf("%s: expected %v but got %v", name, expected, loaded.Elem())
+		}
+	}
+}
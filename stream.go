@@ -0,0 +1,324 @@
+package algebrain
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/unixpickle/anynet/anysgd"
+	"github.com/unixpickle/essentials"
+)
+
+const (
+	// DefaultStreamBufferSize is the default number of
+	// batches buffered by a SampleStream.
+	DefaultStreamBufferSize = 16
+
+	// DefaultStreamShardBatches is the default number of
+	// batches in each shard written by a SampleStream.
+	DefaultStreamShardBatches = 1000
+)
+
+// A SampleStream generates and encodes training batches in
+// the background, so that samples never need to be held in
+// memory all at once.
+//
+// Batches are buffered up to BufferSize, after which the
+// producers block until the consumer catches up.
+//
+// A SampleStream is typically used through a Trainer; see
+// Trainer.StartStream.
+type SampleStream struct {
+	// Generator produces the samples.
+	// It is called from multiple goroutines, so it must be
+	// safe for concurrent use, like the generators in this
+	// package.
+	Generator Generator
+
+	// BatchSize is the number of samples per batch.
+	BatchSize int
+
+	// Workers is the number of producer goroutines.
+	// If this is 0, runtime.GOMAXPROCS(0) is used.
+	Workers int
+
+	// BufferSize is the maximum number of batches which
+	// are generated but not yet consumed.
+	// If this is 0, DefaultStreamBufferSize is used.
+	BufferSize int
+
+	// SpillDir, if non-empty, is a directory where the
+	// samples of every consumed batch are written, in the
+	// order they were consumed.
+	// The batches can be replayed with ReadStreamShards.
+	SpillDir string
+
+	// ShardBatches is the number of batches per file in
+	// SpillDir.
+	// If this is 0, DefaultStreamShardBatches is used.
+	ShardBatches int
+
+	ctx   context.Context
+	items chan *streamItem
+	wg    sync.WaitGroup
+
+	spillLock   sync.Mutex
+	spillFile   *os.File
+	spillWriter *bufio.Writer
+	spillCount  int
+
+	batches       int64
+	producerStall int64
+	consumerStall int64
+}
+
+type streamItem struct {
+	Samples SampleList
+	Batch   anysgd.Batch
+	Err     error
+}
+
+// StreamStats summarizes the activity of a SampleStream.
+//
+// A large ProducerStall means the consumer is the
+// bottleneck, so fewer workers would suffice.
+// A large ConsumerStall means more workers are needed.
+type StreamStats struct {
+	// Batches is the number of batches consumed.
+	Batches int64
+
+	// ProducerStall is the total time that producers spent
+	// waiting for room in the buffer.
+	ProducerStall time.Duration
+
+	// ConsumerStall is the total time that the consumer
+	// spent waiting for batches.
+	ConsumerStall time.Duration
+}
+
+// Start launches the producers, which encode batches with
+// the encode function.
+//
+// The producers stop when ctx is done.
+// Start should only be called once.
+func (s *SampleStream) Start(ctx context.Context, encode func(SampleList) (anysgd.Batch,
+	error)) {
+	bufferSize := s.BufferSize
+	if bufferSize == 0 {
+		bufferSize = DefaultStreamBufferSize
+	}
+	workers := s.Workers
+	if workers == 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	s.ctx = ctx
+	s.items = make(chan *streamItem, bufferSize)
+	for i := 0; i < workers; i++ {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.produce(ctx, encode)
+		}()
+	}
+}
+
+func (s *SampleStream) produce(ctx context.Context, encode func(SampleList) (anysgd.Batch,
+	error)) {
+	for ctx.Err() == nil {
+		samples := make(SampleList, s.BatchSize)
+		for i := range samples {
+			samples[i] = s.Generator.Generate()
+		}
+		batch, err := encode(samples)
+		item := &streamItem{Samples: samples, Batch: batch, Err: err}
+		start := time.Now()
+		select {
+		case s.items <- item:
+		case <-ctx.Done():
+			return
+		}
+		atomic.AddInt64(&s.producerStall, int64(time.Since(start)))
+		if err != nil {
+			return
+		}
+	}
+}
+
+// Next returns the next batch, blocking until one is
+// available.
+//
+// It fails if a batch could not be encoded or spilled to
+// disk, or if the stream's context is done.
+func (s *SampleStream) Next() (anysgd.Batch, error) {
+	if s.items == nil {
+		return nil, errors.New("next batch: stream was not started")
+	}
+	if err := s.ctx.Err(); err != nil {
+		return nil, err
+	}
+	start := time.Now()
+	var item *streamItem
+	select {
+	case item = <-s.items:
+	case <-s.ctx.Done():
+		return nil, s.ctx.Err()
+	}
+	atomic.AddInt64(&s.consumerStall, int64(time.Since(start)))
+	if item.Err != nil {
+		return nil, essentials.AddCtx("next batch", item.Err)
+	}
+	if s.SpillDir != "" {
+		if err := s.spill(item.Samples); err != nil {
+			return nil, essentials.AddCtx("next batch", err)
+		}
+	}
+	atomic.AddInt64(&s.batches, 1)
+	return item.Batch, nil
+}
+
+// Stats returns the current statistics.
+// It is safe to call while the stream is running.
+func (s *SampleStream) Stats() StreamStats {
+	return StreamStats{
+		Batches:       atomic.LoadInt64(&s.batches),
+		ProducerStall: time.Duration(atomic.LoadInt64(&s.producerStall)),
+		ConsumerStall: time.Duration(atomic.LoadInt64(&s.consumerStall)),
+	}
+}
+
+// Close waits for the producers to stop and flushes the
+// current shard in SpillDir.
+//
+// The stream's context must be done before Close is
+// called; otherwise, Close blocks forever.
+func (s *SampleStream) Close() error {
+	s.wg.Wait()
+	s.spillLock.Lock()
+	defer s.spillLock.Unlock()
+	if s.spillFile == nil {
+		return nil
+	}
+	err := s.closeShard()
+	if err != nil {
+		return essentials.AddCtx("close stream", err)
+	}
+	return nil
+}
+
+func (s *SampleStream) spill(samples SampleList) error {
+	s.spillLock.Lock()
+	defer s.spillLock.Unlock()
+	shardBatches := s.ShardBatches
+	if shardBatches == 0 {
+		shardBatches = DefaultStreamShardBatches
+	}
+	if s.spillFile == nil {
+		if err := os.MkdirAll(s.SpillDir, 0755); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("shard-%05d.jsonl", s.spillCount/shardBatches)
+		f, err := os.Create(filepath.Join(s.SpillDir, name))
+		if err != nil {
+			return err
+		}
+		s.spillFile = f
+		s.spillWriter = bufio.NewWriter(f)
+	}
+	data, err := json.Marshal(samples)
+	if err != nil {
+		return err
+	}
+	s.spillWriter.Write(append(data, '\n'))
+	s.spillCount++
+	if s.spillCount%shardBatches == 0 {
+		return s.closeShard()
+	}
+	return nil
+}
+
+func (s *SampleStream) closeShard() error {
+	err := s.spillWriter.Flush()
+	if closeErr := s.spillFile.Close(); err == nil {
+		err = closeErr
+	}
+	s.spillFile = nil
+	s.spillWriter = nil
+	return err
+}
+
+// ReadStreamShards reads the batches spilled to dir by a
+// SampleStream, in the order they were consumed.
+//
+// Every sample is checked with Validate, and the error for
+// an invalid sample includes its file and line number.
+func ReadStreamShards(dir string) ([]SampleList, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "shard-*.jsonl"))
+	if err != nil {
+		return nil, essentials.AddCtx("read stream shards", err)
+	}
+	sort.Strings(paths)
+	var res []SampleList
+	for _, path := range paths {
+		batches, err := readStreamShard(path)
+		if err != nil {
+			return nil, essentials.AddCtx("read stream shards", err)
+		}
+		res = append(res, batches...)
+	}
+	return res, nil
+}
+
+func readStreamShard(path string) ([]SampleList, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var res []SampleList
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 1<<26)
+	for line := 1; scanner.Scan(); line++ {
+		var batch SampleList
+		if err := json.Unmarshal(scanner.Bytes(), &batch); err != nil {
+			return nil, fmt.Errorf("%s: line %d: %v", path, line, err)
+		}
+		for _, sample := range batch {
+			if err := sample.Validate(); err != nil {
+				return nil, fmt.Errorf("%s: line %d: %v", path, line, err)
+			}
+		}
+		res = append(res, batch)
+	}
+	return res, scanner.Err()
+}
+
+// StreamSampleList is a SampleList of placeholder samples
+// for use with a streaming Trainer, whose batches do not
+// come from the sample list.
+//
+// Its length determines the number of batches in each
+// epoch of anysgd.SGD.
+type StreamSampleList int
+
+// Len returns the number of placeholder samples.
+func (s StreamSampleList) Len() int {
+	return int(s)
+}
+
+// Swap does nothing.
+func (s StreamSampleList) Swap(i, j int) {
+}
+
+// Slice returns a list with j-i placeholder samples.
+func (s StreamSampleList) Slice(i, j int) anysgd.SampleList {
+	return StreamSampleList(j - i)
+}
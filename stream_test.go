@@ -0,0 +1,69 @@
+package algebrain
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestSampleStream(t *testing.T) {
+	dir := t.TempDir()
+	gen := MixtureGenerator{
+		&constGenerator{Sample: Sample{Query: "evaluate 2+2", Response: "Result: 4"}},
+		&constGenerator{Sample: Sample{Query: "evaluate 3+3", Response: "Result: 6"}},
+	}
+	stream := &SampleStream{
+		Generator:    gen,
+		BatchSize:    3,
+		Workers:      2,
+		BufferSize:   2,
+		SpillDir:     dir,
+		ShardBatches: 2,
+	}
+	trainer := &Trainer{Network: NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})}
+	ctx, cancel := context.WithCancel(context.Background())
+	trainer.StartStream(ctx, stream)
+
+	for i := 0; i < 5; i++ {
+		batch, err := trainer.Fetch(StreamSampleList(3))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := len(batch.(*Batch).DecOut.Output()[0].Present); n != 3 {
+			t.Fatalf("expected batch size 3 but got %d", n)
+		}
+	}
+	cancel()
+	if _, err := trainer.Fetch(StreamSampleList(3)); err != context.Canceled {
+		t.Errorf("expected cancellation error but got %v", err)
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if stats := stream.Stats(); stats.Batches != 5 {
+		t.Errorf("expected 5 batches but got %d", stats.Batches)
+	}
+
+	if shards, _ := filepath.Glob(filepath.Join(dir, "*.jsonl")); len(shards) != 3 {
+		t.Errorf("expected 3 shards but got %d", len(shards))
+	}
+	batches, err := ReadStreamShards(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(batches) != 5 {
+		t.Fatalf("expected 5 batches but got %d", len(batches))
+	}
+	for _, batch := range batches {
+		if len(batch) != 3 {
+			t.Fatalf("expected batch size 3 but got %d", len(batch))
+		}
+		for _, s := range batch {
+			if s.Query != "evaluate 2+2" && s.Query != "evaluate 3+3" {
+				t.Errorf("unexpected sample: %v", s)
+			}
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package algebrain
+
+import (
+	"fmt"
+	"sync"
+)
+
+// StressTestNetwork runs queries against the network from
+// concurrency goroutines at once, each running steps
+// queries, to detect races in the forward pass.
+//
+// Every response is compared to the response which the
+// network gives when the query is run alone.
+// A mismatch, or a panic in any goroutine, is returned as
+// an error.
+//
+// This is most useful when run with the race detector
+// (go test -race).
+func StressTestNetwork(n Querier, concurrency, steps int) (err error) {
+	queries := make([]string, concurrency)
+	expected := make([]string, concurrency)
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("stress test: panic: %v", r)
+		}
+	}()
+	for i := range queries {
+		queries[i] = fmt.Sprintf("evaluate %d+%d", i, concurrency-i)
+		expected[i] = n.Query(queries[i])
+	}
+
+	var lock sync.Mutex
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil {
+					lock.Lock()
+					err = fmt.Errorf("stress test: panic in goroutine %d: %v", i, r)
+					lock.Unlock()
+				}
+			}()
+			for step := 0; step < steps; step++ {
+				// Rotate through the queries so that goroutines
+				// decode different queries at the same time.
+				idx := (i + step) % concurrency
+				if actual := n.Query(queries[idx]); actual != expected[idx] {
+					panic(fmt.Sprintf("query %q: expected %q but got %q", queries[idx],
+						expected[idx], actual))
+				}
+			}
+		}(i)
+	}
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,22 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestStressTestNetwork(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+
+	// Keep responses short so the test runs quickly.
+	biases := make([]float64, CharCount)
+	biases[Terminator] = 20
+	outLayer := net.Output[0].(*anynet.FC)
+	outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+
+	if err := StressTestNetwork(net, 10, 100); err != nil {
+		t.Fatal(err)
+	}
+}
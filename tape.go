@@ -0,0 +1,79 @@
+package algebrain
+
+import (
+	"sync"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anyvec"
+)
+
+// A GradientSnapshot is a copy of the gradient computed by
+// one call to Trainer.Gradient.
+type GradientSnapshot struct {
+	// Step is the 1-based index of the Gradient call since
+	// the tape was started.
+	Step int
+
+	// Gradients maps the names from NamedParameters to a
+	// copy of the corresponding parameter's gradient.
+	Gradients map[string]anyvec.Vector
+}
+
+// A GradientTape records the gradients computed by a
+// Trainer so that gradient flow can be analyzed after the
+// fact.
+//
+// Since every snapshot copies the full gradient, a tape
+// should only be active for a handful of steps.
+type GradientTape struct {
+	lock     sync.Mutex
+	stopped  bool
+	history  []GradientSnapshot
+	names    map[*anydiff.Var]string
+	numSteps int
+}
+
+// StartTape creates a GradientTape and attaches it to the
+// Trainer, replacing any previous tape.
+// Every subsequent call to Gradient is recorded until the
+// tape is stopped.
+func (t *Trainer) StartTape() *GradientTape {
+	names := map[*anydiff.Var]string{}
+	for name, param := range t.Network.NamedParameters() {
+		names[param] = name
+	}
+	t.tape = &GradientTape{names: names}
+	return t.tape
+}
+
+// Stop ends the recording.
+// The history is preserved.
+func (g *GradientTape) Stop() {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	g.stopped = true
+}
+
+// History returns the snapshots recorded so far, in the
+// order they were taken.
+func (g *GradientTape) History() []GradientSnapshot {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	return append([]GradientSnapshot{}, g.history...)
+}
+
+func (g *GradientTape) record(grad anydiff.Grad) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+	if g.stopped {
+		return
+	}
+	g.numSteps++
+	snapshot := GradientSnapshot{Step: g.numSteps, Gradients: map[string]anyvec.Vector{}}
+	for param, vec := range grad {
+		if name, ok := g.names[param]; ok {
+			snapshot.Gradients[name] = vec.Copy()
+		}
+	}
+	g.history = append(g.history, snapshot)
+}
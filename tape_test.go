@@ -0,0 +1,56 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestGradientTape(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	trainer := &Trainer{Network: net}
+	batch, err := trainer.Fetch(SampleList{{Query: "evaluate 1+1", Response: "Result: 2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// This step happens before the tape is started.
+	trainer.Gradient(batch)
+
+	tape := trainer.StartTape()
+	for i := 0; i < 3; i++ {
+		grad := trainer.Gradient(batch)
+		grad.Scale(net.creator().MakeNumeric(-0.1))
+		grad.AddToVars()
+	}
+	tape.Stop()
+	trainer.Gradient(batch)
+
+	history := tape.History()
+	if len(history) != 3 {
+		t.Fatalf("expected 3 snapshots but got %d", len(history))
+	}
+	for i, snapshot := range history {
+		if snapshot.Step != i+1 {
+			t.Errorf("snapshot %d: unexpected step %d", i, snapshot.Step)
+		}
+		if len(snapshot.Gradients) != len(net.Parameters()) {
+			t.Errorf("snapshot %d: expected %d gradients but got %d", i,
+				len(net.Parameters()), len(snapshot.Gradients))
+		}
+	}
+
+	var differs bool
+	for name, first := range history[0].Gradients {
+		firstData := first.Data().([]float64)
+		thirdData := history[2].Gradients[name].Data().([]float64)
+		for j, x := range firstData {
+			if x != thirdData[j] {
+				differs = true
+			}
+		}
+	}
+	if !differs {
+		t.Error("first and third gradients should differ")
+	}
+}
@@ -0,0 +1,117 @@
+package algebrain
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"math"
+	"os"
+	"time"
+
+	"github.com/unixpickle/essentials"
+)
+
+const tfEventFileVersion = "brain.Event:2"
+
+var tfCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// A TFEventLogger writes scalar training statistics in
+// the TensorBoard event file format.
+//
+// Files are sequences of TFRecords, each of which contains
+// a tf.Event protobuf message.
+type TFEventLogger struct {
+	file *os.File
+	err  error
+}
+
+// NewTFEventLogger creates an event file at the given path
+// and writes the file header to it.
+func NewTFEventLogger(path string) (*TFEventLogger, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, essentials.AddCtx("create event logger", err)
+	}
+	l := &TFEventLogger{file: f}
+	var event []byte
+	event = appendProtoDouble(event, 1, tfWallTime())
+	event = appendProtoBytes(event, 3, []byte(tfEventFileVersion))
+	if err := l.writeRecord(event); err != nil {
+		f.Close()
+		return nil, essentials.AddCtx("create event logger", err)
+	}
+	return l, nil
+}
+
+// LogScalar records a scalar summary for the given step.
+//
+// Write errors are deferred until Close, after which no
+// more scalars will be written.
+func (l *TFEventLogger) LogScalar(tag string, value float64, step int) {
+	if l.err != nil {
+		return
+	}
+	var summaryValue []byte
+	summaryValue = appendProtoBytes(summaryValue, 1, []byte(tag))
+	summaryValue = appendProtoFloat(summaryValue, 2, float32(value))
+	var summary []byte
+	summary = appendProtoBytes(summary, 1, summaryValue)
+
+	var event []byte
+	event = appendProtoDouble(event, 1, tfWallTime())
+	event = appendProtoVarint(event, 2, uint64(step))
+	event = appendProtoBytes(event, 5, summary)
+	l.err = essentials.AddCtx("log scalar", l.writeRecord(event))
+}
+
+// Close closes the underlying file.
+// It returns the first error encountered while logging, if
+// there was one.
+func (l *TFEventLogger) Close() error {
+	closeErr := l.file.Close()
+	if l.err != nil {
+		return l.err
+	}
+	return closeErr
+}
+
+func (l *TFEventLogger) writeRecord(data []byte) error {
+	record := make([]byte, 12, len(data)+16)
+	binary.LittleEndian.PutUint64(record, uint64(len(data)))
+	binary.LittleEndian.PutUint32(record[8:], maskedCRC(record[:8]))
+	record = append(record, data...)
+	var dataCRC [4]byte
+	binary.LittleEndian.PutUint32(dataCRC[:], maskedCRC(data))
+	record = append(record, dataCRC[:]...)
+	_, err := l.file.Write(record)
+	return err
+}
+
+func maskedCRC(data []byte) uint32 {
+	crc := crc32.Checksum(data, tfCRCTable)
+	return ((crc >> 15) | (crc << 17)) + 0xa282ead8
+}
+
+func tfWallTime() float64 {
+	return float64(time.Now().UnixNano()) / 1e9
+}
+
+func appendProtoVarint(buf []byte, field int, value uint64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field<<3))
+	return binary.AppendUvarint(buf, value)
+}
+
+func appendProtoDouble(buf []byte, field int, value float64) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field<<3|1))
+	return binary.LittleEndian.AppendUint64(buf, math.Float64bits(value))
+}
+
+func appendProtoFloat(buf []byte, field int, value float32) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field<<3|5))
+	return binary.LittleEndian.AppendUint32(buf, math.Float32bits(value))
+}
+
+func appendProtoBytes(buf []byte, field int, value []byte) []byte {
+	buf = binary.AppendUvarint(buf, uint64(field<<3|2))
+	buf = binary.AppendUvarint(buf, uint64(len(value)))
+	return append(buf, value...)
+}
@@ -0,0 +1,65 @@
+package algebrain
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"math"
+	"path/filepath"
+	"testing"
+)
+
+func TestTFEventLogger(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.out.tfevents")
+	logger, err := NewTFEventLogger(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	logger.LogScalar("cost", 1.5, 7)
+	if err := logger.Close(); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Header record: tag+wall time (9 bytes), then the
+	// file_version field.
+	headerLen := int(binary.LittleEndian.Uint64(data))
+	if headerLen != 11+len(tfEventFileVersion) {
+		t.Fatalf("unexpected header length: %d", headerLen)
+	}
+	if binary.LittleEndian.Uint32(data[8:]) != maskedCRC(data[:8]) {
+		t.Error("bad header length CRC")
+	}
+	header := data[12 : 12+headerLen]
+	if string(header[11:]) != tfEventFileVersion {
+		t.Errorf("unexpected file version: %q", header[11:])
+	}
+
+	// Scalar record: wall time (9 bytes), step (2 bytes),
+	// summary header (2 bytes), value header (2 bytes), tag
+	// (6 bytes), and then the simple_value field.
+	record := data[12+headerLen+4:]
+	eventLen := int(binary.LittleEndian.Uint64(record))
+	if len(record) != eventLen+16 {
+		t.Fatalf("expected record size %d but got %d", eventLen+16, len(record))
+	}
+	event := record[12 : 12+eventLen]
+	if binary.LittleEndian.Uint32(record[12+eventLen:]) != maskedCRC(event) {
+		t.Error("bad event CRC")
+	}
+	if event[9] != 0x10 || event[10] != 7 {
+		t.Errorf("unexpected step encoding: %v", event[9:11])
+	}
+	if string(event[17:21]) != "cost" {
+		t.Errorf("unexpected tag: %q", event[17:21])
+	}
+	if event[21] != 0x15 {
+		t.Errorf("unexpected simple_value tag: %d", event[21])
+	}
+	value := math.Float32frombits(binary.LittleEndian.Uint32(event[22:]))
+	if value != 1.5 {
+		t.Errorf("expected value 1.5 but got %f", value)
+	}
+}
@@ -0,0 +1,89 @@
+package algebrain
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// benchmarkWarmup is the number of untimed queries run by
+// Benchmark before it starts measuring.
+const benchmarkWarmup = 3
+
+// A BenchResult summarizes the decoding speed of a
+// Network.
+type BenchResult struct {
+	Queries int
+
+	// Tokens is the number of decoder steps, including
+	// terminators.
+	Tokens int
+
+	Duration time.Duration
+
+	TokensPerSecond  float64
+	QueriesPerSecond float64
+
+	// Per-query latency percentiles.
+	LatencyP50 time.Duration
+	LatencyP90 time.Duration
+	LatencyP99 time.Duration
+}
+
+// String returns a one-line summary of the result.
+func (b *BenchResult) String() string {
+	return fmt.Sprintf("%.1f tokens/sec, %.2f queries/sec, latency p50=%s p90=%s p99=%s",
+		b.TokensPerSecond, b.QueriesPerSecond, b.LatencyP50, b.LatencyP90, b.LatencyP99)
+}
+
+// Benchmark measures how quickly the network answers each
+// of the queries with greedy decoding.
+//
+// A few untimed warmup queries are run first, so that
+// lazily initialized state (such as the one-hot vector
+// cache) does not skew the results.
+//
+// It panics if a query cannot be tokenized.
+func Benchmark(n *Network, queries []string) *BenchResult {
+	if len(queries) == 0 {
+		return &BenchResult{}
+	}
+	for i := 0; i < benchmarkWarmup; i++ {
+		n.Query(queries[i%len(queries)])
+	}
+
+	res := &BenchResult{Queries: len(queries)}
+	latencies := make([]time.Duration, len(queries))
+	for i, q := range queries {
+		start := time.Now()
+		out, err := n.decode(context.Background(), q, argMaxFloats)
+		latencies[i] = time.Since(start)
+		if err != nil {
+			panic(err)
+		}
+		res.Tokens += out.Steps
+		res.Duration += latencies[i]
+	}
+
+	seconds := res.Duration.Seconds()
+	res.TokensPerSecond = float64(res.Tokens) / seconds
+	res.QueriesPerSecond = float64(res.Queries) / seconds
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	res.LatencyP50 = latencyPercentile(latencies, 50)
+	res.LatencyP90 = latencyPercentile(latencies, 90)
+	res.LatencyP99 = latencyPercentile(latencies, 99)
+	return res
+}
+
+// latencyPercentile uses the nearest-rank method to find a
+// percentile of sorted latencies.
+func latencyPercentile(sorted []time.Duration, percentile int) time.Duration {
+	rank := (percentile*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
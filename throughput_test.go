@@ -0,0 +1,40 @@
+package algebrain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestBenchmark(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	queries := []string{"evaluate 1+1", "scale x by 2 in x", "shift x by 3 in x^2"}
+	res := Benchmark(net, queries)
+	if res.Queries != len(queries) {
+		t.Errorf("expected %d queries but got %d", len(queries), res.Queries)
+	}
+	if res.Tokens < len(queries) {
+		t.Errorf("expected at least %d tokens but got %d", len(queries), res.Tokens)
+	}
+	if res.TokensPerSecond <= 0 || res.QueriesPerSecond <= 0 {
+		t.Errorf("invalid rates: %s", res)
+	}
+	if res.LatencyP50 > res.LatencyP90 || res.LatencyP90 > res.LatencyP99 ||
+		res.LatencyP99 > res.Duration {
+		t.Errorf("invalid latencies: %s", res)
+	}
+}
+
+func TestLatencyPercentile(t *testing.T) {
+	var latencies []time.Duration
+	for i := 1; i <= 10; i++ {
+		latencies = append(latencies, time.Duration(i))
+	}
+	for percentile, expected := range map[int]time.Duration{0: 1, 50: 5, 90: 9, 99: 10,
+		100: 10} {
+		if actual := latencyPercentile(latencies, percentile); actual != expected {
+			t.Errorf("percentile %d: expected %d but got %d", percentile, expected, actual)
+		}
+	}
+}
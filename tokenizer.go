@@ -0,0 +1,193 @@
+package algebrain
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/unixpickle/essentials"
+	"github.com/unixpickle/serializer"
+)
+
+func init() {
+	var c CharTokenizer
+	serializer.RegisterTypedDeserializer(c.SerializerType(), DeserializeCharTokenizer)
+	var w WordTokenizer
+	serializer.RegisterTypedDeserializer(w.SerializerType(), DeserializeWordTokenizer)
+}
+
+// StandardWords contains the command keywords and
+// function names which are commonly treated as atomic
+// tokens by a WordTokenizer.
+var StandardWords = []string{
+	"shift", "scale", "evaluate", "by", "in", "Result",
+	"sin", "cos", "tan", "exp", "ln", "pi",
+}
+
+// A Tokenizer converts between strings and sequences of
+// token IDs.
+//
+// Token IDs range from 0 to VocabSize()-1, and the ID
+// Terminator is reserved to mark the end of a sequence.
+type Tokenizer interface {
+	serializer.Serializer
+
+	// Encode converts a string into a list of tokens.
+	// It fails if the string cannot be represented.
+	Encode(s string) ([]int, error)
+
+	// Decode converts a list of tokens into a string.
+	Decode(tokens []int) string
+
+	// VocabSize returns the number of distinct tokens.
+	VocabSize() int
+}
+
+// A CharTokenizer treats every ASCII character as its own
+// token.
+type CharTokenizer struct{}
+
+// DeserializeCharTokenizer deserializes a CharTokenizer.
+func DeserializeCharTokenizer(d []byte) (*CharTokenizer, error) {
+	return &CharTokenizer{}, nil
+}
+
+// Encode converts each character to a token.
+func (c *CharTokenizer) Encode(s string) ([]int, error) {
+	var res []int
+	for _, x := range s {
+		if err := checkCharToken(x); err != nil {
+			return nil, err
+		}
+		res = append(res, int(x))
+	}
+	return res, nil
+}
+
+// Decode converts each token back to a character.
+func (c *CharTokenizer) Decode(tokens []int) string {
+	res := make([]rune, len(tokens))
+	for i, x := range tokens {
+		res[i] = rune(x)
+	}
+	return string(res)
+}
+
+// VocabSize returns CharCount.
+func (c *CharTokenizer) VocabSize() int {
+	return CharCount
+}
+
+// SerializerType returns the unique ID used to serialize
+// a CharTokenizer with the serializer package.
+func (c *CharTokenizer) SerializerType() string {
+	return "github.com/unixpickle/algebrain.CharTokenizer"
+}
+
+// Serialize serializes the CharTokenizer.
+func (c *CharTokenizer) Serialize() ([]byte, error) {
+	return []byte{}, nil
+}
+
+// A WordTokenizer treats entire words (runs of letters)
+// from a fixed vocabulary as single tokens.
+// All other characters, including digits and operators,
+// are individual tokens like in a CharTokenizer.
+//
+// The first CharCount token IDs are used for characters,
+// and the remaining IDs correspond to Words.
+type WordTokenizer struct {
+	Words []string
+}
+
+// DeserializeWordTokenizer deserializes a WordTokenizer.
+func DeserializeWordTokenizer(d []byte) (*WordTokenizer, error) {
+	words, err := serializer.DeserializeSlice(d)
+	if err != nil {
+		return nil, essentials.AddCtx("deserialize WordTokenizer", err)
+	}
+	res := &WordTokenizer{}
+	for _, x := range words {
+		if word, ok := x.(serializer.String); ok {
+			res.Words = append(res.Words, string(word))
+		} else {
+			return nil, errors.New("deserialize WordTokenizer: invalid word type")
+		}
+	}
+	return res, nil
+}
+
+// Encode tokenizes the string.
+func (w *WordTokenizer) Encode(s string) ([]int, error) {
+	wordIDs := map[string]int{}
+	for i, x := range w.Words {
+		wordIDs[x] = CharCount + i
+	}
+	var res []int
+	runes := []rune(s)
+	for i := 0; i < len(runes); i++ {
+		if isWordRune(runes[i]) {
+			j := i
+			for j < len(runes) && isWordRune(runes[j]) {
+				j++
+			}
+			if id, ok := wordIDs[string(runes[i:j])]; ok {
+				res = append(res, id)
+				i = j - 1
+				continue
+			}
+		}
+		if err := checkCharToken(runes[i]); err != nil {
+			return nil, err
+		}
+		res = append(res, int(runes[i]))
+	}
+	return res, nil
+}
+
+// Decode turns the tokens back into a string.
+func (w *WordTokenizer) Decode(tokens []int) string {
+	var res []rune
+	for _, x := range tokens {
+		if x >= CharCount {
+			res = append(res, []rune(w.Words[x-CharCount])...)
+		} else {
+			res = append(res, rune(x))
+		}
+	}
+	return string(res)
+}
+
+// VocabSize returns the number of characters plus the
+// number of words.
+func (w *WordTokenizer) VocabSize() int {
+	return CharCount + len(w.Words)
+}
+
+// SerializerType returns the unique ID used to serialize
+// a WordTokenizer with the serializer package.
+func (w *WordTokenizer) SerializerType() string {
+	return "github.com/unixpickle/algebrain.WordTokenizer"
+}
+
+// Serialize serializes the WordTokenizer.
+func (w *WordTokenizer) Serialize() ([]byte, error) {
+	words := make([]serializer.Serializer, len(w.Words))
+	for i, x := range w.Words {
+		words[i] = serializer.String(x)
+	}
+	return serializer.SerializeSlice(words)
+}
+
+func checkCharToken(x rune) error {
+	if x == Terminator {
+		return errors.New("unexpected terminator character")
+	} else if x < 0 || x >= CharCount {
+		return fmt.Errorf("rune out of range: %s", strconv.QuoteRune(x))
+	}
+	return nil
+}
+
+func isWordRune(x rune) bool {
+	return (x >= 'a' && x <= 'z') || (x >= 'A' && x <= 'Z')
+}
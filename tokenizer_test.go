@@ -0,0 +1,34 @@
+package algebrain
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWordTokenizer(t *testing.T) {
+	tok := &WordTokenizer{Words: StandardWords}
+	query := "shift x by 12 in sin(x)+shifty"
+	tokens, err := tok.Encode(query)
+	if err != nil {
+		t.Fatal(err)
+	}
+	word := func(w string) int {
+		for i, x := range tok.Words {
+			if x == w {
+				return CharCount + i
+			}
+		}
+		panic("unknown word: " + w)
+	}
+	expected := []int{word("shift"), ' ', 'x', ' ', word("by"), ' ', '1', '2', ' ',
+		word("in"), ' ', word("sin"), '(', 'x', ')', '+', 's', 'h', 'i', 'f', 't', 'y'}
+	if !reflect.DeepEqual(tokens, expected) {
+		t.Errorf("expected %v but got %v", expected, tokens)
+	}
+	if decoded := tok.Decode(tokens); decoded != query {
+		t.Errorf("expected %q but got %q", query, decoded)
+	}
+	if _, err := tok.Encode("x×y"); err == nil {
+		t.Error("expected error for out-of-range rune")
+	}
+}
@@ -0,0 +1,101 @@
+package algebrain
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+
+	"github.com/unixpickle/anydiff/anyseq"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
+)
+
+// A Trace records the internals of a query for debugging
+// and visualization.
+type Trace struct {
+	// ReadSteps describes the encoder timesteps, in the
+	// order the query tokens were fed to the encoder.
+	ReadSteps []*TraceStep `json:"read_steps"`
+
+	// WriteSteps describes the decoder timesteps.
+	WriteSteps []*TraceStep `json:"write_steps"`
+}
+
+// A TraceStep describes one timestep of a Trace.
+type TraceStep struct {
+	// Token is the ID of the token consumed (while
+	// reading) or emitted (while writing).
+	Token int `json:"token"`
+
+	// Text is the decoded text of the token.
+	// It is empty for the terminator.
+	Text string `json:"text"`
+
+	// Encoded is the encoder output for read steps.
+	Encoded []float64 `json:"encoded,omitempty"`
+
+	// LogProbs is the output distribution for write steps.
+	LogProbs []float64 `json:"log_probs,omitempty"`
+}
+
+// WriteJSON writes the trace as a JSON document.
+func (t *Trace) WriteJSON(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(t); err != nil {
+		return essentials.AddCtx("write trace", err)
+	}
+	return nil
+}
+
+// QueryTraced is like Query, but it also records a Trace
+// of the encoder outputs and decoder distributions.
+//
+// It panics if the query cannot be tokenized.
+func (n *Network) QueryTraced(q string) (string, *Trace) {
+	queryTokens, err := n.Tokenizer.Encode(n.Preprocessor.Apply(q))
+	if err != nil {
+		panic(err)
+	}
+	if n.ReverseInput {
+		for i := 0; i < len(queryTokens)/2; i++ {
+			j := len(queryTokens) - (i + 1)
+			queryTokens[i], queryTokens[j] = queryTokens[j], queryTokens[i]
+		}
+	}
+
+	trace := &Trace{}
+	if len(queryTokens) > 0 {
+		inVecs, err := n.inputSequence(&Sample{Query: q})
+		if err != nil {
+			panic(err)
+		}
+		enc := n.Encoder.Apply(anyseq.ConstSeqList(n.creator(), [][]anyvec.Vector{inVecs}))
+		for i, batch := range enc.Output() {
+			trace.ReadSteps = append(trace.ReadSteps, &TraceStep{
+				Token:   queryTokens[i],
+				Text:    n.Tokenizer.Decode(queryTokens[i : i+1]),
+				Encoded: append([]float64{}, vectorFloats(batch.Packed)...),
+			})
+		}
+	}
+
+	res, err := n.decode(context.Background(), q, func(logProbs []float64) int {
+		token := argMaxFloats(logProbs)
+		step := &TraceStep{
+			Token:    token,
+			LogProbs: append([]float64{}, logProbs...),
+		}
+		if token != Terminator {
+			step.Text = n.Tokenizer.Decode([]int{token})
+		}
+		trace.WriteSteps = append(trace.WriteSteps, step)
+		return token
+	})
+	if err != nil {
+		panic(err)
+	}
+	if res.Truncated {
+		// The last token was chosen but never emitted.
+		trace.WriteSteps = trace.WriteSteps[:len(trace.WriteSteps)-1]
+	}
+	return res.Response, trace
+}
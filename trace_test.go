@@ -0,0 +1,52 @@
+package algebrain
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestQueryTraced(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	for _, reverse := range []bool{false, true} {
+		net.ReverseInput = reverse
+		const query = "evaluate 1+2"
+		response, trace := net.QueryTraced(query)
+		if response != net.Query(query) {
+			t.Errorf("expected response %q but got %q", net.Query(query), response)
+		}
+		if len(trace.ReadSteps) != len(query) {
+			t.Fatalf("expected %d read steps but got %d", len(query), len(trace.ReadSteps))
+		}
+		first, last := trace.ReadSteps[0].Text, trace.ReadSteps[len(query)-1].Text
+		if (!reverse && (first != "e" || last != "2")) || (reverse && (first != "2" || last != "e")) {
+			t.Errorf("unexpected read order (reverse=%v): %q ... %q", reverse, first, last)
+		}
+		var emitted string
+		for i, step := range trace.WriteSteps {
+			if len(step.LogProbs) != CharCount {
+				t.Errorf("write step %d: expected %d log probs but got %d", i, CharCount,
+					len(step.LogProbs))
+			}
+			emitted += step.Text
+		}
+		if emitted != response {
+			t.Errorf("trace emitted %q but response is %q", emitted, response)
+		}
+
+		var buf bytes.Buffer
+		if err := trace.WriteJSON(&buf); err != nil {
+			t.Fatal(err)
+		}
+		var decoded Trace
+		if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+			t.Fatal(err)
+		}
+		if len(decoded.ReadSteps) != len(trace.ReadSteps) ||
+			len(decoded.WriteSteps) != len(trace.WriteSteps) {
+			t.Error("JSON trace has the wrong number of steps")
+		}
+	}
+}
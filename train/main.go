@@ -1,19 +1,22 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"log"
+	"math"
 	"math/rand"
+	"os"
 	"strings"
 	"time"
 
 	"github.com/unixpickle/algebrain"
 	"github.com/unixpickle/algebrain/mathexpr"
 	"github.com/unixpickle/anynet/anysgd"
+	"github.com/unixpickle/anyvec"
 	"github.com/unixpickle/anyvec/anyvec32"
 	"github.com/unixpickle/essentials"
 	"github.com/unixpickle/rip"
-	"github.com/unixpickle/serializer"
 )
 
 var Generators = map[string]algebrain.Generator{
@@ -74,62 +77,245 @@ var Generators = map[string]algebrain.Generator{
 		},
 		MaxDepth: 5,
 	},
+	"AugmentedShift": &algebrain.AugmentedShiftGenerator{
+		ShiftGenerator: algebrain.ShiftGenerator{
+			Generator: &mathexpr.Generator{
+				NoReals:  true,
+				VarNames: []string{"x"},
+			},
+			MaxDepth: 3,
+		},
+	},
+	"DecToBin": &algebrain.BaseConversionGenerator{
+		FromBase: 10,
+		ToBase:   2,
+	},
+	"HexToDec": &algebrain.BaseConversionGenerator{
+		FromBase: 16,
+		ToBase:   10,
+	},
+	"Copy":              &algebrain.CopyGenerator{},
+	"Reverse":           &algebrain.ReverseGenerator{},
+	"Units":             &algebrain.UnitArithmeticGenerator{},
+	"Conditional":       &algebrain.ConditionalGenerator{},
+	"AbsValue":          &algebrain.AbsValueSolveGenerator{},
+	"ArithmeticPattern": &algebrain.PatternGenerator{},
+	"GeometricPattern":  &algebrain.PatternGenerator{Geometric: true},
+	"Combinatorics":     &algebrain.CombinatoricsGenerator{},
+	"ExponentRules":     &algebrain.ExponentRuleGenerator{AllowNonPositive: true},
+	"WordedEval":        &algebrain.WordedEvalGenerator{},
+	"FunctionEval":      &algebrain.FunctionEvalGenerator{},
+	"Inequality":        &algebrain.InequalityGenerator{},
+	"Matrix":            &algebrain.MatrixGenerator{Allow3x3: true},
+	"ImplicitMult":      &algebrain.ImplicitMultGenerator{},
 }
 
 func main() {
 	var genNames string
 	var stepSize float64
+	var minStepSize float64
+	var restartSteps int
 	var batchSize int
 	var outFile string
 	var samplesPerGen int
+	var tokenizerName string
+	var eventFile string
+	var reverseInput bool
+	var preprocessor algebrain.Preprocessor
+	var progressSteps int
+	var maxDifficultyWeight float64
+	var microBatches int
+	var stream bool
+	var streamWorkers int
+	var spillDir string
 	flag.StringVar(&genNames, "generators",
 		"EasyShift,MediumShift,EasyScale,MediumScale,EasyEval,MediumEval,HardShift,HardScale",
 		"comma-separated generator list")
-	flag.Float64Var(&stepSize, "step", 0.001, "SGD step size")
-	flag.IntVar(&batchSize, "batch", 8, "SGD batch size")
+	flag.Float64Var(&stepSize, "step", algebrain.DefaultLearningRate, "SGD step size")
+	flag.IntVar(&restartSteps, "restart", 0,
+		"cosine annealing cycle length in steps (0 for a constant step size)")
+	flag.Float64Var(&minStepSize, "minstep", 0, "step size at the end of each annealing cycle")
+	flag.IntVar(&batchSize, "batch", 8, "SGD batch size (per micro-batch)")
+	flag.IntVar(&microBatches, "accumulate", 1,
+		"number of micro-batches to accumulate into each SGD batch")
 	flag.StringVar(&outFile, "file", "out_net", "output/input network file")
 	flag.IntVar(&samplesPerGen, "samples", 10000, "samples per generator")
+	flag.StringVar(&tokenizerName, "tokenizer", "char", "tokenizer for new networks (char or word)")
+	flag.BoolVar(&reverseInput, "reverse", false, "reverse queries for new networks")
+	flag.BoolVar(&preprocessor.FullWidth, "fullwidth", false,
+		"convert full-width digits and operators in queries to ASCII")
+	flag.BoolVar(&preprocessor.Lowercase, "lowercase", false, "lowercase queries")
+	flag.BoolVar(&preprocessor.CollapseSpace, "collapsespace", false,
+		"collapse whitespace in queries")
+	flag.Float64Var(&maxDifficultyWeight, "difficulty", 1,
+		"cost weight for the most complex samples (1 disables weighting)")
+	flag.IntVar(&progressSteps, "progress", 0,
+		"show a progress bar for this many steps instead of logging costs")
+	flag.StringVar(&eventFile, "events", "", "optional TensorBoard event file for costs")
+	flag.BoolVar(&stream, "stream", false,
+		"generate batches in the background instead of creating all samples up front")
+	flag.IntVar(&streamWorkers, "workers", 0, "sample producers when streaming (0 for one per CPU)")
+	flag.StringVar(&spillDir, "spill", "", "optional directory to record streamed batches in")
 	flag.Parse()
 
-	log.Println("Creating samples...")
-	training := generateSamples(genNames, samplesPerGen)
+	var training anysgd.SampleList
+	if stream {
+		// Each epoch covers the same number of samples as
+		// it would without streaming.
+		numGens := len(strings.Split(genNames, ","))
+		training = algebrain.StreamSampleList(numGens * samplesPerGen)
+	} else {
+		log.Println("Creating samples...")
+		samples := generateSamples(genNames, samplesPerGen)
+		if maxDifficultyWeight != 1 {
+			algebrain.WeightByComplexity(samples, maxDifficultyWeight)
+		}
+		training = samples
+	}
 
 	rand.Seed(time.Now().UnixNano())
 
-	var net *algebrain.Network
-	if err := serializer.LoadAny(outFile, &net); err != nil {
+	var created bool
+	net, err := algebrain.LoadOrCreate(outFile, func() (*algebrain.Network, error) {
 		log.Println("Creating new RNN block...")
-		net = algebrain.NewNetwork(anyvec32.CurrentCreator())
-	} else {
+		created = true
+		net := algebrain.NewNetwork(anyvec32.CurrentCreator(), createTokenizer(tokenizerName))
+		net.ReverseInput = reverseInput
+		net.Preprocessor = &preprocessor
+		return net, nil
+	})
+	if err != nil {
+		essentials.Die("Failed to load block:", err)
+	}
+	if !created {
 		log.Println("Loaded existing RNN block.")
+		flag.Visit(func(f *flag.Flag) {
+			if f.Name == "lowercase" || f.Name == "collapsespace" || f.Name == "fullwidth" {
+				if err := net.CheckPreprocessor(&preprocessor); err != nil {
+					essentials.Die(err)
+				}
+			}
+		})
+	}
+
+	var events *algebrain.TFEventLogger
+	if eventFile != "" {
+		events, err = algebrain.NewTFEventLogger(eventFile)
+		if err != nil {
+			essentials.Die("Failed to create event file:", err)
+		}
 	}
 
 	log.Println("Training...")
-	trainer := &algebrain.Trainer{Network: net}
+	trainer := &algebrain.Trainer{
+		Network:      net,
+		MicroBatches: microBatches,
+		Schedule:     algebrain.ConstantSchedule(stepSize),
+	}
+	if restartSteps > 0 {
+		trainer.Schedule = &algebrain.CosineRestartSchedule{
+			MinRate:     minStepSize,
+			MaxRate:     stepSize,
+			CycleLength: restartSteps,
+		}
+	}
+	if progressSteps > 0 {
+		trainer.Progress = algebrain.NewTextProgressBar(progressSteps, os.Stderr)
+	}
+	var sampleStream *algebrain.SampleStream
+	ctx, cancel := context.WithCancel(context.Background())
+	if stream {
+		if maxDifficultyWeight != 1 {
+			log.Println("Difficulty weighting is not supported when streaming.")
+		}
+		sampleStream = &algebrain.SampleStream{
+			Generator: algebrain.MixtureGenerator(lookupGenerators(genNames)),
+			BatchSize: batchSize * microBatches,
+			Workers:   streamWorkers,
+			SpillDir:  spillDir,
+		}
+		trainer.StartStream(ctx, sampleStream)
+	}
 	var iter int
 	sgd := &anysgd.SGD{
 		Fetcher:     trainer,
 		Gradienter:  trainer,
 		Transformer: &anysgd.Adam{},
 		Samples:     training,
-		Rater:       anysgd.ConstRater(stepSize),
-		BatchSize:   batchSize,
+		Rater:       trainer,
+		BatchSize:   batchSize * microBatches,
 		StatusFunc: func(b anysgd.Batch) {
-			log.Printf("iter %d: cost=%v", iter, trainer.LastCost)
+			if trainer.Progress == nil {
+				log.Printf("iter %d: cost=%v", iter, trainer.LastCost)
+			}
+			if events != nil {
+				events.LogScalar("cost", numericValue(trainer.LastCost), iter)
+			}
 			iter++
 		},
 	}
-	sgd.Run(rip.NewRIP().Chan())
+	if err := sgd.Run(rip.NewRIP().Chan()); err != nil {
+		log.Println("Training stopped:", err)
+	}
+	cancel()
+	if sampleStream != nil {
+		if err := sampleStream.Close(); err != nil {
+			log.Println("Failed to close stream:", err)
+		}
+		stats := sampleStream.Stats()
+		log.Printf("Streamed %d batches: producer stall=%v consumer stall=%v", stats.Batches,
+			stats.ProducerStall, stats.ConsumerStall)
+	}
+	if trainer.Progress != nil {
+		trainer.Progress.Close()
+	}
 
-	if err := serializer.SaveAny(outFile, net); err != nil {
+	if events != nil {
+		if err := events.Close(); err != nil {
+			log.Println("Failed to write event file:", err)
+		}
+	}
+
+	if err := net.SaveAndValidate(outFile); err != nil {
 		essentials.Die("Failed to save block:", err)
 	}
 }
 
+func numericValue(n anyvec.Numeric) float64 {
+	switch n := n.(type) {
+	case float32:
+		return float64(n)
+	case float64:
+		return n
+	}
+	return math.NaN()
+}
+
+func createTokenizer(name string) algebrain.Tokenizer {
+	switch name {
+	case "char":
+		return &algebrain.CharTokenizer{}
+	case "word":
+		return &algebrain.WordTokenizer{Words: algebrain.StandardWords}
+	}
+	essentials.Die("Unknown tokenizer:", name)
+	panic("unreachable")
+}
+
 func generateSamples(genNames string, samplesPer int) algebrain.SampleList {
 	// Ensure that we get the same samples every time.
 	rand.Seed(123)
 
+	var training algebrain.SampleList
+	for _, g := range lookupGenerators(genNames) {
+		for i := 0; i < samplesPer; i++ {
+			training = append(training, g.Generate())
+		}
+	}
+	return training
+}
+
+func lookupGenerators(genNames string) []algebrain.Generator {
 	names := strings.Split(genNames, ",")
 	gens := make([]algebrain.Generator, len(names))
 	for i, x := range names {
@@ -139,11 +325,5 @@ func generateSamples(genNames string, samplesPer int) algebrain.SampleList {
 			essentials.Die("Unknown generator:", x)
 		}
 	}
-	var training algebrain.SampleList
-	for _, g := range gens {
-		for i := 0; i < samplesPer; i++ {
-			training = append(training, g.Generate())
-		}
-	}
-	return training
+	return gens
 }
@@ -1,13 +1,19 @@
 package algebrain
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
 	"github.com/unixpickle/anydiff"
 	"github.com/unixpickle/anydiff/anyseq"
 	"github.com/unixpickle/anynet"
-	"github.com/unixpickle/anynet/anyrnn"
 	"github.com/unixpickle/anynet/anys2s"
 	"github.com/unixpickle/anynet/anysgd"
 	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/essentials"
 )
 
 // A SampleList wraps a slice of Samples for training.
@@ -28,67 +34,334 @@ func (s SampleList) Slice(i, j int) anysgd.SampleList {
 	return append(SampleList{}, s[i:j]...)
 }
 
-// Batch is a batch of fetched training samples.
-type Batch struct {
-	EncIn  anyseq.Seq
-	DecIn  anyseq.Seq
-	DecOut anyseq.Seq
+// ErrTrainingDiverged is matched (via errors.Is) by the
+// errors which a Trainer reports when training diverges.
+var ErrTrainingDiverged = errors.New("training diverged")
+
+// A DivergenceError reports a batch with a non-finite cost
+// or gradient.
+type DivergenceError struct {
+	// Step is the number of calls to Gradient before the
+	// one which diverged.
+	Step int
+
+	// Batch is the offending batch.
+	Batch anysgd.Batch
+}
+
+// Error returns the error message.
+func (d *DivergenceError) Error() string {
+	return fmt.Sprintf("%s at step %d", ErrTrainingDiverged, d.Step)
+}
+
+// Unwrap returns ErrTrainingDiverged.
+func (d *DivergenceError) Unwrap() error {
+	return ErrTrainingDiverged
 }
 
 // A Trainer computes costs and gradients for a Network.
+//
+// If a batch produces a non-finite cost or gradient, the
+// gradient is zeroed so that it cannot corrupt the
+// network, and the next call to Fetch fails with a
+// *DivergenceError, which stops anysgd.SGD.
+// Since anysgd.SGD fetches batches ahead of time, it may
+// still pass one more batch to Gradient.
+// Gradient returns zero gradients for such batches, until
+// a batch is fetched after the error has been reported.
+// Optimizers with momentum may still change the network
+// after a divergence.
 type Trainer struct {
 	Network *Network
 
 	// LastCost is set by every call to Gradient.
 	LastCost anyvec.Numeric
+
+	// Progress, if non-nil, is updated with the cost after
+	// every call to Gradient.
+	Progress ProgressBar
+
+	// MicroBatches, if greater than 1, splits every
+	// fetched batch into this many micro-batches.
+	// Gradients are computed one micro-batch at a time and
+	// averaged, so memory usage is bounded by the size of
+	// a micro-batch while the effective batch size is the
+	// size of the whole batch.
+	//
+	// To accumulate K micro-batches of size B, set this to
+	// K and use a batch size of K*B in the optimizer.
+	MicroBatches int
+
+	// Cost is the per-token anynet.Cost, comparing the
+	// network's output log probabilities to the targets,
+	// which are one-hot vectors scaled by the sample
+	// weights.
+	// The cost is averaged over tokens.
+	// If this is nil, anynet.DotCost (the cross-entropy)
+	// is used.
+	//
+	// Scaling the targets only weights costs which are
+	// linear in the targets, like DotCost, so Fetch fails
+	// if Cost is set and a sample has a Weight other than
+	// 0 or 1.
+	Cost anynet.Cost
+
+	// Distillation, if non-nil, trains the network to
+	// match a teacher network instead of only the expected
+	// responses.
+	Distillation *Distillation
+
+	// Schedule determines the learning rates returned by
+	// Rate.
+	// If this is nil, DefaultLearningRate is used for
+	// every step.
+	Schedule LearningRateSchedule
+
+	// Stream, if non-nil, supplies the batches returned by
+	// Fetch, which then ignores its sample list.
+	// It should be set with StartStream.
+	Stream *SampleStream
+
+	step int
+	tape *GradientTape
+
+	// divergence is a DivergenceError which Fetch has not
+	// reported yet, and diverged is set until a batch is
+	// fetched after it has been reported.
+	divergenceLock sync.Mutex
+	divergence     *DivergenceError
+	diverged       bool
 }
 
-// Fetch creates a *Batch from a SampleList.
+// StartStream starts a SampleStream which produces batches
+// like Fetch, and sets t.Stream to it.
+//
+// The samples passed to Fetch are then ignored, so the
+// anysgd.SGD can use a StreamSampleList.
+// Training stops with an error once ctx is done.
+func (t *Trainer) StartStream(ctx context.Context, s *SampleStream) {
+	t.Stream = s
+	s.Start(ctx, t.makeBatch)
+}
+
+// Fetch creates a batch from a SampleList.
+//
+// The batch is a *Batch, unless MicroBatches is greater
+// than 1.
 func (t *Trainer) Fetch(s anysgd.SampleList) (anysgd.Batch, error) {
-	var encIn, decIn, decOut [][]anyvec.Vector
-	for i := 0; i < s.Len(); i++ {
-		sample := s.(SampleList)[i]
-		encIn = append(encIn, sample.InputSequence())
-		decIn = append(decIn, sample.DecoderInSequence())
-		decOut = append(decOut, sample.DecoderOutSequence())
+	t.divergenceLock.Lock()
+	if t.divergence != nil {
+		err := t.divergence
+		t.divergence = nil
+		t.divergenceLock.Unlock()
+		return nil, err
+	}
+	t.diverged = false
+	t.divergenceLock.Unlock()
+
+	if t.Stream != nil {
+		return t.Stream.Next()
 	}
-	return &Batch{
-		EncIn:  anyseq.ConstSeqList(t.Network.creator(), encIn),
-		DecIn:  anyseq.ConstSeqList(t.Network.creator(), decIn),
-		DecOut: anyseq.ConstSeqList(t.Network.creator(), decOut),
-	}, nil
+	return t.makeBatch(s.(SampleList))
 }
 
-// TotalCost computes the cost for the *Batch.
+// makeBatch encodes samples as a batch for Fetch.
+// It is safe to call from multiple goroutines.
+func (t *Trainer) makeBatch(samples SampleList) (anysgd.Batch, error) {
+	if t.MicroBatches <= 1 {
+		return t.networkBatch(samples)
+	}
+	res := &microBatches{}
+	numMicro := essentials.MinInt(t.MicroBatches, len(samples))
+	for i := 0; i < numMicro; i++ {
+		start, end := i*len(samples)/numMicro, (i+1)*len(samples)/numMicro
+		batch, err := t.networkBatch(samples[start:end])
+		if err != nil {
+			return nil, err
+		}
+		var numTokens int
+		for _, x := range batch.DecOut.Output() {
+			numTokens += x.NumPresent()
+		}
+		res.Batches = append(res.Batches, batch)
+		res.Tokens = append(res.Tokens, numTokens)
+	}
+	return res, nil
+}
+
+// networkBatch creates a *Batch, using distillation
+// targets if t.Distillation is set.
+func (t *Trainer) networkBatch(samples SampleList) (*Batch, error) {
+	if t.Cost != nil {
+		for _, sample := range samples {
+			if sample.Weight != 0 && sample.Weight != 1 {
+				return nil, errors.New("make batch: sample weights require the default cost")
+			}
+		}
+	}
+	batch, err := t.Network.MakeBatch(samples)
+	if err != nil {
+		return nil, err
+	}
+	if t.Distillation != nil {
+		err := t.Distillation.distillBatch(t.Network, samples, batch, t.Stream == nil)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return batch, nil
+}
+
+// TotalCost computes the cost for a fetched batch.
 func (t *Trainer) TotalCost(b anysgd.Batch) anydiff.Res {
-	trainer, batch := t.tempTrainer(b)
+	if micro, ok := b.(*microBatches); ok {
+		var res anydiff.Res
+		for i, batch := range micro.Batches {
+			cost := anydiff.Scale(t.TotalCost(batch), micro.fraction(t.Network.creator(), i))
+			if res == nil {
+				res = cost
+			} else {
+				res = anydiff.Add(res, cost)
+			}
+		}
+		return res
+	}
+	trainer, batch := t.tempTrainer(b.(*Batch))
 	return trainer.TotalCost(batch)
 }
 
 // Gradient computes the cost gradient.
 // It sets t.LastCost to the cost.
+//
+// After a divergence, it returns a zero gradient without
+// updating t.LastCost, as described for Trainer.
 func (t *Trainer) Gradient(b anysgd.Batch) anydiff.Grad {
-	trainer, batch := t.tempTrainer(b)
-	res := trainer.Gradient(batch)
-	t.LastCost = trainer.LastCost
+	t.divergenceLock.Lock()
+	diverged := t.diverged
+	t.divergenceLock.Unlock()
+	if diverged {
+		return anydiff.NewGrad(t.Network.Parameters()...)
+	}
+
+	var res anydiff.Grad
+	if micro, ok := b.(*microBatches); ok {
+		res = t.accumulatedGradient(micro)
+	} else {
+		trainer, batch := t.tempTrainer(b.(*Batch))
+		res = trainer.Gradient(batch)
+		t.LastCost = trainer.LastCost
+	}
+	if !gradientFinite(t.Network.creator(), t.LastCost, res) {
+		res.Clear()
+		t.divergenceLock.Lock()
+		t.divergence = &DivergenceError{Step: t.step, Batch: b}
+		t.diverged = true
+		t.divergenceLock.Unlock()
+	}
+	t.step++
+	if t.Progress != nil {
+		t.Progress.Update(t.step, t.Network.creator().Float64(t.LastCost))
+	}
+	if t.tape != nil {
+		t.tape.record(res)
+	}
+	return res
+}
+
+// Rate implements anysgd.Rater, so that the Trainer can
+// apply its Schedule to every step of an anysgd.SGD.
+//
+// The epoch is ignored.
+// Instead, the rate is determined by the number of calls
+// to Gradient before the most recent one, i.e. the step
+// whose gradient is being applied.
+func (t *Trainer) Rate(epoch float64) float64 {
+	step := essentials.MaxInt(t.step-1, 0)
+	if t.Schedule == nil {
+		return ConstantSchedule(DefaultLearningRate).Rate(step)
+	}
+	return t.Schedule.Rate(step)
+}
+
+// accumulatedGradient averages the gradients of the
+// micro-batches, weighted by their numbers of tokens.
+func (t *Trainer) accumulatedGradient(micro *microBatches) anydiff.Grad {
+	c := t.Network.creator()
+	var res anydiff.Grad
+	var cost float64
+	for i, batch := range micro.Batches {
+		trainer, b := t.tempTrainer(batch)
+		grad := trainer.Gradient(b)
+		fraction := micro.fraction(c, i)
+		grad.Scale(fraction)
+		cost += c.Float64(trainer.LastCost) * c.Float64(fraction)
+		if res == nil {
+			res = grad
+		} else {
+			for param, vec := range grad {
+				res[param].Add(vec)
+			}
+		}
+	}
+	t.LastCost = c.MakeNumeric(cost)
 	return res
 }
 
-func (t *Trainer) tempTrainer(b anysgd.Batch) (*anys2s.Trainer, *anys2s.Batch) {
+// gradientFinite checks that a cost and every entry of a
+// gradient are finite.
+func gradientFinite(c anyvec.Creator, cost anyvec.Numeric, grad anydiff.Grad) bool {
+	finite := func(x float64) bool {
+		return !math.IsNaN(x) && !math.IsInf(x, 0)
+	}
+	if !finite(c.Float64(cost)) {
+		return false
+	}
+	for _, vec := range grad {
+		// Non-finite entries make the sum non-finite.
+		if !finite(c.Float64(anyvec.Sum(vec))) {
+			return false
+		}
+	}
+	return true
+}
+
+func (t *Trainer) tempTrainer(b *Batch) (*anys2s.Trainer, *anys2s.Batch) {
+	var cost anynet.Cost = anynet.DotCost{}
+	if t.Cost != nil {
+		cost = t.Cost
+	}
 	return &anys2s.Trainer{
 			Func: func(s anyseq.Seq) anyseq.Seq {
-				enc := t.Network.Encoder.Apply(s)
-				return anyseq.Pool(enc, func(enc anyseq.Seq) anyseq.Seq {
-					block := t.Network.Align.Block(enc)
-					return anyseq.Map(anyrnn.Map(b.(*Batch).DecIn, block),
-						t.Network.Output.Apply)
-				})
+				return t.Network.applyTeacherForced(s, b.DecIn)
 			},
-			Cost:    anynet.DotCost{},
+			Cost:    cost,
 			Params:  t.Network.Parameters(),
 			Average: true,
 		}, &anys2s.Batch{
-			Inputs:  b.(*Batch).EncIn,
-			Outputs: b.(*Batch).DecOut,
+			Inputs:  b.EncIn,
+			Outputs: b.DecOut,
 		}
 }
+
+// microBatches is the batch type fetched when gradients
+// are accumulated over micro-batches.
+type microBatches struct {
+	Batches []*Batch
+
+	// Tokens stores the number of output tokens in each
+	// micro-batch.
+	Tokens []int
+}
+
+// fraction gets the fraction of all the output tokens
+// which belong to the given micro-batch.
+//
+// Costs are averaged over tokens, so this is the weight of
+// the micro-batch in the average for the whole batch.
+func (m *microBatches) fraction(c anyvec.Creator, idx int) anyvec.Numeric {
+	var total int
+	for _, count := range m.Tokens {
+		total += count
+	}
+	return c.MakeNumeric(float64(m.Tokens[idx]) / float64(total))
+}
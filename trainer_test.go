@@ -0,0 +1,307 @@
+package algebrain
+
+import (
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anydiff"
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anynet/anysgd"
+	"github.com/unixpickle/anyvec"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestTrainerSampleWeights(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	trainer := &Trainer{Network: net}
+	gradient := func(weight float64) []float64 {
+		samples := SampleList{
+			{Query: "scale x by 2 in x", Response: "x*2", Weight: weight},
+			{Query: "evaluate 1+1", Response: "Result: 2"},
+		}
+		batch, err := trainer.Fetch(samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+		grad := trainer.Gradient(batch)
+		var res []float64
+		for _, p := range net.Parameters() {
+			res = append(res, grad[p].Data().([]float64)...)
+		}
+		return res
+	}
+
+	// The gradient is linear in the weight, so the change
+	// from doubling 2 to 4 should be twice the change from
+	// doubling 1 to 2.
+	grad1, grad2, grad4 := gradient(1), gradient(2), gradient(4)
+	for i, x := range grad1 {
+		expected := 2 * (grad2[i] - x)
+		actual := grad4[i] - grad2[i]
+		if math.Abs(actual-expected) > 1e-8 {
+			t.Fatalf("gradient %d: expected delta %f but got %f", i, expected, actual)
+		}
+	}
+
+	samples := []*Sample{{Query: "evaluate 1+1", Response: "Result: 2"}}
+	batch, err := net.MakeBatch([]*Sample{{Query: samples[0].Query,
+		Response: samples[0].Response, Weight: 3}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	unweighted, err := net.MakeBatch(samples)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := 3 * anyvec.Sum(trainer.TotalCost(unweighted).Output()).(float64)
+	actual := anyvec.Sum(trainer.TotalCost(batch).Output()).(float64)
+	if math.Abs(actual-expected) > 1e-8 {
+		t.Errorf("expected cost %f but got %f", expected, actual)
+	}
+}
+
+func TestTrainerMicroBatches(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	samples := SampleList{
+		{Query: "scale x by 2 in x", Response: "x*2"},
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "evaluate 3*4", Response: "Result: 12", Weight: 2},
+		{Query: "shift x by 1 in x^2", Response: "(x-1)^2"},
+		{Query: "evaluate 9", Response: "Result: 9"},
+	}
+	gradient := func(trainer *Trainer) ([]float64, float64, float64) {
+		batch, err := trainer.Fetch(samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+		grad := trainer.Gradient(batch)
+		var res []float64
+		for _, p := range net.Parameters() {
+			res = append(res, grad[p].Data().([]float64)...)
+		}
+		totalCost := anyvec.Sum(trainer.TotalCost(batch).Output()).(float64)
+		return res, trainer.LastCost.(float64), totalCost
+	}
+
+	expected, expectedCost, expectedTotal := gradient(&Trainer{Network: net})
+	actual, actualCost, actualTotal := gradient(&Trainer{Network: net, MicroBatches: 3})
+	for i, x := range expected {
+		if math.Abs(actual[i]-x) > 1e-8 {
+			t.Fatalf("gradient %d: expected %f but got %f", i, x, actual[i])
+		}
+	}
+	if math.Abs(actualCost-expectedCost) > 1e-8 {
+		t.Errorf("expected cost %f but got %f", expectedCost, actualCost)
+	}
+	if math.Abs(actualTotal-expectedTotal) > 1e-8 {
+		t.Errorf("expected total cost %f but got %f", expectedTotal, actualTotal)
+	}
+}
+
+type recordingProgressBar struct {
+	steps  []int
+	losses []float64
+}
+
+func (r *recordingProgressBar) Update(step int, loss float64) {
+	r.steps = append(r.steps, step)
+	r.losses = append(r.losses, loss)
+}
+
+func (r *recordingProgressBar) Close() {
+}
+
+func TestTrainerProgress(t *testing.T) {
+	progress := &recordingProgressBar{}
+	trainer := &Trainer{
+		Network:  NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{}),
+		Progress: progress,
+	}
+	batch, err := trainer.Fetch(SampleList{{Query: "evaluate 1+1", Response: "Result: 2"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		trainer.Gradient(batch)
+	}
+	if len(progress.steps) != 2 || progress.steps[0] != 1 || progress.steps[1] != 2 {
+		t.Errorf("unexpected steps: %v", progress.steps)
+	}
+	if progress.losses[1] != trainer.LastCost.(float64) {
+		t.Errorf("expected loss %v but got %v", trainer.LastCost, progress.losses[1])
+	}
+}
+
+func TestTrainerDivergence(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	trainer := &Trainer{Network: net}
+	good := SampleList{{Query: "evaluate 1+1", Response: "Result: 2"}}
+	bad := SampleList{{Query: "evaluate 1+1", Response: "Result: 2", Weight: math.Inf(1)}}
+
+	batch, err := trainer.Fetch(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	trainer.Gradient(batch)
+
+	badBatch, err := trainer.Fetch(bad)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// anysgd.SGD may fetch the next batch before computing
+	// the gradient of the current one.
+	nextBatch, err := trainer.Fetch(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	grad := trainer.Gradient(badBatch)
+	for _, p := range net.Parameters() {
+		for _, x := range grad[p].Data().([]float64) {
+			if x != 0 {
+				t.Fatal("divergent gradient should be zeroed")
+			}
+		}
+	}
+	grad = trainer.Gradient(nextBatch)
+	for _, p := range net.Parameters() {
+		for _, x := range grad[p].Data().([]float64) {
+			if x != 0 {
+				t.Fatal("gradient after divergence should be zeroed")
+			}
+		}
+	}
+
+	_, err = trainer.Fetch(good)
+	var divergence *DivergenceError
+	if !errors.Is(err, ErrTrainingDiverged) || !errors.As(err, &divergence) {
+		t.Fatalf("expected divergence error but got %v", err)
+	}
+	if divergence.Batch != badBatch || divergence.Step != 1 {
+		t.Errorf("unexpected divergence: step %d, batch %v", divergence.Step, divergence.Batch)
+	}
+	if _, err := trainer.Fetch(good); err != nil {
+		t.Errorf("error should only be reported once, got: %v", err)
+	}
+}
+
+func TestTrainerDivergenceSGD(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	trainer := &Trainer{Network: net}
+	good := &Sample{Query: "evaluate 1+1", Response: "Result: 2"}
+	bad := &Sample{Query: "evaluate 1+1", Response: "Result: 2", Weight: math.Inf(1)}
+
+	var steps int
+	var snapshot []anyvec.Vector
+	sgd := &anysgd.SGD{
+		Fetcher:    trainer,
+		Gradienter: trainer,
+		Samples:    unshuffledSamples{good, bad, good, good},
+		Rater:      anysgd.ConstRater(0.01),
+		BatchSize:  1,
+		StatusFunc: func(b anysgd.Batch) {
+			// StatusFunc is called before each step, so this
+			// snapshot is taken before the divergent step.
+			steps++
+			if steps == 2 {
+				for _, p := range net.Parameters() {
+					snapshot = append(snapshot, p.Vector.Copy())
+				}
+			}
+		},
+	}
+	if err := sgd.Run(nil); !errors.Is(err, ErrTrainingDiverged) {
+		t.Fatalf("expected divergence but got %v", err)
+	}
+	for i, p := range net.Parameters() {
+		diff := p.Vector.Copy()
+		diff.Sub(snapshot[i])
+		if anyvec.AbsMax(diff).(float64) != 0 {
+			t.Fatalf("parameters changed after %d steps", steps)
+		}
+	}
+}
+
+// unshuffledSamples is a SampleList which anysgd.SGD
+// cannot shuffle.
+type unshuffledSamples SampleList
+
+func (u unshuffledSamples) Len() int {
+	return len(u)
+}
+
+func (u unshuffledSamples) Swap(i, j int) {
+}
+
+func (u unshuffledSamples) Slice(i, j int) anysgd.SampleList {
+	return SampleList(u[i:j])
+}
+
+// doubledCost is twice the cross-entropy cost.
+type doubledCost struct {
+	Calls int
+}
+
+func (d *doubledCost) Cost(desired, actual anydiff.Res, n int) anydiff.Res {
+	d.Calls++
+	c := actual.Output().Creator()
+	return anydiff.Scale(anynet.DotCost{}.Cost(desired, actual, n), c.MakeNumeric(2))
+}
+
+func TestTrainerCustomCost(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	samples := SampleList{{Query: "evaluate 1+1", Response: "Result: 2"}}
+	gradient := func(trainer *Trainer) (float64, []float64) {
+		batch, err := trainer.Fetch(samples)
+		if err != nil {
+			t.Fatal(err)
+		}
+		grad := trainer.Gradient(batch)
+		var res []float64
+		for _, p := range net.Parameters() {
+			res = append(res, grad[p].Data().([]float64)...)
+		}
+		return trainer.LastCost.(float64), res
+	}
+
+	cost := &doubledCost{}
+	defaultCost, defaultGrad := gradient(&Trainer{Network: net})
+	customCost, customGrad := gradient(&Trainer{Network: net, Cost: cost})
+	if cost.Calls == 0 {
+		t.Fatal("custom cost was not used")
+	}
+	if math.Abs(customCost-2*defaultCost) > 1e-8 {
+		t.Errorf("expected cost %f but got %f", 2*defaultCost, customCost)
+	}
+	var nonZero bool
+	for i, x := range defaultGrad {
+		if math.Abs(customGrad[i]-2*x) > 1e-8 {
+			t.Fatalf("gradient %d: expected %f but got %f", i, 2*x, customGrad[i])
+		}
+		nonZero = nonZero || x != 0
+	}
+	if !nonZero {
+		t.Error("gradient is zero")
+	}
+}
+
+func TestTrainerCustomCostWeights(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	trainer := &Trainer{Network: net, Cost: &doubledCost{}}
+	unweighted := SampleList{
+		{Query: "evaluate 1+1", Response: "Result: 2"},
+		{Query: "evaluate 1+2", Response: "Result: 3", Weight: 1},
+	}
+	if _, err := trainer.Fetch(unweighted); err != nil {
+		t.Error(err)
+	}
+	weighted := append(unweighted, &Sample{Query: "evaluate 2+2", Response: "Result: 4",
+		Weight: 2})
+	if _, err := trainer.Fetch(weighted); err == nil {
+		t.Error("expected error for weighted samples with a custom cost")
+	}
+	trainer.Cost = nil
+	if _, err := trainer.Fetch(weighted); err != nil {
+		t.Error(err)
+	}
+}
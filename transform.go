@@ -0,0 +1,159 @@
+package algebrain
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// A QueryTransformer rewrites a query before it is passed
+// to a Network.
+type QueryTransformer func(string) string
+
+// Chain creates a QueryTransformer which applies each of
+// the transformers in order.
+// With no transformers, queries are unchanged.
+func Chain(transformers ...QueryTransformer) QueryTransformer {
+	return func(query string) string {
+		for _, t := range transformers {
+			query = t(query)
+		}
+		return query
+	}
+}
+
+// TrimSpaces removes leading and trailing whitespace.
+func TrimSpaces(query string) string {
+	return strings.TrimSpace(query)
+}
+
+// NormalizeOperatorSpacing removes the whitespace around
+// operators and parentheses, so that "1 + (2 * x)" becomes
+// "1+(2*x)".
+//
+// Whitespace next to a word of two or more letters is
+// kept, so "shift x by -3" is unchanged.
+// Other runs of whitespace are replaced with a single
+// space, and leading and trailing whitespace is removed.
+func NormalizeOperatorSpacing(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return ""
+	}
+	res := fields[0]
+	for i, field := range fields[1:] {
+		prev := fields[i]
+		joinable := isOperatorRune(lastRune(prev)) || isOperatorRune(firstRune(field))
+		if joinable && trailingLetters(prev) < 2 && leadingLetters(field) < 2 {
+			res += field
+		} else {
+			res += " " + field
+		}
+	}
+	return res
+}
+
+// LowercaseCommand converts the first word of the query,
+// such as "EVALUATE" or "Shift", to lowercase.
+func LowercaseCommand(query string) string {
+	start := strings.IndexFunc(query, func(r rune) bool {
+		return !unicode.IsSpace(r)
+	})
+	if start < 0 {
+		return query
+	}
+	end := strings.IndexFunc(query[start:], unicode.IsSpace)
+	if end < 0 {
+		end = len(query)
+	} else {
+		end += start
+	}
+	return query[:start] + strings.ToLower(query[start:end]) + query[end:]
+}
+
+// NormalizeUnicodeQuery replaces full-width digits,
+// arithmetic operators, and parentheses with their ASCII
+// equivalents, so that "１＋２" becomes "1+2".
+// Other characters are unchanged.
+//
+// To apply this to every query before it is validated,
+// set FullWidth in the network's Preprocessor.
+func NormalizeUnicodeQuery(query string) string {
+	return strings.Map(func(r rune) rune {
+		// The full-width forms are offset from ASCII by a
+		// constant.
+		if (r >= '０' && r <= '９') || strings.ContainsRune("＋－＊／（）", r) {
+			return r - ('０' - '0')
+		}
+		return r
+	}, query)
+}
+
+func isOperatorRune(r rune) bool {
+	return strings.ContainsRune("+-*/^=()", r)
+}
+
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
+func lastRune(s string) rune {
+	runes := []rune(s)
+	if len(runes) == 0 {
+		return 0
+	}
+	return runes[len(runes)-1]
+}
+
+func leadingLetters(s string) int {
+	var res int
+	for _, r := range s {
+		if !unicode.IsLetter(r) {
+			break
+		}
+		res++
+	}
+	return res
+}
+
+func trailingLetters(s string) int {
+	runes := []rune(s)
+	var res int
+	for i := len(runes) - 1; i >= 0 && unicode.IsLetter(runes[i]); i-- {
+		res++
+	}
+	return res
+}
+
+// A TransformedNetwork applies a QueryTransformer to every
+// query before passing it to a Network.
+type TransformedNetwork struct {
+	Network     *Network
+	Transformer QueryTransformer
+}
+
+// WithQueryTransformer creates a TransformedNetwork which
+// applies t to every query.
+//
+// Unlike the Preprocessor, t is not saved with the
+// network.
+func (n *Network) WithQueryTransformer(t QueryTransformer) *TransformedNetwork {
+	return &TransformedNetwork{Network: n, Transformer: t}
+}
+
+// Query transforms a query and runs it against the
+// network.
+//
+// It panics if the transformed query cannot be tokenized.
+func (t *TransformedNetwork) Query(q string) string {
+	return t.Network.Query(t.Transformer(q))
+}
+
+// QueryContext is like Query, but it returns an error
+// rather than panicking, as in Network.QueryContext.
+func (t *TransformedNetwork) QueryContext(ctx context.Context, q string) (string, error) {
+	return t.Network.QueryContext(ctx, t.Transformer(q))
+}
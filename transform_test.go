@@ -0,0 +1,80 @@
+package algebrain
+
+import (
+	"context"
+	"testing"
+
+	"github.com/unixpickle/anynet"
+	"github.com/unixpickle/anyvec/anyvec64"
+)
+
+func TestQueryTransformers(t *testing.T) {
+	if actual := Chain(TrimSpaces, LowercaseCommand)("  EVALUATE 1+1  "); actual !=
+		"evaluate 1+1" {
+		t.Errorf("unexpected chain result: %q", actual)
+	}
+	if actual := Chain()("  x "); actual != "  x " {
+		t.Errorf("empty chain changed query: %q", actual)
+	}
+	if actual := LowercaseCommand(" Shift X by 2 in X"); actual != " shift X by 2 in X" {
+		t.Errorf("unexpected LowercaseCommand result: %q", actual)
+	}
+
+	unicodeCases := map[string]string{
+		"１＋２":                "1+2",
+		"evaluate （３－x）＊４／５": "evaluate (3-x)*4/5",
+		"ｘ＋１":                "ｘ+1",
+	}
+	for in, expected := range unicodeCases {
+		if actual := NormalizeUnicodeQuery(in); actual != expected {
+			t.Errorf("NormalizeUnicodeQuery(%q): expected %q but got %q", in, expected,
+				actual)
+		}
+	}
+
+	spacing := map[string]string{
+		"evaluate 1 + (2 * x)":     "evaluate 1+(2*x)",
+		"shift x by -3 in x  -  1": "shift x by -3 in x-1",
+		"evaluate sin(x) ^ 2":      "evaluate sin(x)^2",
+		"  scale   y by 2 in y  ":  "scale y by 2 in y",
+		"":                         "",
+	}
+	for in, expected := range spacing {
+		if actual := NormalizeOperatorSpacing(in); actual != expected {
+			t.Errorf("NormalizeOperatorSpacing(%q): expected %q but got %q", in, expected,
+				actual)
+		}
+	}
+}
+
+func TestTransformedNetwork(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	biases := make([]float64, CharCount)
+	biases[Terminator] = 1000
+	outLayer := net.Output[0].(*anynet.FC)
+	outLayer.Biases.Vector.SetData(outLayer.Biases.Vector.Creator().MakeNumericList(biases))
+
+	var transformed string
+	tn := net.WithQueryTransformer(Chain(TrimSpaces, func(q string) string {
+		transformed = q
+		return q
+	}))
+	if res := tn.Query("  evaluate 1+1 "); res != "" {
+		t.Errorf("unexpected response: %q", res)
+	}
+	if transformed != "evaluate 1+1" {
+		t.Errorf("transformer was not applied: %q", transformed)
+	}
+	if _, err := tn.QueryContext(context.Background(), "é"); err == nil {
+		t.Error("expected error for untokenizable query")
+	}
+}
+
+func TestTransformedNetworkUnicode(t *testing.T) {
+	net := NewNetwork(anyvec64.CurrentCreator(), &CharTokenizer{})
+	tn := net.WithQueryTransformer(NormalizeUnicodeQuery)
+	expected := net.Query("evaluate 1+2")
+	if actual := tn.Query("evaluate １＋２"); actual != expected {
+		t.Errorf("expected %q but got %q", expected, actual)
+	}
+}
@@ -0,0 +1,126 @@
+package algebrain
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// A TTLCachedNetwork wraps a Network with a cache of query
+// responses.
+//
+// The cache holds at most a fixed number of entries,
+// evicting the least recently used entry when it is full,
+// and entries expire a fixed time after they are added.
+//
+// It is safe to use a TTLCachedNetwork from multiple
+// Goroutines.
+type TTLCachedNetwork struct {
+	Network *Network
+
+	maxEntries int
+	ttl        time.Duration
+	now        func() time.Time
+
+	lock      sync.Mutex
+	entries   map[string]*list.Element
+	lru       *list.List
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+type ttlCacheEntry struct {
+	Query    string
+	Response string
+	Expiry   time.Time
+}
+
+// WithTTLCache creates a TTLCachedNetwork which caches up
+// to maxEntries responses for the duration ttl.
+func (n *Network) WithTTLCache(maxEntries int, ttl time.Duration) *TTLCachedNetwork {
+	return &TTLCachedNetwork{
+		Network:    n,
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		now:        time.Now,
+		entries:    map[string]*list.Element{},
+		lru:        list.New(),
+	}
+}
+
+// Query is like Network.Query, but it uses the cache.
+//
+// It panics if the query cannot be tokenized.
+func (t *TTLCachedNetwork) Query(q string) string {
+	res, err := t.QueryContext(context.Background(), q)
+	if err != nil {
+		panic(err)
+	}
+	return res
+}
+
+// QueryContext is like Network.QueryContext, but it uses
+// the cache.
+// Failed queries are not cached.
+func (t *TTLCachedNetwork) QueryContext(ctx context.Context, q string) (string, error) {
+	if res, ok := t.lookup(q); ok {
+		return res, nil
+	}
+	res, err := t.Network.QueryContext(ctx, q)
+	if err != nil {
+		return "", err
+	}
+	t.insert(q, res)
+	return res, nil
+}
+
+// CacheStats returns the number of cache hits and misses,
+// and the number of entries evicted to stay within the
+// size limit.
+// Expired entries count as misses rather than evictions.
+func (t *TTLCachedNetwork) CacheStats() (hits, misses, evictions int64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return t.hits, t.misses, t.evictions
+}
+
+func (t *TTLCachedNetwork) lookup(q string) (string, bool) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if elem, ok := t.entries[q]; ok {
+		entry := elem.Value.(*ttlCacheEntry)
+		if t.now().Before(entry.Expiry) {
+			t.hits++
+			t.lru.MoveToFront(elem)
+			return entry.Response, true
+		}
+		t.lru.Remove(elem)
+		delete(t.entries, q)
+	}
+	t.misses++
+	return "", false
+}
+
+func (t *TTLCachedNetwork) insert(q, response string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.maxEntries <= 0 {
+		return
+	}
+	entry := &ttlCacheEntry{Query: q, Response: response, Expiry: t.now().Add(t.ttl)}
+	if elem, ok := t.entries[q]; ok {
+		// Another Goroutine computed the same query.
+		elem.Value = entry
+		t.lru.MoveToFront(elem)
+		return
+	}
+	t.entries[q] = t.lru.PushFront(entry)
+	for t.lru.Len() > t.maxEntries {
+		oldest := t.lru.Back()
+		t.lru.Remove(oldest)
+		delete(t.entries, oldest.Value.(*ttlCacheEntry).Query)
+		t.evictions++
+	}
+}
@@ -0,0 +1,68 @@
+package algebrain
+
+import (
+	"testing"
+	"time"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestTTLCachedNetwork(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	cached := net.WithTTLCache(2, time.Minute)
+	now := time.Now()
+	cached.now = func() time.Time {
+		return now
+	}
+
+	checkStats := func(hits, misses, evictions int64) {
+		t.Helper()
+		h, m, e := cached.CacheStats()
+		if h != hits || m != misses || e != evictions {
+			t.Errorf("expected stats %d/%d/%d but got %d/%d/%d", hits, misses, evictions,
+				h, m, e)
+		}
+	}
+
+	queries := []string{"evaluate 1+1", "evaluate 2+2", "evaluate 3+3"}
+	expected := map[string]string{}
+	for _, q := range queries {
+		expected[q] = net.Query(q)
+	}
+
+	if res := cached.Query(queries[0]); res != expected[queries[0]] {
+		t.Errorf("expected %q but got %q", expected[queries[0]], res)
+	}
+	checkStats(0, 1, 0)
+
+	// Hits must not use the network.
+	cached.Network = nil
+	if res := cached.Query(queries[0]); res != expected[queries[0]] {
+		t.Errorf("expected %q but got %q", expected[queries[0]], res)
+	}
+	checkStats(1, 1, 0)
+
+	// After the TTL, the entry is a miss.
+	cached.Network = net
+	now = now.Add(time.Minute)
+	cached.Query(queries[0])
+	checkStats(1, 2, 0)
+
+	// Filling the cache evicts the least recently used
+	// entry.
+	now = now.Add(time.Second)
+	cached.Query(queries[1])
+	cached.Query(queries[0])
+	cached.Query(queries[2])
+	checkStats(2, 4, 1)
+	cached.Network = nil
+	for _, q := range []string{queries[0], queries[2]} {
+		if res := cached.Query(q); res != expected[q] {
+			t.Errorf("expected %q but got %q", expected[q], res)
+		}
+	}
+	checkStats(4, 4, 1)
+	cached.Network = net
+	cached.Query(queries[1])
+	checkStats(4, 5, 2)
+}
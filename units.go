@@ -0,0 +1,121 @@
+package algebrain
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+// DefaultUnitArithmeticMax is the default maximum quantity
+// used by a UnitArithmeticGenerator.
+const DefaultUnitArithmeticMax = 20
+
+// A Unit is a unit of measurement.
+type Unit struct {
+	// Name is the abbreviation used in queries, e.g. "cm".
+	Name string
+
+	// Dimension is the kind of quantity measured by the
+	// unit, e.g. "length".
+	// Units can only be combined with units of the same
+	// dimension.
+	Dimension string
+
+	// Scale is the size of the unit, measured in some
+	// fixed base unit of the dimension.
+	Scale int64
+}
+
+// DefaultUnits contains common units of length, time, and
+// mass.
+var DefaultUnits = []*Unit{
+	{Name: "mm", Dimension: "length", Scale: 1},
+	{Name: "cm", Dimension: "length", Scale: 10},
+	{Name: "m", Dimension: "length", Scale: 1000},
+	{Name: "km", Dimension: "length", Scale: 1000000},
+	{Name: "s", Dimension: "time", Scale: 1},
+	{Name: "min", Dimension: "time", Scale: 60},
+	{Name: "h", Dimension: "time", Scale: 3600},
+	{Name: "mg", Dimension: "mass", Scale: 1},
+	{Name: "g", Dimension: "mass", Scale: 1000},
+	{Name: "kg", Dimension: "mass", Scale: 1000000},
+}
+
+// A UnitArithmeticGenerator generates Samples with queries
+// like "3 m + 200 cm", expecting "Result: 5 m".
+//
+// Results are written in the larger of the two units.
+// The quantity in the smaller unit is always a whole
+// number of larger units, so results are exact integers.
+// Subtractions never have negative results.
+type UnitArithmeticGenerator struct {
+	// Units is the unit table.
+	// If this is nil, DefaultUnits is used.
+	//
+	// Two units are only combined if they have the same
+	// dimension and the larger Scale is a multiple of the
+	// smaller one.
+	Units []*Unit
+
+	// MaxValue is the maximum quantity, measured in the
+	// larger unit of a query.
+	// If this is 0, DefaultUnitArithmeticMax is used.
+	MaxValue int
+}
+
+// Generate generates a unit arithmetic sample.
+func (u *UnitArithmeticGenerator) Generate() *Sample {
+	units := u.Units
+	if units == nil {
+		units = DefaultUnits
+	}
+	max := u.MaxValue
+	if max == 0 {
+		max = DefaultUnitArithmeticMax
+	}
+
+	var pairs [][2]*Unit
+	for _, large := range units {
+		for _, small := range units {
+			if large.Dimension == small.Dimension && small.Scale > 0 &&
+				large.Scale >= small.Scale && large.Scale%small.Scale == 0 {
+				pairs = append(pairs, [2]*Unit{large, small})
+			}
+		}
+	}
+	if len(pairs) == 0 {
+		panic("no compatible units")
+	}
+	pair := pairs[rand.Intn(len(pairs))]
+	large, small := pair[0], pair[1]
+	ratio := large.Scale / small.Scale
+
+	// Each quantity is written in its own unit, but its
+	// value is measured in the larger unit.
+	largeNum := int64(rand.Intn(max + 1))
+	smallNum := int64(rand.Intn(max + 1))
+	quantities := []struct {
+		Text  string
+		Value int64
+	}{
+		{fmt.Sprintf("%d %s", largeNum, large.Name), largeNum},
+		{fmt.Sprintf("%d %s", smallNum*ratio, small.Name), smallNum},
+	}
+	if rand.Intn(2) == 0 {
+		quantities[0], quantities[1] = quantities[1], quantities[0]
+	}
+
+	op := "+"
+	result := quantities[0].Value + quantities[1].Value
+	if rand.Intn(2) == 0 {
+		op = "-"
+		result = quantities[0].Value - quantities[1].Value
+		if result < 0 {
+			quantities[0], quantities[1] = quantities[1], quantities[0]
+			result = -result
+		}
+	}
+	return &Sample{
+		Query:    quantities[0].Text + " " + op + " " + quantities[1].Text,
+		Response: fmt.Sprintf("Result: %d %s", result, large.Name),
+	}
+}
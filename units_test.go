@@ -0,0 +1,63 @@
+package algebrain
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnitArithmeticGenerator(t *testing.T) {
+	gen := &UnitArithmeticGenerator{
+		Units: []*Unit{
+			{Name: "cm", Dimension: "length", Scale: 10},
+			{Name: "m", Dimension: "length", Scale: 1000},
+		},
+		MaxValue: 3,
+	}
+	expected := map[string]string{
+		"3 m + 200 cm": "Result: 5 m",
+		"200 cm + 3 m": "Result: 5 m",
+		"3 m - 200 cm": "Result: 1 m",
+		"300 cm - 2 m": "Result: 1 m",
+		"2 cm + 3 cm":  "Result: 5 cm",
+		"1 m - 1 m":    "Result: 0 m",
+	}
+	seen := map[string]bool{}
+	for i := 0; i < 20000 && len(seen) < len(expected); i++ {
+		sample := gen.Generate()
+		if strings.Contains(sample.Response, "-") {
+			t.Errorf("%s: negative result %q", sample.Query, sample.Response)
+		}
+		if exp, ok := expected[sample.Query]; ok {
+			seen[sample.Query] = true
+			if sample.Response != exp {
+				t.Errorf("%s: expected %q but got %q", sample.Query, exp, sample.Response)
+			}
+		}
+	}
+	if len(seen) < len(expected) {
+		t.Errorf("only saw %d of %d queries", len(seen), len(expected))
+	}
+}
+
+func TestUnitArithmeticIncompatible(t *testing.T) {
+	gen := &UnitArithmeticGenerator{
+		Units: []*Unit{
+			{Name: "m", Dimension: "length", Scale: 2},
+			{Name: "ft", Dimension: "length", Scale: 3},
+			{Name: "s", Dimension: "time", Scale: 1},
+		},
+	}
+	for i := 0; i < 1000; i++ {
+		sample := gen.Generate()
+		fields := strings.Fields(sample.Query)
+		if len(fields) != 5 {
+			t.Fatalf("unexpected query: %s", sample.Query)
+		}
+		if fields[1] != fields[4] {
+			t.Fatalf("incompatible units in query: %s", sample.Query)
+		}
+		if !strings.HasSuffix(sample.Response, " "+fields[1]) {
+			t.Fatalf("unexpected response unit: %s -> %s", sample.Query, sample.Response)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package algebrain
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+// DefaultSelfVerifyRetries is the default number of
+// retries used by a SelfVerifyingGenerator.
+const DefaultSelfVerifyRetries = 10
+
+// A VerifiedSample is a Sample along with the result of
+// checking its response.
+type VerifiedSample struct {
+	*Sample
+
+	Verified bool
+}
+
+// A SelfVerifyingGenerator wraps a Generator and checks
+// that the samples it generates are correct, generating
+// new samples if they are not.
+type SelfVerifyingGenerator struct {
+	Generator Generator
+
+	// Verify checks a sample.
+	// If this is nil, VerifySample is used.
+	Verify func(s *Sample) bool
+
+	// MaxRetries is the number of new samples to try after
+	// the first one fails verification.
+	// If this is 0, DefaultSelfVerifyRetries is used.
+	MaxRetries int
+}
+
+// Generate generates a sample, which may not be verified
+// if every attempt failed.
+func (s *SelfVerifyingGenerator) Generate() *Sample {
+	return s.GenerateVerified().Sample
+}
+
+// GenerateVerified generates a sample and reports whether
+// it was verified.
+//
+// If every attempt fails, the last sample is returned with
+// Verified set to false.
+func (s *SelfVerifyingGenerator) GenerateVerified() *VerifiedSample {
+	verify := s.Verify
+	if verify == nil {
+		verify = VerifySample
+	}
+	retries := s.MaxRetries
+	if retries == 0 {
+		retries = DefaultSelfVerifyRetries
+	}
+	var sample *Sample
+	for i := 0; i <= retries; i++ {
+		sample = s.Generator.Generate()
+		if verify(sample) {
+			return &VerifiedSample{Sample: sample, Verified: true}
+		}
+	}
+	return &VerifiedSample{Sample: sample}
+}
+
+// VerifySample independently checks the response of a
+// sample from a ShiftGenerator, ScaleGenerator,
+// MultiScaleGenerator, EvalGenerator, or MatrixGenerator.
+//
+// Expression responses are compared to the substituted
+// query expression with mathexpr.NumericallyEquivalent.
+// Results of "evaluate" queries are compared to the value
+// of the query expression, rounded to the precision of
+// the response.
+//
+// Samples with other kinds of queries are never verified.
+func VerifySample(s *Sample) bool {
+	switch {
+	case strings.HasPrefix(s.Query, "shift "):
+		return verifySubstitution(s, "shift ", mathexpr.SubtractOp)
+	case strings.HasPrefix(s.Query, "scale "):
+		return verifySubstitution(s, "scale ", mathexpr.MultiplyOp)
+	case strings.HasPrefix(s.Query, "evaluate "):
+		return verifyEvaluation(s)
+	case strings.HasPrefix(s.Query, "determinant of "):
+		return verifyMatrix(s, "determinant of ", matrixDeterminant)
+	case strings.HasPrefix(s.Query, "trace of "):
+		return verifyMatrix(s, "trace of ", matrixTrace)
+	}
+	return false
+}
+
+// verifySubstitution checks queries like "shift x by 2 and
+// y by 3 in x*y", where each variable v is replaced by
+// (v op amount).
+func verifySubstitution(s *Sample, prefix, op string) bool {
+	parts := strings.SplitN(strings.TrimPrefix(s.Query, prefix), " in ", 2)
+	if len(parts) != 2 {
+		return false
+	}
+	expected, err := mathexpr.ParseString(parts[1])
+	if err != nil {
+		return false
+	}
+	for _, sub := range strings.Split(parts[0], " and ") {
+		fields := strings.SplitN(sub, " by ", 2)
+		if len(fields) != 2 {
+			return false
+		}
+		amount, err := mathexpr.ParseString(fields[1])
+		if err != nil {
+			return false
+		}
+		expected = substituteNode(expected, fields[0], &mathexpr.BinaryOp{
+			Op:    op,
+			Left:  mathexpr.RawNode(fields[0]),
+			Right: amount,
+		})
+	}
+	actual, err := mathexpr.ParseString(s.Response)
+	if err != nil {
+		return false
+	}
+	return mathexpr.NumericallyEquivalent(expected, actual)
+}
+
+func verifyEvaluation(s *Sample) bool {
+	if !strings.HasPrefix(s.Response, "Result: ") {
+		return false
+	}
+	resultStr := strings.TrimPrefix(s.Response, "Result: ")
+	if _, err := strconv.ParseFloat(resultStr, 64); err != nil {
+		return false
+	}
+	expr, err := mathexpr.ParseString(strings.TrimPrefix(s.Query, "evaluate "))
+	if err != nil {
+		return false
+	}
+	expected, err := mathexpr.Evaluate(expr, nil)
+	if err != nil || math.IsNaN(expected) || math.IsInf(expected, 0) {
+		return false
+	}
+	var prec int
+	if idx := strings.Index(resultStr, "."); idx >= 0 {
+		prec = len(resultStr) - (idx + 1)
+	}
+	return strconv.FormatFloat(expected, 'f', prec, 64) == resultStr
+}
+
+func verifyMatrix(s *Sample, prefix string, f func([][]int) int) bool {
+	matrix, err := parseMatrix(strings.TrimPrefix(s.Query, prefix))
+	if err != nil {
+		return false
+	}
+	return s.Response == "Result: "+strconv.Itoa(f(matrix))
+}
@@ -0,0 +1,66 @@
+package algebrain
+
+import (
+	"testing"
+
+	"github.com/unixpickle/algebrain/mathexpr"
+)
+
+type buggyGenerator struct {
+	Calls int
+}
+
+func (b *buggyGenerator) Generate() *Sample {
+	b.Calls++
+	return &Sample{Query: "scale x by 2 in x^2", Response: "2*x^2"}
+}
+
+func TestSelfVerifyingGeneratorRetries(t *testing.T) {
+	inner := &buggyGenerator{}
+	gen := &SelfVerifyingGenerator{Generator: inner, MaxRetries: 3}
+	sample := gen.GenerateVerified()
+	if sample.Verified {
+		t.Error("buggy sample should not be verified")
+	}
+	if inner.Calls != 4 {
+		t.Errorf("expected 4 attempts but got %d", inner.Calls)
+	}
+	if sample.Response != "2*x^2" {
+		t.Errorf("unexpected response: %s", sample.Response)
+	}
+}
+
+func TestVerifySample(t *testing.T) {
+	exprGen := &mathexpr.Generator{NoReals: true, VarNames: []string{"x", "y"}}
+	gens := []Generator{
+		&ShiftGenerator{Generator: exprGen, MaxDepth: 3},
+		&ShiftGenerator{Generator: exprGen, MaxDepth: 3, ShiftAllVars: true},
+		&ScaleGenerator{Generator: exprGen, MaxDepth: 3},
+		&MultiScaleGenerator{Generator: exprGen, MaxDepth: 3},
+		&EvalGenerator{Generator: &mathexpr.Generator{NoReals: true}, MaxDepth: 3,
+			AllInts: true},
+		&MatrixGenerator{Allow3x3: true},
+	}
+	for i, gen := range gens {
+		for j := 0; j < 100; j++ {
+			sample := gen.Generate()
+			if !VerifySample(sample) {
+				t.Fatalf("generator %d: failed to verify %q -> %q", i, sample.Query,
+					sample.Response)
+			}
+		}
+	}
+
+	for _, sample := range []*Sample{
+		{Query: "shift x by 2 in x^2", Response: "(x+2)^2"},
+		{Query: "scale x by 3 in x+1", Response: "(x*3)+3"},
+		{Query: "evaluate 2*3+1", Response: "Result: 8"},
+		{Query: "determinant of [[1,2],[3,4]]", Response: "Result: 2"},
+		{Query: "trace of [[1,2],[3]]", Response: "Result: 1"},
+		{Query: "3 m + 200 cm", Response: "Result: 5 m"},
+	} {
+		if VerifySample(sample) {
+			t.Errorf("incorrectly verified %q -> %q", sample.Query, sample.Response)
+		}
+	}
+}
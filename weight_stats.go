@@ -0,0 +1,56 @@
+package algebrain
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/unixpickle/anyvec"
+)
+
+// ParamStats summarizes the entries of a parameter.
+type ParamStats struct {
+	Min    float64
+	Max    float64
+	Mean   float64
+	StdDev float64
+	L2Norm float64
+}
+
+// String returns a one-line summary of the statistics.
+func (p *ParamStats) String() string {
+	return fmt.Sprintf("min=%f max=%f mean=%f stddev=%f l2=%f", p.Min, p.Max,
+		p.Mean, p.StdDev, p.L2Norm)
+}
+
+// WeightStats computes summary statistics for each of the
+// network's NamedParameters.
+//
+// This is useful for spotting exploding, vanishing, or
+// uninitialized weights.
+func (n *Network) WeightStats() map[string]*ParamStats {
+	res := map[string]*ParamStats{}
+	for name, param := range n.NamedParameters() {
+		res[name] = vectorStats(param.Vector)
+	}
+	return res
+}
+
+func vectorStats(v anyvec.Vector) *ParamStats {
+	values := vectorFloats(v)
+	if len(values) == 0 {
+		return &ParamStats{}
+	}
+	res := &ParamStats{Min: math.Inf(1), Max: math.Inf(-1)}
+	var sum, sqSum float64
+	for _, x := range values {
+		res.Min = math.Min(res.Min, x)
+		res.Max = math.Max(res.Max, x)
+		sum += x
+		sqSum += x * x
+	}
+	count := float64(len(values))
+	res.Mean = sum / count
+	res.StdDev = math.Sqrt(math.Max(0, sqSum/count-res.Mean*res.Mean))
+	res.L2Norm = math.Sqrt(sqSum)
+	return res
+}
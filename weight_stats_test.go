@@ -0,0 +1,39 @@
+package algebrain
+
+import (
+	"math"
+	"testing"
+
+	"github.com/unixpickle/anyvec/anyvec32"
+)
+
+func TestWeightStats(t *testing.T) {
+	net := NewNetwork(anyvec32.CurrentCreator(), &CharTokenizer{})
+	stats := net.WeightStats()
+	if len(stats) != len(net.Parameters()) {
+		t.Fatalf("expected %d stats but got %d", len(net.Parameters()), len(stats))
+	}
+	var anyVariance bool
+	for name, s := range stats {
+		for _, x := range []float64{s.Min, s.Max, s.Mean, s.StdDev, s.L2Norm} {
+			if math.IsNaN(x) || math.IsInf(x, 0) {
+				t.Fatalf("%s: non-finite statistic: %s", name, s)
+			}
+		}
+		if s.Min > s.Mean+1e-5 || s.Mean > s.Max+1e-5 {
+			t.Errorf("%s: mean out of range: %s", name, s)
+		}
+		if s.StdDev < 0 || s.L2Norm < 0 {
+			t.Errorf("%s: negative statistic: %s", name, s)
+		}
+		if s.StdDev > 10 {
+			t.Errorf("%s: unexpected initialization: %s", name, s)
+		}
+		if s.StdDev > 0 {
+			anyVariance = true
+		}
+	}
+	if !anyVariance {
+		t.Error("all parameters are constant")
+	}
+}
@@ -0,0 +1,120 @@
+package algebrain
+
+import (
+	"math/rand"
+	"strconv"
+	"strings"
+)
+
+// DefaultWordedEvalMax is the default maximum operand for
+// a WordedEvalGenerator.
+const DefaultWordedEvalMax = 100
+
+// MaxEnglishNumber is the largest number supported by
+// EnglishNumber.
+const MaxEnglishNumber = 9999
+
+var (
+	englishOnes = []string{"zero", "one", "two", "three", "four", "five", "six", "seven",
+		"eight", "nine", "ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen",
+		"sixteen", "seventeen", "eighteen", "nineteen"}
+	englishTens = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty",
+		"seventy", "eighty", "ninety"}
+)
+
+// A NumberWordStyle controls how EnglishNumber writes
+// numbers.
+type NumberWordStyle struct {
+	// NoHyphens separates tens and ones with a space, as in
+	// "thirty four", rather than a hyphen.
+	NoHyphens bool
+
+	// HundredAnd inserts "and" before the last two digits,
+	// as in "one hundred and five" or "two thousand and
+	// ten".
+	HundredAnd bool
+}
+
+// EnglishNumber writes a number between 0 and
+// MaxEnglishNumber in English words, e.g. "three thousand
+// four hundred twelve".
+//
+// It panics if n is out of range.
+func EnglishNumber(n int, style NumberWordStyle) string {
+	if n < 0 || n > MaxEnglishNumber {
+		panic("number out of range: " + strconv.Itoa(n))
+	}
+	var words []string
+	if n >= 1000 {
+		words = append(words, englishOnes[n/1000], "thousand")
+		n %= 1000
+	}
+	if n >= 100 {
+		words = append(words, englishOnes[n/100], "hundred")
+		n %= 100
+	}
+	if n == 0 && len(words) > 0 {
+		return strings.Join(words, " ")
+	}
+	if len(words) > 0 && style.HundredAnd {
+		words = append(words, "and")
+	}
+	if n < len(englishOnes) {
+		words = append(words, englishOnes[n])
+	} else if n%10 == 0 {
+		words = append(words, englishTens[n/10])
+	} else if style.NoHyphens {
+		words = append(words, englishTens[n/10], englishOnes[n%10])
+	} else {
+		words = append(words, englishTens[n/10]+"-"+englishOnes[n%10])
+	}
+	return strings.Join(words, " ")
+}
+
+// A WordedEvalGenerator generates Samples with arithmetic
+// queries written in words, like "what is twelve plus
+// thirty-four", expecting "Result: 46".
+//
+// Responses use the same format as an EvalGenerator with
+// AllInts set.
+// Divisions are always exact.
+type WordedEvalGenerator struct {
+	// Max is the maximum operand, which must not exceed
+	// MaxEnglishNumber.
+	// If this is 0, DefaultWordedEvalMax is used.
+	Max int
+
+	// Style determines how the operands are written.
+	Style NumberWordStyle
+}
+
+// Generate generates a worded arithmetic sample.
+func (w *WordedEvalGenerator) Generate() *Sample {
+	max := w.Max
+	if max == 0 {
+		max = DefaultWordedEvalMax
+	}
+	var a, b, result int
+	var op string
+	switch rand.Intn(4) {
+	case 0:
+		a, b = rand.Intn(max+1), rand.Intn(max+1)
+		op, result = "plus", a+b
+	case 1:
+		a, b = rand.Intn(max+1), rand.Intn(max+1)
+		op, result = "minus", a-b
+	case 2:
+		a, b = rand.Intn(max+1), rand.Intn(max+1)
+		op, result = "times", a*b
+	default:
+		b = rand.Intn(max) + 1
+		result = rand.Intn(max/b + 1)
+		a = b * result
+		op = "divided by"
+	}
+	return &Sample{
+		Query: "what is " + EnglishNumber(a, w.Style) + " " + op + " " +
+			EnglishNumber(b, w.Style),
+		Response: "Result: " + strconv.Itoa(result),
+	}
+}
@@ -0,0 +1,126 @@
+package algebrain
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestEnglishNumber(t *testing.T) {
+	cases := []struct {
+		N        int
+		Style    NumberWordStyle
+		Expected string
+	}{
+		{0, NumberWordStyle{}, "zero"},
+		{7, NumberWordStyle{}, "seven"},
+		{10, NumberWordStyle{}, "ten"},
+		{13, NumberWordStyle{}, "thirteen"},
+		{19, NumberWordStyle{}, "nineteen"},
+		{20, NumberWordStyle{}, "twenty"},
+		{21, NumberWordStyle{}, "twenty-one"},
+		{21, NumberWordStyle{NoHyphens: true}, "twenty one"},
+		{40, NumberWordStyle{}, "forty"},
+		{99, NumberWordStyle{}, "ninety-nine"},
+		{100, NumberWordStyle{}, "one hundred"},
+		{100, NumberWordStyle{HundredAnd: true}, "one hundred"},
+		{105, NumberWordStyle{}, "one hundred five"},
+		{105, NumberWordStyle{HundredAnd: true}, "one hundred and five"},
+		{115, NumberWordStyle{}, "one hundred fifteen"},
+		{342, NumberWordStyle{}, "three hundred forty-two"},
+		{342, NumberWordStyle{HundredAnd: true, NoHyphens: true},
+			"three hundred and forty two"},
+		{1000, NumberWordStyle{}, "one thousand"},
+		{2010, NumberWordStyle{}, "two thousand ten"},
+		{2010, NumberWordStyle{HundredAnd: true}, "two thousand and ten"},
+		{3412, NumberWordStyle{}, "three thousand four hundred twelve"},
+		{9999, NumberWordStyle{HundredAnd: true},
+			"nine thousand nine hundred and ninety-nine"},
+	}
+	for _, c := range cases {
+		if actual := EnglishNumber(c.N, c.Style); actual != c.Expected {
+			t.Errorf("%d (%+v): expected %q but got %q", c.N, c.Style, c.Expected, actual)
+		}
+	}
+
+	for _, n := range []int{-1, MaxEnglishNumber + 1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic for %d", n)
+				}
+			}()
+			EnglishNumber(n, NumberWordStyle{})
+		}()
+	}
+}
+
+func TestEnglishNumberUnique(t *testing.T) {
+	for _, style := range []NumberWordStyle{{}, {NoHyphens: true, HundredAnd: true}} {
+		seen := map[string]int{}
+		for i := 0; i <= MaxEnglishNumber; i++ {
+			words := EnglishNumber(i, style)
+			if j, ok := seen[words]; ok {
+				t.Fatalf("%d and %d are both %q", i, j, words)
+			}
+			seen[words] = i
+		}
+	}
+}
+
+func TestWordedEvalGenerator(t *testing.T) {
+	gen := &WordedEvalGenerator{Max: 30, Style: NumberWordStyle{NoHyphens: true}}
+	numbers := map[string]int{}
+	for i := 0; i <= gen.Max; i++ {
+		numbers[EnglishNumber(i, gen.Style)] = i
+	}
+	ops := []struct {
+		Word  string
+		Apply func(a, b int) int
+	}{
+		{" plus ", func(a, b int) int { return a + b }},
+		{" minus ", func(a, b int) int { return a - b }},
+		{" times ", func(a, b int) int { return a * b }},
+		{" divided by ", func(a, b int) int { return a / b }},
+	}
+	seenOps := map[string]bool{}
+	for i := 0; i < 1000; i++ {
+		sample := gen.Generate()
+		if err := sample.Validate(); err != nil {
+			t.Fatal(err)
+		}
+		if !strings.HasPrefix(sample.Query, "what is ") {
+			t.Fatalf("unexpected query: %q", sample.Query)
+		}
+		body := strings.TrimPrefix(sample.Query, "what is ")
+		var matched bool
+		for _, op := range ops {
+			parts := strings.Split(body, op.Word)
+			if len(parts) != 2 {
+				continue
+			}
+			a, ok1 := numbers[parts[0]]
+			b, ok2 := numbers[parts[1]]
+			if !ok1 || !ok2 {
+				continue
+			}
+			if op.Word == " divided by " && (b == 0 || a%b != 0) {
+				t.Fatalf("inexact division: %q", sample.Query)
+			}
+			expected := "Result: " + strconv.Itoa(op.Apply(a, b))
+			if sample.Response != expected {
+				t.Fatalf("query %q: expected %q but got %q", sample.Query, expected,
+					sample.Response)
+			}
+			seenOps[op.Word] = true
+			matched = true
+			break
+		}
+		if !matched {
+			t.Fatalf("unparsable query: %q", sample.Query)
+		}
+	}
+	if len(seenOps) != len(ops) {
+		t.Errorf("only saw operations %v", seenOps)
+	}
+}